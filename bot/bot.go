@@ -15,6 +15,7 @@ import (
 	"golang.org/x/time/rate"
 
 	"github.com/gsmcwhirter/discord-bot-lib/v24/bot/session"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/commandsync"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/errreport"
@@ -49,6 +50,10 @@ type Config struct {
 	BotPresence string
 
 	GlobalSlashCommands []entity.ApplicationCommand
+
+	// CommandCacheFile is where the hashes of the last synced commands are kept; if empty, they
+	// are only kept in memory (and every start fetches the registered commands once)
+	CommandCacheFile string
 }
 
 // HBReconfig
@@ -59,8 +64,9 @@ type hbReconfig struct {
 
 // DiscordBot is the actal bot
 type DiscordBot struct {
-	config Config
-	deps   dependencies
+	config   Config
+	deps     dependencies
+	commands *commandsync.Syncer
 
 	permissions int
 	intents     int
@@ -89,6 +95,11 @@ func NewDiscordBot(deps dependencies, conf Config, permissions, intents int) *Di
 		lastSequence: -1,
 	}
 
+	d.commands = commandsync.NewSyncer(deps, deps.DiscordJSONClient(), conf.ClientID)
+	if conf.CommandCacheFile != "" {
+		d.commands.SetCache(commandsync.NewFileCache(conf.CommandCacheFile))
+	}
+
 	d.deps.Dispatcher().ConnectToBot(d)
 
 	return d
@@ -154,35 +165,44 @@ func (d *DiscordBot) AuthenticateAndConnect() error {
 }
 
 // ErrDuplicateCommand represents having multiple commands with the same name
-var ErrDuplicateCommand = errors.New("duplicate command")
+var ErrDuplicateCommand = commandsync.ErrDuplicateCommand
 
 // RegisterGlobalCommands registers the global bot commands with discord
+//
+// Only the commands that changed since they were last registered are created, edited or deleted
+// (see commandsync.Syncer)
 func (d *DiscordBot) RegisterGlobalCommands(ctx context.Context) error {
 	ctx, span := d.deps.Telemetry().StartSpan(ctx, "bot", "RegisterGlobalCommands")
 	defer span.End()
 
 	logger := logging.WithContext(ctx, d.deps.Logger())
-	c := d.deps.DiscordJSONClient()
 
 	level.Debug(logger).Message("starting global command registration")
-	if _, err := c.BulkOverwriteGlobalCommands(ctx, d.config.ClientID, d.config.GlobalSlashCommands); err != nil {
-		return errors.Wrap(err, "could not BulkOverwriteGlobalCommands")
+	if _, _, err := d.commands.SyncGlobal(ctx, d.config.GlobalSlashCommands); err != nil {
+		return errors.Wrap(err, "could not sync global commands")
 	}
 
 	return nil
 }
 
 // RegisterGuildCommands registers the guild-specific commands for a guild with discord
+//
+// Only the commands that changed since they were last registered are created, edited or deleted. The
+// registered commands (with their ids) are returned, even if nothing changed
 func (d *DiscordBot) RegisterGuildCommands(ctx context.Context, gid snowflake.Snowflake, cmds []entity.ApplicationCommand) ([]entity.ApplicationCommand, error) {
 	ctx, span := d.deps.Telemetry().StartSpan(ctx, "bot", "RegisterGuildCommands", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
 	defer span.End()
 
 	logger := logging.WithContext(ctx, d.deps.Logger())
-	c := d.deps.DiscordJSONClient()
 
 	level.Debug(logger).Message("starting guild command registration", "gid", gid, "cmds", fmt.Sprintf("%#v", cmds))
-	learned, err := c.BulkOverwriteGuildCommands(ctx, d.config.ClientID, gid, cmds)
-	return learned, errors.Wrap(err, "could not BulkOverwriteGuildCommands", "gid", gid.ToString())
+	_, learned, err := d.commands.SyncGuild(ctx, gid, cmds)
+	return learned, errors.Wrap(err, "could not sync guild commands", "gid", gid.ToString())
+}
+
+// CommandSyncer returns the syncer that registers the bot commands, for planning changes without applying them
+func (d *DiscordBot) CommandSyncer() *commandsync.Syncer {
+	return d.commands
 }
 
 // ReconfigureHeartbeat re-configures the heartbeat ticker
@@ -0,0 +1,448 @@
+package session
+
+import (
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// These are the names of the change events generated from session updates
+const (
+	EventMemberAdded               = "MEMBER_ADDED"
+	EventMemberUpdated             = "MEMBER_UPDATED"
+	EventMemberRemoved             = "MEMBER_REMOVED"
+	EventMemberNickChanged         = "MEMBER_NICK_CHANGED"
+	EventMemberRolesChanged        = "MEMBER_ROLES_CHANGED"
+	EventRoleCreated               = "ROLE_CREATED"
+	EventRoleUpdated               = "ROLE_UPDATED"
+	EventRoleDeleted               = "ROLE_DELETED"
+	EventRolePermissionsChanged    = "ROLE_PERMISSIONS_CHANGED"
+	EventChannelCreated            = "CHANNEL_CREATED"
+	EventChannelUpdated            = "CHANNEL_UPDATED"
+	EventChannelDeleted            = "CHANNEL_DELETED"
+	EventChannelRenamed            = "CHANNEL_RENAMED"
+	EventChannelPermissionsChanged = "CHANNEL_PERMISSIONS_CHANGED"
+)
+
+// ChangeEvent is a typed notification that some part of the session state changed
+type ChangeEvent interface {
+	EventName() string
+	GuildID() snowflake.Snowflake
+}
+
+// MemberDiff describes the change to a guild member caused by a session update
+//
+// Old is the zero value when Created or OldUnknown is true, and New is the zero value when Removed is true.
+// OldUnknown is set for an update to a member that was not cached (common in large guilds), in which
+// case only a MemberUpdated event is generated
+type MemberDiff struct {
+	Guild      snowflake.Snowflake
+	Old        GuildMember
+	New        GuildMember
+	Created    bool
+	Removed    bool
+	OldUnknown bool
+}
+
+// NickChanged determines if the nickname of the member changed
+func (d MemberDiff) NickChanged() bool {
+	return !d.Created && !d.Removed && !d.OldUnknown && d.Old.nick != d.New.nick
+}
+
+// RolesAdded returns the ids of the roles the member gained
+func (d MemberDiff) RolesAdded() []snowflake.Snowflake {
+	if d.OldUnknown {
+		return nil
+	}
+
+	return snowflakesMissing(d.New.roles, d.Old.roles)
+}
+
+// RolesRemoved returns the ids of the roles the member lost
+func (d MemberDiff) RolesRemoved() []snowflake.Snowflake {
+	if d.OldUnknown {
+		return nil
+	}
+
+	return snowflakesMissing(d.Old.roles, d.New.roles)
+}
+
+// Changed determines if the diff represents any change at all
+func (d MemberDiff) Changed() bool {
+	if d.Created || d.Removed || d.OldUnknown {
+		return true
+	}
+
+	return d.NickChanged() || len(d.RolesAdded()) > 0 || len(d.RolesRemoved()) > 0 || d.Old.user != d.New.user
+}
+
+// Events generates the change events described by the diff
+func (d MemberDiff) Events() []ChangeEvent {
+	switch {
+	case d.Created:
+		return []ChangeEvent{MemberAdded{Guild: d.Guild, Member: d.New}}
+	case d.Removed:
+		return []ChangeEvent{MemberRemoved{Guild: d.Guild, Member: d.Old}}
+	case d.OldUnknown:
+		return []ChangeEvent{MemberUpdated{Guild: d.Guild, New: d.New, OldUnknown: true}}
+	case !d.Changed():
+		return nil
+	}
+
+	evts := []ChangeEvent{MemberUpdated{Guild: d.Guild, Old: d.Old, New: d.New}}
+
+	if d.NickChanged() {
+		evts = append(evts, MemberNickChanged{Guild: d.Guild, Old: d.Old, New: d.New})
+	}
+
+	added, removed := d.RolesAdded(), d.RolesRemoved()
+	if len(added) > 0 || len(removed) > 0 {
+		evts = append(evts, MemberRolesChanged{Guild: d.Guild, Old: d.Old, New: d.New, Added: added, Removed: removed})
+	}
+
+	return evts
+}
+
+// RoleDiff describes the change to a guild role caused by a session update
+//
+// Old is the zero value when Created is true, and New is the zero value when Deleted is true.
+// Members holds the changes to the members who lost a deleted role
+type RoleDiff struct {
+	Guild   snowflake.Snowflake
+	Old     Role
+	New     Role
+	Created bool
+	Deleted bool
+	Members []MemberDiff
+}
+
+// PermissionsChanged determines if the permissions of the role changed
+func (d RoleDiff) PermissionsChanged() bool {
+	return !d.Created && !d.Deleted && d.Old.permissions != d.New.permissions
+}
+
+// Changed determines if the diff represents any change at all
+func (d RoleDiff) Changed() bool {
+	return d.Created || d.Deleted || d.Old != d.New
+}
+
+// Events generates the change events described by the diff
+func (d RoleDiff) Events() []ChangeEvent {
+	switch {
+	case d.Created:
+		return []ChangeEvent{RoleCreated{Guild: d.Guild, Role: d.New}}
+	case d.Deleted:
+		evts := []ChangeEvent{RoleDeleted{Guild: d.Guild, Role: d.Old}}
+		for _, md := range d.Members {
+			evts = append(evts, md.Events()...)
+		}
+		return evts
+	case !d.Changed():
+		return nil
+	}
+
+	evts := []ChangeEvent{RoleUpdated{Guild: d.Guild, Old: d.Old, New: d.New}}
+
+	if d.PermissionsChanged() {
+		evts = append(evts, RolePermissionsChanged{
+			Guild:   d.Guild,
+			Old:     d.Old,
+			New:     d.New,
+			Granted: d.New.permissions &^ d.Old.permissions,
+			Revoked: d.Old.permissions &^ d.New.permissions,
+		})
+	}
+
+	return evts
+}
+
+// ChannelDiff describes the change to a channel caused by a session update
+//
+// Old is the zero value when Created is true, and New is the zero value when Deleted is true
+type ChannelDiff struct {
+	Guild   snowflake.Snowflake
+	Old     Channel
+	New     Channel
+	Created bool
+	Deleted bool
+}
+
+// ChannelID returns the id of the channel the diff is about
+func (d ChannelDiff) ChannelID() snowflake.Snowflake {
+	if d.New.id != 0 {
+		return d.New.id
+	}
+
+	return d.Old.id
+}
+
+// Renamed determines if the name of the channel changed
+func (d ChannelDiff) Renamed() bool {
+	return !d.Created && !d.Deleted && d.Old.name != d.New.name
+}
+
+// PermissionsChanged determines if the permission overwrites of the channel changed
+func (d ChannelDiff) PermissionsChanged() bool {
+	if d.Created || d.Deleted {
+		return false
+	}
+
+	if len(d.Old.overwrites) != len(d.New.overwrites) {
+		return true
+	}
+
+	old := make(map[snowflake.Snowflake]PermissionOverwrite, len(d.Old.overwrites))
+	for _, ow := range d.Old.overwrites {
+		old[ow.id] = ow
+	}
+
+	for _, ow := range d.New.overwrites {
+		if old[ow.id] != ow {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Changed determines if the diff represents any change at all
+func (d ChannelDiff) Changed() bool {
+	if d.Created || d.Deleted {
+		return true
+	}
+
+	return d.Renamed() || d.PermissionsChanged() ||
+		d.Old.topic != d.New.topic ||
+		d.Old.position != d.New.position ||
+		d.Old.parentID != d.New.parentID ||
+		d.Old.channelType != d.New.channelType ||
+		d.Old.thread != d.New.thread
+}
+
+// Events generates the change events described by the diff
+func (d ChannelDiff) Events() []ChangeEvent {
+	switch {
+	case d.Created:
+		return []ChangeEvent{ChannelCreated{Guild: d.Guild, Channel: d.New}}
+	case d.Deleted:
+		return []ChangeEvent{ChannelDeleted{Guild: d.Guild, Channel: d.Old}}
+	case !d.Changed():
+		return nil
+	}
+
+	evts := []ChangeEvent{ChannelUpdated{Guild: d.Guild, Old: d.Old, New: d.New}}
+
+	if d.Renamed() {
+		evts = append(evts, ChannelRenamed{Guild: d.Guild, Old: d.Old, New: d.New})
+	}
+
+	if d.PermissionsChanged() {
+		evts = append(evts, ChannelPermissionsChanged{Guild: d.Guild, Old: d.Old, New: d.New})
+	}
+
+	return evts
+}
+
+// MemberAdded is the event generated when a member joins a guild
+type MemberAdded struct {
+	Guild  snowflake.Snowflake
+	Member GuildMember
+}
+
+// EventName returns the name of the event
+func (e MemberAdded) EventName() string { return EventMemberAdded }
+
+// GuildID returns the id of the guild the event occurred in
+func (e MemberAdded) GuildID() snowflake.Snowflake { return e.Guild }
+
+// MemberUpdated is the event generated when any data about a guild member changes
+//
+// If OldUnknown is true, the member was not cached before the update and Old is the zero value
+type MemberUpdated struct {
+	Guild      snowflake.Snowflake
+	Old        GuildMember
+	New        GuildMember
+	OldUnknown bool
+}
+
+// EventName returns the name of the event
+func (e MemberUpdated) EventName() string { return EventMemberUpdated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e MemberUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// MemberRemoved is the event generated when a member leaves (or is removed from) a guild
+type MemberRemoved struct {
+	Guild  snowflake.Snowflake
+	Member GuildMember
+}
+
+// EventName returns the name of the event
+func (e MemberRemoved) EventName() string { return EventMemberRemoved }
+
+// GuildID returns the id of the guild the event occurred in
+func (e MemberRemoved) GuildID() snowflake.Snowflake { return e.Guild }
+
+// MemberNickChanged is the event generated when the nickname of a guild member changes
+type MemberNickChanged struct {
+	Guild snowflake.Snowflake
+	Old   GuildMember
+	New   GuildMember
+}
+
+// EventName returns the name of the event
+func (e MemberNickChanged) EventName() string { return EventMemberNickChanged }
+
+// GuildID returns the id of the guild the event occurred in
+func (e MemberNickChanged) GuildID() snowflake.Snowflake { return e.Guild }
+
+// MemberRolesChanged is the event generated when a guild member gains or loses roles
+type MemberRolesChanged struct {
+	Guild   snowflake.Snowflake
+	Old     GuildMember
+	New     GuildMember
+	Added   []snowflake.Snowflake
+	Removed []snowflake.Snowflake
+}
+
+// EventName returns the name of the event
+func (e MemberRolesChanged) EventName() string { return EventMemberRolesChanged }
+
+// GuildID returns the id of the guild the event occurred in
+func (e MemberRolesChanged) GuildID() snowflake.Snowflake { return e.Guild }
+
+// RoleCreated is the event generated when a role is created in a guild
+type RoleCreated struct {
+	Guild snowflake.Snowflake
+	Role  Role
+}
+
+// EventName returns the name of the event
+func (e RoleCreated) EventName() string { return EventRoleCreated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e RoleCreated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// RoleUpdated is the event generated when any data about a role changes
+type RoleUpdated struct {
+	Guild snowflake.Snowflake
+	Old   Role
+	New   Role
+}
+
+// EventName returns the name of the event
+func (e RoleUpdated) EventName() string { return EventRoleUpdated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e RoleUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// RoleDeleted is the event generated when a role is deleted from a guild
+type RoleDeleted struct {
+	Guild snowflake.Snowflake
+	Role  Role
+}
+
+// EventName returns the name of the event
+func (e RoleDeleted) EventName() string { return EventRoleDeleted }
+
+// GuildID returns the id of the guild the event occurred in
+func (e RoleDeleted) GuildID() snowflake.Snowflake { return e.Guild }
+
+// RolePermissionsChanged is the event generated when the permissions of a role change
+//
+// Granted and Revoked are the permission bits that were turned on and off, respectively
+type RolePermissionsChanged struct {
+	Guild   snowflake.Snowflake
+	Old     Role
+	New     Role
+	Granted int64
+	Revoked int64
+}
+
+// EventName returns the name of the event
+func (e RolePermissionsChanged) EventName() string { return EventRolePermissionsChanged }
+
+// GuildID returns the id of the guild the event occurred in
+func (e RolePermissionsChanged) GuildID() snowflake.Snowflake { return e.Guild }
+
+// ChannelCreated is the event generated when a channel is created
+type ChannelCreated struct {
+	Guild   snowflake.Snowflake
+	Channel Channel
+}
+
+// EventName returns the name of the event
+func (e ChannelCreated) EventName() string { return EventChannelCreated }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e ChannelCreated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// ChannelUpdated is the event generated when any data about a channel changes
+type ChannelUpdated struct {
+	Guild snowflake.Snowflake
+	Old   Channel
+	New   Channel
+}
+
+// EventName returns the name of the event
+func (e ChannelUpdated) EventName() string { return EventChannelUpdated }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e ChannelUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// ChannelDeleted is the event generated when a channel is deleted
+type ChannelDeleted struct {
+	Guild   snowflake.Snowflake
+	Channel Channel
+}
+
+// EventName returns the name of the event
+func (e ChannelDeleted) EventName() string { return EventChannelDeleted }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e ChannelDeleted) GuildID() snowflake.Snowflake { return e.Guild }
+
+// ChannelRenamed is the event generated when the name of a channel changes
+type ChannelRenamed struct {
+	Guild snowflake.Snowflake
+	Old   Channel
+	New   Channel
+}
+
+// EventName returns the name of the event
+func (e ChannelRenamed) EventName() string { return EventChannelRenamed }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e ChannelRenamed) GuildID() snowflake.Snowflake { return e.Guild }
+
+// ChannelPermissionsChanged is the event generated when the permission overwrites of a channel change
+type ChannelPermissionsChanged struct {
+	Guild snowflake.Snowflake
+	Old   Channel
+	New   Channel
+}
+
+// EventName returns the name of the event
+func (e ChannelPermissionsChanged) EventName() string { return EventChannelPermissionsChanged }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e ChannelPermissionsChanged) GuildID() snowflake.Snowflake { return e.Guild }
+
+// snowflakesMissing returns the values in a that are not present in b
+func snowflakesMissing(a, b []snowflake.Snowflake) []snowflake.Snowflake {
+	var missing []snowflake.Snowflake
+
+	for _, sa := range a {
+		found := false
+		for _, sb := range b {
+			if sa == sb {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, sa)
+		}
+	}
+
+	return missing
+}
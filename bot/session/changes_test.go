@@ -0,0 +1,400 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func eventNames(evts []ChangeEvent) []string {
+	names := make([]string, 0, len(evts))
+	for _, evt := range evts {
+		names = append(names, evt.EventName())
+	}
+	return names
+}
+
+func TestMemberDiff_Events(t *testing.T) {
+	t.Parallel()
+
+	ann := GuildMember{id: 7, user: User{id: 7, username: "ann"}, nick: "Ann", roles: []snowflake.Snowflake{1, 2}}
+
+	with := func(f func(m *GuildMember)) GuildMember {
+		m := ann
+		m.roles = append([]snowflake.Snowflake(nil), ann.roles...)
+		f(&m)
+		return m
+	}
+
+	tests := []struct {
+		name        string
+		diff        MemberDiff
+		wantChanged bool
+		wantEvents  []string
+		wantAdded   []snowflake.Snowflake
+		wantRemoved []snowflake.Snowflake
+	}{
+		{
+			name:        "joined",
+			diff:        MemberDiff{Guild: 1, New: ann, Created: true},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberAdded},
+			wantAdded:   []snowflake.Snowflake{1, 2},
+		},
+		{
+			name:        "left",
+			diff:        MemberDiff{Guild: 1, Old: ann, Removed: true},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberRemoved},
+			wantRemoved: []snowflake.Snowflake{1, 2},
+		},
+		{
+			name:       "no change",
+			diff:       MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) {})},
+			wantEvents: []string{},
+		},
+		{
+			name:        "nick changed",
+			diff:        MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.nick = "Annie" })},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated, EventMemberNickChanged},
+		},
+		{
+			name:        "nick cleared",
+			diff:        MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.nick = "" })},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated, EventMemberNickChanged},
+		},
+		{
+			name:        "role added",
+			diff:        MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.roles = append(m.roles, 3) })},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated, EventMemberRolesChanged},
+			wantAdded:   []snowflake.Snowflake{3},
+		},
+		{
+			name:        "role removed",
+			diff:        MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.roles = m.roles[:1] })},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated, EventMemberRolesChanged},
+			wantRemoved: []snowflake.Snowflake{2},
+		},
+		{
+			name:       "roles reordered",
+			diff:       MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.roles = []snowflake.Snowflake{2, 1} })},
+			wantEvents: []string{},
+		},
+		{
+			name: "nick and roles changed",
+			diff: MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) {
+				m.nick = "Annie"
+				m.roles = []snowflake.Snowflake{2, 4}
+			})},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated, EventMemberNickChanged, EventMemberRolesChanged},
+			wantAdded:   []snowflake.Snowflake{4},
+			wantRemoved: []snowflake.Snowflake{1},
+		},
+		{
+			name:        "username changed",
+			diff:        MemberDiff{Guild: 1, Old: ann, New: with(func(m *GuildMember) { m.user.username = "anne" })},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated},
+		},
+		{
+			name:        "update of an uncached member",
+			diff:        MemberDiff{Guild: 1, New: ann, OldUnknown: true},
+			wantChanged: true,
+			wantEvents:  []string{EventMemberUpdated},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantChanged, tt.diff.Changed())
+			assert.Equal(t, tt.wantAdded, tt.diff.RolesAdded())
+			assert.Equal(t, tt.wantRemoved, tt.diff.RolesRemoved())
+
+			evts := tt.diff.Events()
+			assert.Equal(t, tt.wantEvents, eventNames(evts))
+
+			for _, evt := range evts {
+				assert.Equal(t, snowflake.Snowflake(1), evt.GuildID())
+
+				if rc, ok := evt.(MemberRolesChanged); ok {
+					assert.Equal(t, tt.wantAdded, rc.Added)
+					assert.Equal(t, tt.wantRemoved, rc.Removed)
+				}
+
+				if mu, ok := evt.(MemberUpdated); ok {
+					assert.Equal(t, tt.diff.OldUnknown, mu.OldUnknown)
+				}
+			}
+		})
+	}
+}
+
+func TestRoleDiff_Events(t *testing.T) {
+	t.Parallel()
+
+	mods := Role{id: 5, name: "mods", permissions: 0x2 | 0x4, position: 3}
+
+	with := func(f func(r *Role)) Role {
+		r := mods
+		f(&r)
+		return r
+	}
+
+	tests := []struct {
+		name        string
+		diff        RoleDiff
+		wantChanged bool
+		wantEvents  []string
+		wantGranted int64
+		wantRevoked int64
+	}{
+		{
+			name:        "created",
+			diff:        RoleDiff{Guild: 1, New: mods, Created: true},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleCreated},
+		},
+		{
+			name:        "deleted",
+			diff:        RoleDiff{Guild: 1, Old: mods, Deleted: true},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleDeleted},
+		},
+		{
+			name: "deleted from members",
+			diff: RoleDiff{Guild: 1, Old: mods, Deleted: true, Members: []MemberDiff{
+				{Guild: 1, Old: GuildMember{id: 7, roles: []snowflake.Snowflake{5}}, New: GuildMember{id: 7}},
+			}},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleDeleted, EventMemberUpdated, EventMemberRolesChanged},
+		},
+		{
+			name:       "no change",
+			diff:       RoleDiff{Guild: 1, Old: mods, New: mods},
+			wantEvents: []string{},
+		},
+		{
+			name:        "renamed",
+			diff:        RoleDiff{Guild: 1, Old: mods, New: with(func(r *Role) { r.name = "moderators" })},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleUpdated},
+		},
+		{
+			name:        "permissions edited",
+			diff:        RoleDiff{Guild: 1, Old: mods, New: with(func(r *Role) { r.permissions = 0x4 | 0x8 })},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleUpdated, EventRolePermissionsChanged},
+			wantGranted: 0x8,
+			wantRevoked: 0x2,
+		},
+		{
+			name:        "moved",
+			diff:        RoleDiff{Guild: 1, Old: mods, New: with(func(r *Role) { r.position = 1 })},
+			wantChanged: true,
+			wantEvents:  []string{EventRoleUpdated},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantChanged, tt.diff.Changed())
+
+			evts := tt.diff.Events()
+			assert.Equal(t, tt.wantEvents, eventNames(evts))
+
+			for _, evt := range evts {
+				if pc, ok := evt.(RolePermissionsChanged); ok {
+					assert.Equal(t, tt.wantGranted, pc.Granted)
+					assert.Equal(t, tt.wantRevoked, pc.Revoked)
+				}
+			}
+		})
+	}
+}
+
+func TestChannelDiff_Events(t *testing.T) {
+	t.Parallel()
+
+	general := Channel{
+		id:          10,
+		name:        "general",
+		channelType: GuildTextChannel,
+		overwrites: []PermissionOverwrite{
+			{id: 1, allow: 0x400},
+			{id: 5, deny: 0x800},
+		},
+	}
+
+	with := func(f func(c *Channel)) Channel {
+		c := general
+		c.overwrites = append([]PermissionOverwrite(nil), general.overwrites...)
+		f(&c)
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		diff        ChannelDiff
+		wantChanged bool
+		wantEvents  []string
+	}{
+		{
+			name:        "created",
+			diff:        ChannelDiff{Guild: 1, New: general, Created: true},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelCreated},
+		},
+		{
+			name:        "deleted",
+			diff:        ChannelDiff{Guild: 1, Old: general, Deleted: true},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelDeleted},
+		},
+		{
+			name:       "no change",
+			diff:       ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) {})},
+			wantEvents: []string{},
+		},
+		{
+			name:        "renamed",
+			diff:        ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) { c.name = "lobby" })},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelUpdated, EventChannelRenamed},
+		},
+		{
+			name:        "overwrite edited",
+			diff:        ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) { c.overwrites[1].deny = 0xc00 })},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelUpdated, EventChannelPermissionsChanged},
+		},
+		{
+			name:        "overwrite added",
+			diff:        ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) { c.overwrites = append(c.overwrites, PermissionOverwrite{id: 6}) })},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelUpdated, EventChannelPermissionsChanged},
+		},
+		{
+			name:       "overwrites reordered",
+			diff:       ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) { c.overwrites[0], c.overwrites[1] = c.overwrites[1], c.overwrites[0] })},
+			wantEvents: []string{},
+		},
+		{
+			name: "renamed and permissions edited",
+			diff: ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) {
+				c.name = "lobby"
+				c.overwrites = c.overwrites[:1]
+			})},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelUpdated, EventChannelRenamed, EventChannelPermissionsChanged},
+		},
+		{
+			name:        "topic changed",
+			diff:        ChannelDiff{Guild: 1, Old: general, New: with(func(c *Channel) { c.topic = "hi" })},
+			wantChanged: true,
+			wantEvents:  []string{EventChannelUpdated},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantChanged, tt.diff.Changed())
+			assert.Equal(t, snowflake.Snowflake(10), tt.diff.ChannelID())
+			assert.Equal(t, tt.wantEvents, eventNames(tt.diff.Events()))
+		})
+	}
+}
+
+func memberElementMap(uid int64, nick string, roles ...int64) map[string]etfapi.Element {
+	roleElems := make([]etfapi.Element, 0, len(roles))
+	for _, rid := range roles {
+		roleElems = append(roleElems, mustElement(etfapi.NewSmallBigElement(rid)))
+	}
+
+	return map[string]etfapi.Element{
+		"user": mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+			"id":       mustElement(etfapi.NewSmallBigElement(uid)),
+			"username": mustElement(etfapi.NewStringElement("someone")),
+		})),
+		"nick":  mustElement(etfapi.NewStringElement(nick)),
+		"roles": mustElement(etfapi.NewListElement(roleElems)),
+	}
+}
+
+func TestGuild_memberUpserts(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+
+	// an update for a member that was never cached is not a join
+	diff, err := g.UpdateMemberFromElementMap(memberElementMap(7, "Ann", 2))
+	require.NoError(t, err)
+	assert.False(t, diff.Created)
+	assert.True(t, diff.OldUnknown)
+	assert.Equal(t, []string{EventMemberUpdated}, eventNames(diff.Events()))
+
+	// later updates are diffed against the cached member
+	diff, err = g.UpdateMemberFromElementMap(memberElementMap(7, "Annie", 2, 3))
+	require.NoError(t, err)
+	assert.False(t, diff.OldUnknown)
+	assert.Equal(t, []string{EventMemberUpdated, EventMemberNickChanged, EventMemberRolesChanged}, eventNames(diff.Events()))
+
+	// a join is always reported as one
+	diff, err = g.UpsertMemberFromElementMap(memberElementMap(8, "Bob"))
+	require.NoError(t, err)
+	assert.True(t, diff.Created)
+	assert.Equal(t, []string{EventMemberAdded}, eventNames(diff.Events()))
+}
+
+func TestGuild_RemoveRole(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putRole(Role{id: 2, position: 1})
+	g.putRole(Role{id: 3, position: 2})
+	g.putMember(GuildMember{id: 7, roles: []snowflake.Snowflake{2, 3}})
+	g.putMember(GuildMember{id: 8, roles: []snowflake.Snowflake{3}})
+	g.putMember(GuildMember{id: 9, roles: []snowflake.Snowflake{2}})
+
+	diff := g.RemoveRole(3)
+	require.True(t, diff.Deleted)
+	require.Len(t, diff.Members, 2)
+
+	byID := map[snowflake.Snowflake]MemberDiff{}
+	for _, md := range diff.Members {
+		byID[md.New.id] = md
+	}
+
+	assert.Equal(t, []snowflake.Snowflake{3}, byID[7].RolesRemoved())
+	assert.Equal(t, []snowflake.Snowflake{2}, byID[7].New.roles)
+	assert.Equal(t, []snowflake.Snowflake{3}, byID[8].RolesRemoved())
+	assert.Empty(t, byID[8].New.roles)
+
+	assert.Equal(t, []string{
+		EventRoleDeleted,
+		EventMemberUpdated, EventMemberRolesChanged,
+		EventMemberUpdated, EventMemberRolesChanged,
+	}, eventNames(diff.Events()))
+
+	assert.Empty(t, g.MembersWithRole(3))
+
+	// removing an unknown role changes nothing
+	assert.False(t, g.RemoveRole(3).Changed())
+}
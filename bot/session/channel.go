@@ -19,6 +19,12 @@ const (
 	GuildVoiceChannel    ChannelType = 2
 	GroupDMChannel       ChannelType = 3
 	GuildCategoryChannel ChannelType = 4
+	GuildNewsChannel     ChannelType = 5
+	GuildStoreChannel    ChannelType = 6
+	GuildNewsThread      ChannelType = 10
+	GuildPublicThread    ChannelType = 11
+	GuildPrivateThread   ChannelType = 12
+	GuildStageVoice      ChannelType = 13
 )
 
 // ChannelTypeFromElement extracts the channel type from a etf Element
@@ -39,11 +45,28 @@ func (t ChannelType) String() string {
 		return "GROUP_DM"
 	case GuildCategoryChannel:
 		return "GUILD_CATEGORY"
+	case GuildNewsChannel:
+		return "GUILD_NEWS"
+	case GuildStoreChannel:
+		return "GUILD_STORE"
+	case GuildNewsThread:
+		return "GUILD_NEWS_THREAD"
+	case GuildPublicThread:
+		return "GUILD_PUBLIC_THREAD"
+	case GuildPrivateThread:
+		return "GUILD_PRIVATE_THREAD"
+	case GuildStageVoice:
+		return "GUILD_STAGE_VOICE"
 	default:
 		return fmt.Sprintf("(unknown: %d)", int(t))
 	}
 }
 
+// IsThread determines if the channel type is one of the thread types
+func (t ChannelType) IsThread() bool {
+	return t == GuildNewsThread || t == GuildPublicThread || t == GuildPrivateThread
+}
+
 // Channel represents known information about a discord channel
 type Channel struct {
 	id            snowflake.Snowflake
@@ -55,7 +78,10 @@ type Channel struct {
 	channelType   ChannelType
 	name          string
 	topic         string
+	position      int
 	recipients    []User
+	overwrites    []PermissionOverwrite
+	thread        ThreadMetadata
 }
 
 // ID returns the channel's ID
@@ -63,6 +89,55 @@ func (c *Channel) ID() snowflake.Snowflake {
 	return c.id
 }
 
+// GuildID returns the ID of the guild owning the channel (or 0 for private channels)
+func (c *Channel) GuildID() snowflake.Snowflake {
+	return c.guildID
+}
+
+// ParentID returns the ID of the channel's parent (or 0 if it has none)
+func (c *Channel) ParentID() snowflake.Snowflake {
+	return c.parentID
+}
+
+// Type returns the channel's type
+func (c *Channel) Type() ChannelType {
+	return c.channelType
+}
+
+// Name returns the channel's name
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// Topic returns the channel's topic
+func (c *Channel) Topic() string {
+	return c.topic
+}
+
+// Position returns the channel's sorting position
+func (c *Channel) Position() int {
+	return c.position
+}
+
+// IsThread determines if the channel is a thread
+func (c *Channel) IsThread() bool {
+	return c.channelType.IsThread()
+}
+
+// ThreadMetadata returns the thread-specific information about the channel
+//
+// This will be the zero value if the channel is not a thread
+func (c *Channel) ThreadMetadata() ThreadMetadata {
+	return c.thread
+}
+
+// PermissionOverwrites returns a copy of the channel's permission overwrites
+func (c *Channel) PermissionOverwrites() []PermissionOverwrite {
+	ows := make([]PermissionOverwrite, len(c.overwrites))
+	copy(ows, c.overwrites)
+	return ows
+}
+
 // UpdateFromElementMap updates information about the channel
 // This will not remove known data, only replace it
 func (c *Channel) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
@@ -100,6 +175,14 @@ func (c *Channel) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 		}
 	}
 
+	e2, ok = eMap["position"]
+	if ok {
+		c.position, err = e2.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get position")
+		}
+	}
+
 	e2, ok = eMap["last_message_id"]
 	if ok && !e2.IsNil() {
 		c.lastMessageID, err = etfapi.SnowflakeFromElement(e2)
@@ -144,6 +227,24 @@ func (c *Channel) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 		}
 	}
 
+	if c.channelType.IsThread() {
+		if err = c.thread.UpdateFromElementMap(eMap); err != nil {
+			return errors.Wrap(err, "could not get thread metadata")
+		}
+	}
+
+	e2, ok = eMap["permission_overwrites"]
+	if ok && !e2.IsNil() {
+		c.overwrites = make([]PermissionOverwrite, 0, len(e2.Vals))
+		for _, e3 := range e2.Vals {
+			ow, err := PermissionOverwriteFromElement(e3)
+			if err != nil {
+				return errors.Wrap(err, "could not inflate channel permission overwrite")
+			}
+			c.overwrites = append(c.overwrites, ow)
+		}
+	}
+
 	return nil
 }
 
@@ -176,3 +277,69 @@ func ChannelFromElementMap(eMap map[string]etfapi.Element) (Channel, error) {
 	err = c.UpdateFromElementMap(eMap)
 	return c, errors.Wrap(err, "could not create a channel")
 }
+
+// PermissionOverwrite represents a permission override for a role or member on a channel
+type PermissionOverwrite struct {
+	id            snowflake.Snowflake
+	overwriteType int
+	allow         int64
+	deny          int64
+}
+
+// ID returns the id of the role or member the overwrite applies to
+func (ow PermissionOverwrite) ID() snowflake.Snowflake {
+	return ow.id
+}
+
+// IsMember determines if the overwrite applies to a member (rather than a role)
+func (ow PermissionOverwrite) IsMember() bool {
+	return ow.overwriteType == 1
+}
+
+// Allow returns the bitset of explicitly allowed permissions
+func (ow PermissionOverwrite) Allow() int64 {
+	return ow.allow
+}
+
+// Deny returns the bitset of explicitly denied permissions
+func (ow PermissionOverwrite) Deny() int64 {
+	return ow.deny
+}
+
+// PermissionOverwriteFromElement creates a new PermissionOverwrite object from the given etf Element
+func PermissionOverwriteFromElement(e etfapi.Element) (PermissionOverwrite, error) {
+	var ow PermissionOverwrite
+	var eMap map[string]etfapi.Element
+	var sf snowflake.Snowflake
+	var ok bool
+	var err error
+
+	eMap, ow.id, err = etfapi.MapAndIDFromElement(e)
+	if err != nil {
+		return ow, err
+	}
+
+	e2 := eMap["type"]
+	ow.overwriteType, err = e2.ToInt()
+	if err != nil {
+		return ow, errors.Wrap(err, "could not get overwrite type")
+	}
+
+	if e2, ok = eMap["allow"]; ok {
+		sf, err = etfapi.SnowflakeFromUnknownElement(e2)
+		if err != nil {
+			return ow, errors.Wrap(err, "could not get allow")
+		}
+		ow.allow = int64(sf)
+	}
+
+	if e2, ok = eMap["deny"]; ok {
+		sf, err = etfapi.SnowflakeFromUnknownElement(e2)
+		if err != nil {
+			return ow, errors.Wrap(err, "could not get deny")
+		}
+		ow.deny = int64(sf)
+	}
+
+	return ow, nil
+}
@@ -0,0 +1,426 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// These are the names of the change events generated from emoji and sticker updates
+const (
+	EventEmojisUpdated   = "EMOJIS_UPDATED"
+	EventStickersUpdated = "STICKERS_UPDATED"
+)
+
+// Emoji represents the known information about a custom guild emoji
+type Emoji struct {
+	id            snowflake.Snowflake
+	name          string
+	roles         []snowflake.Snowflake
+	requireColons bool
+	managed       bool
+	animated      bool
+	available     bool
+}
+
+// ID returns the emoji's id
+func (e *Emoji) ID() snowflake.Snowflake {
+	return e.id
+}
+
+// Name returns the emoji's name
+func (e *Emoji) Name() string {
+	return e.name
+}
+
+// Roles returns a copy of the ids of the roles allowed to use the emoji (empty if unrestricted)
+func (e *Emoji) Roles() []snowflake.Snowflake {
+	return copySnowflakes(e.roles)
+}
+
+// Animated determines if the emoji is animated
+func (e *Emoji) Animated() bool {
+	return e.animated
+}
+
+// Managed determines if the emoji is managed by an integration
+func (e *Emoji) Managed() bool {
+	return e.managed
+}
+
+// Available determines if the emoji can currently be used (it may not be due to lost boosts)
+func (e *Emoji) Available() bool {
+	return e.available
+}
+
+// String formats the emoji for use in message content (<:name:id> or <a:name:id>)
+func (e *Emoji) String() string {
+	if e.animated {
+		return fmt.Sprintf("<a:%s:%d>", e.name, e.id)
+	}
+
+	return fmt.Sprintf("<:%s:%d>", e.name, e.id)
+}
+
+// ReactionString formats the emoji for use with the reaction endpoints (name:id)
+func (e *Emoji) ReactionString() string {
+	return fmt.Sprintf("%s:%d", e.name, e.id)
+}
+
+// UpdateFromElementMap updates the information about the emoji
+func (e *Emoji) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
+	var err error
+
+	if e2, ok := eMap["name"]; ok && !e2.IsNil() {
+		e.name, err = e2.ToString()
+		if err != nil {
+			return errors.Wrap(err, "could not get name")
+		}
+	}
+
+	if e2, ok := eMap["roles"]; ok {
+		e.roles = make([]snowflake.Snowflake, 0, len(e2.Vals))
+		for _, e3 := range e2.Vals {
+			rid, err := etfapi.SnowflakeFromUnknownElement(e3)
+			if err != nil {
+				return errors.Wrap(err, "could not get emoji role id")
+			}
+			e.roles = append(e.roles, rid)
+		}
+	}
+
+	flags := []struct {
+		key string
+		val *bool
+	}{
+		{"require_colons", &e.requireColons},
+		{"managed", &e.managed},
+		{"animated", &e.animated},
+		{"available", &e.available},
+	}
+
+	for _, f := range flags {
+		e2, ok := eMap[f.key]
+		if !ok || e2.IsNil() {
+			continue
+		}
+
+		*f.val, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get "+f.key)
+		}
+	}
+
+	return nil
+}
+
+// EmojiFromElement creates a new Emoji object from the given etf Element
+func EmojiFromElement(e etfapi.Element) (Emoji, error) {
+	em := Emoji{available: true}
+
+	eMap, id, err := etfapi.MapAndIDFromElement(e)
+	if err != nil {
+		return em, err
+	}
+	em.id = id
+
+	err = em.UpdateFromElementMap(eMap)
+	return em, errors.Wrap(err, "could not inflate emoji")
+}
+
+// Sticker represents the known information about a custom guild sticker
+type Sticker struct {
+	id          snowflake.Snowflake
+	name        string
+	description string
+	tags        string
+	formatType  int
+	available   bool
+}
+
+// ID returns the sticker's id
+func (s *Sticker) ID() snowflake.Snowflake {
+	return s.id
+}
+
+// Name returns the sticker's name
+func (s *Sticker) Name() string {
+	return s.name
+}
+
+// Description returns the sticker's description
+func (s *Sticker) Description() string {
+	return s.description
+}
+
+// Tags returns the autocomplete/suggestion tags of the sticker
+func (s *Sticker) Tags() string {
+	return s.tags
+}
+
+// FormatType returns the sticker's format type (1 png, 2 apng, 3 lottie)
+func (s *Sticker) FormatType() int {
+	return s.formatType
+}
+
+// Available determines if the sticker can currently be used (it may not be due to lost boosts)
+func (s *Sticker) Available() bool {
+	return s.available
+}
+
+// StickerFromElement creates a new Sticker object from the given etf Element
+func StickerFromElement(e etfapi.Element) (Sticker, error) {
+	s := Sticker{available: true}
+
+	eMap, id, err := etfapi.MapAndIDFromElement(e)
+	if err != nil {
+		return s, err
+	}
+	s.id = id
+
+	strs := []struct {
+		key string
+		val *string
+	}{
+		{"name", &s.name},
+		{"description", &s.description},
+		{"tags", &s.tags},
+	}
+
+	for _, f := range strs {
+		e2, ok := eMap[f.key]
+		if !ok || e2.IsNil() {
+			continue
+		}
+
+		*f.val, err = e2.ToString()
+		if err != nil {
+			return s, errors.Wrap(err, "could not get "+f.key)
+		}
+	}
+
+	if e2, ok := eMap["format_type"]; ok {
+		s.formatType, err = e2.ToInt()
+		if err != nil {
+			return s, errors.Wrap(err, "could not get format_type")
+		}
+	}
+
+	if e2, ok := eMap["available"]; ok && !e2.IsNil() {
+		s.available, err = e2.ToBool()
+		if err != nil {
+			return s, errors.Wrap(err, "could not get available")
+		}
+	}
+
+	return s, nil
+}
+
+// EmojisUpdated is the event generated when the custom emojis of a guild change
+type EmojisUpdated struct {
+	Guild   snowflake.Snowflake
+	Added   []Emoji
+	Removed []Emoji
+	Changed []Emoji
+}
+
+// EventName returns the name of the event
+func (e EmojisUpdated) EventName() string { return EventEmojisUpdated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e EmojisUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// StickersUpdated is the event generated when the custom stickers of a guild change
+type StickersUpdated struct {
+	Guild   snowflake.Snowflake
+	Added   []Sticker
+	Removed []Sticker
+	Changed []Sticker
+}
+
+// EventName returns the name of the event
+func (e StickersUpdated) EventName() string { return EventStickersUpdated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e StickersUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// Emoji finds the custom emoji with the provided id, if one is known
+//
+// The second return value will be false if no such emoji was found
+func (g *Guild) Emoji(eid snowflake.Snowflake) (Emoji, bool) {
+	em, ok := g.emojis[eid]
+	return em, ok
+}
+
+// EmojiWithName finds the custom emoji with the provided name (case-insensitive), if one is known
+//
+// The second return value will be false if no such emoji was found
+func (g *Guild) EmojiWithName(name string) (Emoji, bool) {
+	if g.index == nil {
+		return Emoji{}, false
+	}
+
+	eid, ok := g.index.emojisByName[strings.ToLower(name)]
+	if !ok {
+		return Emoji{}, false
+	}
+
+	return g.Emoji(eid)
+}
+
+// Emojis returns all of the known custom emojis of the guild
+func (g *Guild) Emojis() []Emoji {
+	ems := make([]Emoji, 0, len(g.emojis))
+	for _, em := range g.emojis {
+		ems = append(ems, em)
+	}
+
+	return ems
+}
+
+// EmojiUsableBy determines if the member with the provided id may use the custom emoji with the provided id
+//
+// An emoji is usable if it is available and either unrestricted or restricted to a role the member has
+func (g *Guild) EmojiUsableBy(eid, uid snowflake.Snowflake) bool {
+	em, ok := g.emojis[eid]
+	if !ok || !em.available {
+		return false
+	}
+
+	if len(em.roles) == 0 {
+		return true
+	}
+
+	for _, rid := range em.roles {
+		if g.HasRole(uid, rid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sticker finds the custom sticker with the provided id, if one is known
+//
+// The second return value will be false if no such sticker was found
+func (g *Guild) Sticker(sid snowflake.Snowflake) (Sticker, bool) {
+	st, ok := g.stickers[sid]
+	return st, ok
+}
+
+// StickerWithName finds the custom sticker with the provided name (case-insensitive), if one is known
+//
+// The second return value will be false if no such sticker was found
+func (g *Guild) StickerWithName(name string) (Sticker, bool) {
+	if g.index == nil {
+		return Sticker{}, false
+	}
+
+	sid, ok := g.index.stickersByName[strings.ToLower(name)]
+	if !ok {
+		return Sticker{}, false
+	}
+
+	return g.Sticker(sid)
+}
+
+// Stickers returns all of the known custom stickers of the guild
+func (g *Guild) Stickers() []Sticker {
+	sts := make([]Sticker, 0, len(g.stickers))
+	for _, st := range g.stickers {
+		sts = append(sts, st)
+	}
+
+	return sts
+}
+
+// ReplaceEmojisFromElement replaces the custom emojis of the guild with the given list Element
+func (g *Guild) ReplaceEmojisFromElement(e etfapi.Element) (EmojisUpdated, error) {
+	evt := EmojisUpdated{Guild: g.id}
+
+	emojis := make(map[snowflake.Snowflake]Emoji, len(e.Vals))
+	for _, e2 := range e.Vals {
+		em, err := EmojiFromElement(e2)
+		if err != nil {
+			return evt, errors.Wrap(err, "could not inflate guild emoji")
+		}
+		emojis[em.id] = em
+	}
+
+	for eid, em := range emojis {
+		old, ok := g.emojis[eid]
+		switch {
+		case !ok:
+			evt.Added = append(evt.Added, em)
+		case !emojisEqual(old, em):
+			evt.Changed = append(evt.Changed, em)
+		}
+	}
+
+	for eid, old := range g.emojis {
+		if _, ok := emojis[eid]; !ok {
+			evt.Removed = append(evt.Removed, old)
+		}
+	}
+
+	g.ensureIndex()
+	g.emojis = emojis
+	g.index.emojisByName = make(map[string]snowflake.Snowflake, len(emojis))
+	for _, em := range emojis {
+		g.index.emojisByName[strings.ToLower(em.name)] = em.id
+	}
+
+	return evt, nil
+}
+
+// ReplaceStickersFromElement replaces the custom stickers of the guild with the given list Element
+func (g *Guild) ReplaceStickersFromElement(e etfapi.Element) (StickersUpdated, error) {
+	evt := StickersUpdated{Guild: g.id}
+
+	stickers := make(map[snowflake.Snowflake]Sticker, len(e.Vals))
+	for _, e2 := range e.Vals {
+		st, err := StickerFromElement(e2)
+		if err != nil {
+			return evt, errors.Wrap(err, "could not inflate guild sticker")
+		}
+		stickers[st.id] = st
+	}
+
+	for sid, st := range stickers {
+		old, ok := g.stickers[sid]
+		switch {
+		case !ok:
+			evt.Added = append(evt.Added, st)
+		case old != st:
+			evt.Changed = append(evt.Changed, st)
+		}
+	}
+
+	for sid, old := range g.stickers {
+		if _, ok := stickers[sid]; !ok {
+			evt.Removed = append(evt.Removed, old)
+		}
+	}
+
+	g.ensureIndex()
+	g.stickers = stickers
+	g.index.stickersByName = make(map[string]snowflake.Snowflake, len(stickers))
+	for _, st := range stickers {
+		g.index.stickersByName[strings.ToLower(st.name)] = st.id
+	}
+
+	return evt, nil
+}
+
+func emojisEqual(a, b Emoji) bool {
+	if a.id != b.id || a.name != b.name || a.requireColons != b.requireColons ||
+		a.managed != b.managed || a.animated != b.animated || a.available != b.available {
+		return false
+	}
+
+	return len(snowflakesMissing(a.roles, b.roles)) == 0 && len(snowflakesMissing(b.roles, a.roles)) == 0
+}
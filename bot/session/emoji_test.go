@@ -0,0 +1,190 @@
+package session
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func emojiElement(id int64, name string, available bool, roles ...int64) etfapi.Element {
+	roleElems := make([]etfapi.Element, 0, len(roles))
+	for _, rid := range roles {
+		roleElems = append(roleElems, mustElement(etfapi.NewSmallBigElement(rid)))
+	}
+
+	return mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+		"id":        mustElement(etfapi.NewSmallBigElement(id)),
+		"name":      mustElement(etfapi.NewStringElement(name)),
+		"roles":     mustElement(etfapi.NewListElement(roleElems)),
+		"available": mustElement(etfapi.NewBoolElement(available)),
+	}))
+}
+
+func stickerElement(id int64, name, tags string) etfapi.Element {
+	return mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+		"id":          mustElement(etfapi.NewSmallBigElement(id)),
+		"name":        mustElement(etfapi.NewStringElement(name)),
+		"tags":        mustElement(etfapi.NewStringElement(tags)),
+		"format_type": mustElement(etfapi.NewInt8Element(1)),
+	}))
+}
+
+func emojiIDs(ems []Emoji) []snowflake.Snowflake {
+	ids := make([]snowflake.Snowflake, 0, len(ems))
+	for _, em := range ems {
+		ids = append(ids, em.id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func stickerIDs(sts []Sticker) []snowflake.Snowflake {
+	ids := make([]snowflake.Snowflake, 0, len(sts))
+	for _, st := range sts {
+		ids = append(ids, st.id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestState_emojis(t *testing.T) {
+	t.Parallel()
+
+	s := newState()
+
+	_, err := s.UpsertGuildFromElementMap(map[string]etfapi.Element{
+		"id": mustElement(etfapi.NewSmallBigElement(1)),
+		"members": mustElement(etfapi.NewListElement([]etfapi.Element{
+			mustElement(etfapi.NewMapElement(memberElementMap(7, "", 2))),
+			mustElement(etfapi.NewMapElement(memberElementMap(8, ""))),
+		})),
+		"emojis": mustElement(etfapi.NewListElement([]etfapi.Element{
+			emojiElement(10, "PartyBlob", true),
+			emojiElement(11, "modhammer", true, 2),
+			emojiElement(12, "boosted", false),
+		})),
+	})
+	require.NoError(t, err)
+
+	g, ok := s.Guild(1)
+	require.True(t, ok)
+
+	em, ok := g.Emoji(10)
+	require.True(t, ok)
+	assert.Equal(t, "PartyBlob", em.Name())
+	assert.Equal(t, "<:PartyBlob:10>", em.String())
+	assert.Equal(t, "PartyBlob:10", em.ReactionString())
+
+	em, ok = g.EmojiWithName("partyblob")
+	assert.True(t, ok)
+	assert.Equal(t, snowflake.Snowflake(10), em.ID())
+
+	_, ok = g.Emoji(99)
+	assert.False(t, ok)
+	_, ok = g.EmojiWithName("missing")
+	assert.False(t, ok)
+
+	tests := []struct {
+		name string
+		eid  snowflake.Snowflake
+		uid  snowflake.Snowflake
+		want bool
+	}{
+		{name: "unrestricted", eid: 10, uid: 8, want: true},
+		{name: "restricted to a role the member has", eid: 11, uid: 7, want: true},
+		{name: "restricted to a role the member lacks", eid: 11, uid: 8, want: false},
+		{name: "restricted and unknown member", eid: 11, uid: 9, want: false},
+		{name: "unavailable", eid: 12, uid: 7, want: false},
+		{name: "unknown emoji", eid: 99, uid: 7, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, g.EmojiUsableBy(tt.eid, tt.uid), tt.name)
+	}
+
+	evt, err := s.UpdateGuildEmojisFromElementMap(map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(1)),
+		"emojis": mustElement(etfapi.NewListElement([]etfapi.Element{
+			emojiElement(10, "PartyBlob", true),
+			emojiElement(11, "banhammer", true, 2, 3),
+			emojiElement(13, "wave", true),
+		})),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, snowflake.Snowflake(1), evt.GuildID())
+	assert.Equal(t, []snowflake.Snowflake{13}, emojiIDs(evt.Added))
+	assert.Equal(t, []snowflake.Snowflake{11}, emojiIDs(evt.Changed))
+	assert.Equal(t, []snowflake.Snowflake{12}, emojiIDs(evt.Removed))
+
+	g, _ = s.Guild(1)
+	assert.Equal(t, []snowflake.Snowflake{10, 11, 13}, emojiIDs(g.Emojis()))
+
+	// the name index follows renames
+	_, ok = g.EmojiWithName("modhammer")
+	assert.False(t, ok)
+	em, ok = g.EmojiWithName("BanHammer")
+	assert.True(t, ok)
+	assert.Equal(t, []snowflake.Snowflake{2, 3}, em.Roles())
+}
+
+func TestState_stickers(t *testing.T) {
+	t.Parallel()
+
+	s := newState()
+
+	_, err := s.UpsertGuildFromElementMap(map[string]etfapi.Element{
+		"id": mustElement(etfapi.NewSmallBigElement(1)),
+		"stickers": mustElement(etfapi.NewListElement([]etfapi.Element{
+			stickerElement(20, "Wumpus", "wave"),
+			stickerElement(21, "clyde", "robot"),
+		})),
+	})
+	require.NoError(t, err)
+
+	g, ok := s.Guild(1)
+	require.True(t, ok)
+
+	st, ok := g.Sticker(20)
+	require.True(t, ok)
+	assert.Equal(t, "Wumpus", st.Name())
+	assert.Equal(t, "wave", st.Tags())
+	assert.Equal(t, 1, st.FormatType())
+	assert.True(t, st.Available())
+
+	st, ok = g.StickerWithName("WUMPUS")
+	assert.True(t, ok)
+	assert.Equal(t, snowflake.Snowflake(20), st.ID())
+
+	evt, err := s.UpdateGuildStickersFromElementMap(map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(1)),
+		"stickers": mustElement(etfapi.NewListElement([]etfapi.Element{
+			stickerElement(20, "Wumpus", "happy"),
+			stickerElement(22, "nelly", "dog"),
+		})),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, snowflake.Snowflake(1), evt.GuildID())
+	assert.Equal(t, []snowflake.Snowflake{22}, stickerIDs(evt.Added))
+	assert.Equal(t, []snowflake.Snowflake{20}, stickerIDs(evt.Changed))
+	assert.Equal(t, []snowflake.Snowflake{21}, stickerIDs(evt.Removed))
+
+	g, _ = s.Guild(1)
+	assert.Equal(t, []snowflake.Snowflake{20, 22}, stickerIDs(g.Stickers()))
+
+	_, ok = g.StickerWithName("clyde")
+	assert.False(t, ok)
+	_, ok = g.Sticker(21)
+	assert.False(t, ok)
+
+	// an update for an unknown guild is an error
+	_, err = s.UpdateGuildStickersFromElementMap(map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(2)),
+		"stickers": mustElement(etfapi.NewListElement(nil)),
+	})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
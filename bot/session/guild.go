@@ -19,6 +19,10 @@ type Guild struct {
 	members       map[snowflake.Snowflake]GuildMember
 	channels      map[snowflake.Snowflake]Channel
 	roles         map[snowflake.Snowflake]Role
+	voiceStates   map[snowflake.Snowflake]VoiceState
+	emojis        map[snowflake.Snowflake]Emoji
+	stickers      map[snowflake.Snowflake]Sticker
+	index         *guildIndex
 }
 
 // ID returns the guild ID
@@ -117,6 +121,253 @@ func (g *Guild) IsAdmin(uid snowflake.Snowflake) bool {
 	return false
 }
 
+// Member finds the guild member with the provided user id, if one is known
+//
+// The second return value will be false if no such member was found
+func (g *Guild) Member(uid snowflake.Snowflake) (GuildMember, bool) {
+	m, ok := g.members[uid]
+	return m, ok
+}
+
+// Channel finds the guild channel with the provided id, if one is known
+//
+// The second return value will be false if no such channel was found
+func (g *Guild) Channel(cid snowflake.Snowflake) (Channel, bool) {
+	c, ok := g.channels[cid]
+	return c, ok
+}
+
+// Role finds the guild role with the provided id, if one is known
+//
+// The second return value will be false if no such role was found
+func (g *Guild) Role(rid snowflake.Snowflake) (Role, bool) {
+	r, ok := g.roles[rid]
+	return r, ok
+}
+
+// MemberCount returns the number of members known to be in the guild
+func (g *Guild) MemberCount() int {
+	return len(g.members)
+}
+
+// ChannelCount returns the number of channels known to be in the guild
+func (g *Guild) ChannelCount() int {
+	return len(g.channels)
+}
+
+// RoleCount returns the number of roles known to be in the guild
+func (g *Guild) RoleCount() int {
+	return len(g.roles)
+}
+
+// RoleMemberCount returns the number of members known to have the role with the provided id
+func (g *Guild) RoleMemberCount(rid snowflake.Snowflake) int {
+	if g.index == nil {
+		return 0
+	}
+
+	return len(g.index.membersByRole[rid])
+}
+
+// MembersWithRole returns the ids of the members known to have the role with the provided id
+func (g *Guild) MembersWithRole(rid snowflake.Snowflake) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	set := g.index.membersByRole[rid]
+	uids := make([]snowflake.Snowflake, 0, len(set))
+	for uid := range set {
+		uids = append(uids, uid)
+	}
+
+	return uids
+}
+
+// MembersWithNamePrefix returns the ids of the members whose username or nickname
+// starts with the provided prefix (case-insensitive), ordered by the matching name
+func (g *Guild) MembersWithNamePrefix(prefix string) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	return g.index.membersWithNamePrefix(prefix)
+}
+
+// ChannelsOfType returns the ids of the channels of the provided type, in position order
+func (g *Guild) ChannelsOfType(t ChannelType) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	return copySnowflakes(g.index.channelsByType[t])
+}
+
+// CategoryChildren returns the ids of the channels whose parent is the provided category, in position order
+func (g *Guild) CategoryChildren(cid snowflake.Snowflake) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	return copySnowflakes(g.index.channelsByParent[cid])
+}
+
+// RolesByHierarchy returns the ids of the roles in the guild, from the highest to the lowest
+func (g *Guild) RolesByHierarchy() []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	return copySnowflakes(g.index.roleOrder)
+}
+
+// HighestRole finds the highest role in the hierarchy that the member with the provided id has
+//
+// The second return value will be false if the member is unknown or has no known roles
+func (g *Guild) HighestRole(uid snowflake.Snowflake) (snowflake.Snowflake, bool) {
+	m, ok := g.members[uid]
+	if !ok || g.index == nil {
+		return 0, false
+	}
+
+	var highest snowflake.Snowflake
+	for _, rid := range m.roles {
+		if _, ok := g.roles[rid]; !ok {
+			continue
+		}
+
+		if highest == 0 || g.index.roleOutranks(rid, highest) {
+			highest = rid
+		}
+	}
+
+	return highest, highest != 0
+}
+
+// CanModerate determines if the member with id actor sits high enough in the guild hierarchy
+// to kick, ban, time out, or rename the member with id target
+//
+// Nobody can act on the guild owner, and the owner can act on anyone else. Targets that are
+// not known members of the guild are treated as having no roles. Otherwise, the highest role
+// of the actor must outrank the highest role of the target
+func (g *Guild) CanModerate(actor, target snowflake.Snowflake) bool {
+	if g.ownerID != 0 && target == g.ownerID {
+		return false
+	}
+
+	if g.ownerID != 0 && actor == g.ownerID {
+		return true
+	}
+
+	actorRole, ok := g.HighestRole(actor)
+	if !ok {
+		return false
+	}
+
+	targetRole, ok := g.HighestRole(target)
+	if !ok {
+		return true
+	}
+
+	return g.index.roleOutranks(actorRole, targetRole)
+}
+
+// CanManageRole determines if the member with id actor sits high enough in the guild hierarchy
+// to grant or remove the role with id rid
+func (g *Guild) CanManageRole(actor, rid snowflake.Snowflake) bool {
+	if g.ownerID != 0 && actor == g.ownerID {
+		return true
+	}
+
+	actorRole, ok := g.HighestRole(actor)
+	if !ok {
+		return false
+	}
+
+	return g.index.roleOutranks(actorRole, rid)
+}
+
+func (g *Guild) ensureIndex() {
+	if g.index == nil {
+		g.index = newGuildIndex()
+	}
+}
+
+// putMembers stores a batch of members, indexing them all at once
+func (g *Guild) putMembers(members []GuildMember) {
+	g.ensureIndex()
+
+	for _, m := range members {
+		if old, ok := g.members[m.id]; ok {
+			g.index.removeMember(old)
+		}
+	}
+
+	for _, m := range members {
+		g.members[m.id] = m
+		g.index.addMember(m, false)
+	}
+
+	g.index.sortNames()
+}
+
+func (g *Guild) putMember(m GuildMember) {
+	g.ensureIndex()
+
+	if old, ok := g.members[m.id]; ok {
+		g.index.removeMember(old)
+	}
+
+	g.members[m.id] = m
+	g.index.addMember(m, true)
+}
+
+func (g *Guild) dropMember(uid snowflake.Snowflake) {
+	if old, ok := g.members[uid]; ok && g.index != nil {
+		g.index.removeMember(old)
+	}
+
+	delete(g.members, uid)
+}
+
+func (g *Guild) putChannel(c Channel) {
+	g.ensureIndex()
+
+	if old, ok := g.channels[c.id]; ok {
+		g.index.removeChannel(old)
+	}
+
+	g.channels[c.id] = c
+	g.index.addChannel(c)
+}
+
+func (g *Guild) dropChannel(cid snowflake.Snowflake) {
+	if old, ok := g.channels[cid]; ok && g.index != nil {
+		g.index.removeChannel(old)
+	}
+
+	delete(g.channels, cid)
+}
+
+func (g *Guild) putRole(r Role) {
+	g.ensureIndex()
+
+	if old, ok := g.roles[r.id]; ok {
+		g.index.roleOrder = removeSnowflake(g.index.roleOrder, old.id)
+	}
+
+	g.roles[r.id] = r
+	g.index.addRole(r)
+}
+
+func (g *Guild) dropRole(rid snowflake.Snowflake) {
+	if old, ok := g.roles[rid]; ok && g.index != nil {
+		g.index.removeRole(old)
+	}
+
+	delete(g.roles, rid)
+}
+
 // UpdateFromElementMap updates information about the guild from the provided data
 //
 // This will not delete data; it will only add and change data
@@ -174,13 +425,15 @@ func (g *Guild) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 
 	e2, ok = eMap["members"]
 	if ok {
+		members := make([]GuildMember, 0, len(e2.Vals))
 		for _, e3 := range e2.Vals {
 			m, err = GuildMemberFromElement(e3)
 			if err != nil {
 				return errors.Wrap(err, "could not inflate guild member")
 			}
-			g.members[m.id] = m
+			members = append(members, m)
 		}
+		g.putMembers(members)
 	}
 
 	e2, ok = eMap["channels"]
@@ -190,7 +443,7 @@ func (g *Guild) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 			if err != nil {
 				return errors.Wrap(err, "could not inflate guild channel")
 			}
-			g.channels[c.id] = c
+			g.putChannel(c)
 		}
 	}
 
@@ -201,63 +454,210 @@ func (g *Guild) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 			if err != nil {
 				return errors.Wrap(err, "could not inflate guild role")
 			}
-			g.roles[r.id] = r
+			g.putRole(r)
+		}
+	}
+
+	e2, ok = eMap["emojis"]
+	if ok && !e2.IsNil() {
+		if _, err = g.ReplaceEmojisFromElement(e2); err != nil {
+			return errors.Wrap(err, "could not update guild emojis")
+		}
+	}
+
+	e2, ok = eMap["stickers"]
+	if ok && !e2.IsNil() {
+		if _, err = g.ReplaceStickersFromElement(e2); err != nil {
+			return errors.Wrap(err, "could not update guild stickers")
+		}
+	}
+
+	e2, ok = eMap["voice_states"]
+	if ok && !e2.IsNil() {
+		states := make([]VoiceState, 0, len(e2.Vals))
+		for _, e3 := range e2.Vals {
+			var v VoiceState
+			v, err = VoiceStateFromElement(e3)
+			if err != nil {
+				return errors.Wrap(err, "could not inflate guild voice state")
+			}
+			states = append(states, v)
+		}
+		g.replaceVoiceStates(states)
+	}
+
+	e2, ok = eMap["threads"]
+	if ok && !e2.IsNil() {
+		_, err = g.syncThreads(e2.Vals, nil, nil)
+		if err != nil {
+			return errors.Wrap(err, "could not sync guild threads")
 		}
 	}
 
 	return nil
 }
 
-// UpsertMemberFromElementMap upserts a GuildMemeber in the guild from the given data
-func (g *Guild) UpsertMemberFromElementMap(eMap map[string]etfapi.Element) (GuildMember, error) {
-	var m GuildMember
+// UpsertMemberFromElementMap upserts a GuildMember in the guild from the given data of a member who joined
+//
+// The data should be a guild member object (including the "user" field)
+func (g *Guild) UpsertMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	return g.upsertMember(eMap, true)
+}
+
+// UpdateMemberFromElementMap upserts a GuildMember in the guild from the given data of a member update
+//
+// Unlike UpsertMemberFromElementMap, a member that was not cached is not reported as created, since they
+// did not just join (the diff has OldUnknown set instead)
+func (g *Guild) UpdateMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	return g.upsertMember(eMap, false)
+}
+
+func (g *Guild) upsertMember(eMap map[string]etfapi.Element, joined bool) (MemberDiff, error) {
+	diff := MemberDiff{Guild: g.id}
 
-	mid, err := etfapi.SnowflakeFromElement(eMap["id"])
+	_, mid, err := etfapi.MapAndIDFromElement(eMap["user"])
 	if err != nil {
-		return m, errors.Wrap(err, "could not get member id")
+		return diff, errors.Wrap(err, "could not get member id")
 	}
 
 	m, ok := g.members[mid]
 	if !ok {
 		m.id = mid
+		m.user.id = mid
+		diff.Created = joined
+		diff.OldUnknown = !joined
+	} else {
+		diff.Old = m
 	}
-	err = m.UpdateFromElementMap(eMap)
-	if err != nil {
-		return m, err
+
+	if err = m.UpdateFromElementMap(eMap); err != nil {
+		return diff, err
 	}
-	g.members[mid] = m
 
-	return m, nil
+	diff.New = m
+	g.putMember(m)
+
+	return diff, nil
+}
+
+// RemoveMember removes the member with the provided user id from the guild
+func (g *Guild) RemoveMember(uid snowflake.Snowflake) MemberDiff {
+	m, ok := g.members[uid]
+	if !ok {
+		return MemberDiff{Guild: g.id}
+	}
+
+	g.dropMember(uid)
+	g.putVoiceState(VoiceState{userID: uid})
+	return MemberDiff{Guild: g.id, Old: m, Removed: true}
 }
 
 // UpsertRoleFromElementMap upserts a Role in the guild from the given data
-func (g *Guild) UpsertRoleFromElementMap(eMap map[string]etfapi.Element) (Role, error) {
-	var r Role
+func (g *Guild) UpsertRoleFromElementMap(eMap map[string]etfapi.Element) (RoleDiff, error) {
+	diff := RoleDiff{Guild: g.id}
 
 	rid, err := etfapi.SnowflakeFromElement(eMap["id"])
 	if err != nil {
-		return r, errors.Wrap(err, "could not get role id")
+		return diff, errors.Wrap(err, "could not get role id")
 	}
 
 	r, ok := g.roles[rid]
 	if !ok {
 		r.id = rid
+		diff.Created = true
+	} else {
+		diff.Old = r
 	}
+
 	err = r.UpdateFromElementMap(eMap)
 	if err != nil {
-		return r, err
+		return diff, err
+	}
+
+	g.putRole(r)
+	diff.New = r
+	return diff, nil
+}
+
+// RemoveRole removes the role with the provided id from the guild (and from any members who had it)
+//
+// The changes to those members are included in the Members of the diff
+func (g *Guild) RemoveRole(rid snowflake.Snowflake) RoleDiff {
+	r, ok := g.roles[rid]
+	if !ok {
+		return RoleDiff{Guild: g.id}
+	}
+
+	diff := RoleDiff{Guild: g.id, Old: r, Deleted: true}
+
+	for _, uid := range g.MembersWithRole(rid) {
+		m := g.members[uid]
+		old := m
+
+		roles := make([]snowflake.Snowflake, 0, len(m.roles)-1)
+		for _, rid2 := range m.roles {
+			if rid2 != rid {
+				roles = append(roles, rid2)
+			}
+		}
+		m.roles = roles
+		g.putMember(m)
+
+		diff.Members = append(diff.Members, MemberDiff{Guild: g.id, Old: old, New: m})
+	}
+
+	g.dropRole(rid)
+
+	return diff
+}
+
+// UpsertChannelFromElementMap upserts a Channel in the guild from the given data
+func (g *Guild) UpsertChannelFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	diff := ChannelDiff{Guild: g.id}
+
+	cid, err := etfapi.SnowflakeFromElement(eMap["id"])
+	if err != nil {
+		return diff, errors.Wrap(err, "could not get channel id")
+	}
+
+	c, ok := g.channels[cid]
+	if !ok {
+		c.id = cid
+		diff.Created = true
+	} else {
+		diff.Old = c
+	}
+
+	if err = c.UpdateFromElementMap(eMap); err != nil {
+		return diff, err
+	}
+
+	g.putChannel(c)
+	diff.New = c
+	return diff, nil
+}
+
+// RemoveChannel removes the channel with the provided id from the guild
+func (g *Guild) RemoveChannel(cid snowflake.Snowflake) ChannelDiff {
+	c, ok := g.channels[cid]
+	if !ok {
+		return ChannelDiff{Guild: g.id}
 	}
 
-	g.roles[rid] = r
-	return r, nil
+	g.dropChannel(cid)
+	return ChannelDiff{Guild: g.id, Old: c, Deleted: true}
 }
 
 // GuildFromElementMap creates a new Guild object from the given data
 func GuildFromElementMap(eMap map[string]etfapi.Element) (Guild, error) {
 	g := Guild{
-		channels: map[snowflake.Snowflake]Channel{},
-		members:  map[snowflake.Snowflake]GuildMember{},
-		roles:    map[snowflake.Snowflake]Role{},
+		channels:    map[snowflake.Snowflake]Channel{},
+		members:     map[snowflake.Snowflake]GuildMember{},
+		roles:       map[snowflake.Snowflake]Role{},
+		voiceStates: map[snowflake.Snowflake]VoiceState{},
+		emojis:      map[snowflake.Snowflake]Emoji{},
+		stickers:    map[snowflake.Snowflake]Sticker{},
+		index:       newGuildIndex(),
 	}
 
 	var err error
@@ -0,0 +1,220 @@
+package session
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// nameIndexEntry is a single (lowercased name, member id) pair in a guild's name index
+type nameIndexEntry struct {
+	key string
+	uid snowflake.Snowflake
+}
+
+func (e nameIndexEntry) less(o nameIndexEntry) bool {
+	if e.key != o.key {
+		return e.key < o.key
+	}
+
+	return e.uid < o.uid
+}
+
+// guildIndex holds the secondary indexes used to answer guild queries without
+// scanning all the members, channels, or roles of the guild
+//
+// It is maintained by the Guild put*/drop* methods and is not concurrency safe
+type guildIndex struct {
+	membersByRole map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}
+	names         []nameIndexEntry
+
+	channelPositions map[snowflake.Snowflake]int
+	channelsByType   map[ChannelType][]snowflake.Snowflake
+	channelsByParent map[snowflake.Snowflake][]snowflake.Snowflake
+
+	rolePositions map[snowflake.Snowflake]int
+	roleOrder     []snowflake.Snowflake
+
+	voiceByChannel map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}
+
+	emojisByName   map[string]snowflake.Snowflake
+	stickersByName map[string]snowflake.Snowflake
+}
+
+func newGuildIndex() *guildIndex {
+	return &guildIndex{
+		membersByRole:    map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}{},
+		channelPositions: map[snowflake.Snowflake]int{},
+		channelsByType:   map[ChannelType][]snowflake.Snowflake{},
+		channelsByParent: map[snowflake.Snowflake][]snowflake.Snowflake{},
+		rolePositions:    map[snowflake.Snowflake]int{},
+		voiceByChannel:   map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}{},
+		emojisByName:     map[string]snowflake.Snowflake{},
+		stickersByName:   map[string]snowflake.Snowflake{},
+	}
+}
+
+func memberNameKeys(m GuildMember) []string {
+	keys := make([]string, 0, 2)
+	if m.user.username != "" {
+		keys = append(keys, strings.ToLower(m.user.username))
+	}
+
+	if nick := strings.ToLower(m.nick); nick != "" && (len(keys) == 0 || keys[0] != nick) {
+		keys = append(keys, nick)
+	}
+
+	return keys
+}
+
+// addMember indexes a member; if sorted is false, the caller must call sortNames when done
+func (ix *guildIndex) addMember(m GuildMember, sorted bool) {
+	for _, rid := range m.roles {
+		set, ok := ix.membersByRole[rid]
+		if !ok {
+			set = map[snowflake.Snowflake]struct{}{}
+			ix.membersByRole[rid] = set
+		}
+		set[m.id] = struct{}{}
+	}
+
+	for _, key := range memberNameKeys(m) {
+		entry := nameIndexEntry{key: key, uid: m.id}
+		if !sorted {
+			ix.names = append(ix.names, entry)
+			continue
+		}
+
+		i := sort.Search(len(ix.names), func(i int) bool { return !ix.names[i].less(entry) })
+		ix.names = append(ix.names, nameIndexEntry{})
+		copy(ix.names[i+1:], ix.names[i:])
+		ix.names[i] = entry
+	}
+}
+
+func (ix *guildIndex) removeMember(m GuildMember) {
+	for _, rid := range m.roles {
+		set := ix.membersByRole[rid]
+		delete(set, m.id)
+		if len(set) == 0 {
+			delete(ix.membersByRole, rid)
+		}
+	}
+
+	for _, key := range memberNameKeys(m) {
+		entry := nameIndexEntry{key: key, uid: m.id}
+		i := sort.Search(len(ix.names), func(i int) bool { return !ix.names[i].less(entry) })
+		if i < len(ix.names) && ix.names[i] == entry {
+			ix.names = append(ix.names[:i], ix.names[i+1:]...)
+		}
+	}
+}
+
+func (ix *guildIndex) sortNames() {
+	sort.Slice(ix.names, func(i, j int) bool { return ix.names[i].less(ix.names[j]) })
+}
+
+func (ix *guildIndex) membersWithNamePrefix(prefix string) []snowflake.Snowflake {
+	prefix = strings.ToLower(prefix)
+
+	i := sort.Search(len(ix.names), func(i int) bool { return ix.names[i].key >= prefix })
+
+	seen := map[snowflake.Snowflake]struct{}{}
+	uids := []snowflake.Snowflake{}
+	for ; i < len(ix.names) && strings.HasPrefix(ix.names[i].key, prefix); i++ {
+		uid := ix.names[i].uid
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		uids = append(uids, uid)
+	}
+
+	return uids
+}
+
+// channelBefore determines if channel a sorts before channel b, by position and then by id
+func (ix *guildIndex) channelBefore(a, b snowflake.Snowflake) bool {
+	pa, pb := ix.channelPositions[a], ix.channelPositions[b]
+	if pa != pb {
+		return pa < pb
+	}
+
+	return a < b
+}
+
+func (ix *guildIndex) addChannel(c Channel) {
+	ix.channelPositions[c.id] = c.position
+
+	ix.channelsByType[c.channelType] = insertSnowflake(ix.channelsByType[c.channelType], c.id, ix.channelBefore)
+
+	if c.parentID != 0 {
+		ix.channelsByParent[c.parentID] = insertSnowflake(ix.channelsByParent[c.parentID], c.id, ix.channelBefore)
+	}
+}
+
+func (ix *guildIndex) removeChannel(c Channel) {
+	delete(ix.channelPositions, c.id)
+
+	ix.channelsByType[c.channelType] = removeSnowflake(ix.channelsByType[c.channelType], c.id)
+	if len(ix.channelsByType[c.channelType]) == 0 {
+		delete(ix.channelsByType, c.channelType)
+	}
+
+	if c.parentID != 0 {
+		ix.channelsByParent[c.parentID] = removeSnowflake(ix.channelsByParent[c.parentID], c.id)
+		if len(ix.channelsByParent[c.parentID]) == 0 {
+			delete(ix.channelsByParent, c.parentID)
+		}
+	}
+}
+
+// roleOutranks determines if role a sits above role b in the guild hierarchy
+//
+// Higher positions outrank lower ones; ties are broken in favor of the older (lower id) role
+func (ix *guildIndex) roleOutranks(a, b snowflake.Snowflake) bool {
+	pa, pb := ix.rolePositions[a], ix.rolePositions[b]
+	if pa != pb {
+		return pa > pb
+	}
+
+	return a < b
+}
+
+func (ix *guildIndex) addRole(r Role) {
+	ix.rolePositions[r.id] = r.position
+	ix.roleOrder = insertSnowflake(ix.roleOrder, r.id, ix.roleOutranks)
+}
+
+func (ix *guildIndex) removeRole(r Role) {
+	delete(ix.rolePositions, r.id)
+	ix.roleOrder = removeSnowflake(ix.roleOrder, r.id)
+	delete(ix.membersByRole, r.id)
+}
+
+// insertSnowflake inserts sf into sfs, which must already be sorted by less, keeping it sorted
+func insertSnowflake(sfs []snowflake.Snowflake, sf snowflake.Snowflake, less func(a, b snowflake.Snowflake) bool) []snowflake.Snowflake {
+	i := sort.Search(len(sfs), func(i int) bool { return !less(sfs[i], sf) })
+	sfs = append(sfs, 0)
+	copy(sfs[i+1:], sfs[i:])
+	sfs[i] = sf
+
+	return sfs
+}
+
+func removeSnowflake(sfs []snowflake.Snowflake, sf snowflake.Snowflake) []snowflake.Snowflake {
+	for i, sf2 := range sfs {
+		if sf2 == sf {
+			return append(sfs[:i], sfs[i+1:]...)
+		}
+	}
+
+	return sfs
+}
+
+func copySnowflakes(sfs []snowflake.Snowflake) []snowflake.Snowflake {
+	out := make([]snowflake.Snowflake, len(sfs))
+	copy(out, sfs)
+	return out
+}
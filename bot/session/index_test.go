@@ -0,0 +1,212 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func newTestGuild() *Guild {
+	return &Guild{
+		id:       1,
+		members:  map[snowflake.Snowflake]GuildMember{},
+		channels: map[snowflake.Snowflake]Channel{},
+		roles:    map[snowflake.Snowflake]Role{},
+	}
+}
+
+func TestGuild_channelOrder(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putChannel(Channel{id: 30, channelType: GuildTextChannel, parentID: 9, position: 2})
+	g.putChannel(Channel{id: 10, channelType: GuildTextChannel, parentID: 9, position: 1})
+	g.putChannel(Channel{id: 20, channelType: GuildTextChannel, position: 1})
+	g.putChannel(Channel{id: 40, channelType: GuildVoiceChannel, parentID: 9, position: 0})
+
+	assert.Equal(t, []snowflake.Snowflake{10, 20, 30}, g.ChannelsOfType(GuildTextChannel))
+	assert.Equal(t, []snowflake.Snowflake{40, 10, 30}, g.CategoryChildren(9))
+
+	// moving a channel keeps the others in place
+	g.putChannel(Channel{id: 30, channelType: GuildTextChannel, parentID: 9, position: 0})
+	assert.Equal(t, []snowflake.Snowflake{30, 10, 20}, g.ChannelsOfType(GuildTextChannel))
+	assert.Equal(t, []snowflake.Snowflake{30, 40, 10}, g.CategoryChildren(9))
+
+	g.dropChannel(10)
+	assert.Equal(t, []snowflake.Snowflake{30, 20}, g.ChannelsOfType(GuildTextChannel))
+	assert.Equal(t, []snowflake.Snowflake{30, 40}, g.CategoryChildren(9))
+}
+
+func TestGuild_roleOrder(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putRole(Role{id: 1, position: 0})
+	g.putRole(Role{id: 5, position: 2})
+	g.putRole(Role{id: 3, position: 1})
+	g.putRole(Role{id: 2, position: 1})
+
+	assert.Equal(t, []snowflake.Snowflake{5, 2, 3, 1}, g.RolesByHierarchy())
+
+	g.putRole(Role{id: 1, position: 3})
+	assert.Equal(t, []snowflake.Snowflake{1, 5, 2, 3}, g.RolesByHierarchy())
+
+	g.dropRole(5)
+	assert.Equal(t, []snowflake.Snowflake{1, 2, 3}, g.RolesByHierarchy())
+}
+
+func TestGuild_MembersWithRole(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putMember(GuildMember{id: 7, roles: []snowflake.Snowflake{2, 3}})
+	g.putMember(GuildMember{id: 8, roles: []snowflake.Snowflake{3}})
+	g.putMembers([]GuildMember{
+		{id: 9, roles: []snowflake.Snowflake{2}},
+		{id: 10},
+	})
+
+	assert.ElementsMatch(t, []snowflake.Snowflake{7, 9}, g.MembersWithRole(2))
+	assert.ElementsMatch(t, []snowflake.Snowflake{7, 8}, g.MembersWithRole(3))
+	assert.Equal(t, 2, g.RoleMemberCount(3))
+	assert.Empty(t, g.MembersWithRole(4))
+
+	// changing a member's roles moves them between the sets
+	g.putMember(GuildMember{id: 7, roles: []snowflake.Snowflake{4}})
+	assert.ElementsMatch(t, []snowflake.Snowflake{9}, g.MembersWithRole(2))
+	assert.ElementsMatch(t, []snowflake.Snowflake{8}, g.MembersWithRole(3))
+	assert.ElementsMatch(t, []snowflake.Snowflake{7}, g.MembersWithRole(4))
+
+	g.dropMember(8)
+	assert.Empty(t, g.MembersWithRole(3))
+	assert.Equal(t, 0, g.RoleMemberCount(3))
+}
+
+func TestGuild_MembersWithNamePrefix(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putMembers([]GuildMember{
+		{id: 7, user: User{id: 7, username: "annabel"}, nick: "Anna"},
+		{id: 8, user: User{id: 8, username: "bob"}, nick: "Annie"},
+		{id: 9, user: User{id: 9, username: "andy"}, nick: "ANDY"},
+	})
+	g.putMember(GuildMember{id: 10, user: User{id: 10, username: "carol"}})
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []snowflake.Snowflake
+	}{
+		{name: "nick and username match once", prefix: "ann", want: []snowflake.Snowflake{7, 8}},
+		{name: "nick equal to username is indexed once", prefix: "andy", want: []snowflake.Snowflake{9}},
+		{name: "case insensitive", prefix: "AN", want: []snowflake.Snowflake{9, 7, 8}},
+		{name: "username only", prefix: "bo", want: []snowflake.Snowflake{8}},
+		{name: "no nick", prefix: "car", want: []snowflake.Snowflake{10}},
+		{name: "no match", prefix: "zed", want: []snowflake.Snowflake{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, g.MembersWithNamePrefix(tt.prefix))
+		})
+	}
+}
+
+func TestGuild_MembersWithNamePrefix_removal(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putMember(GuildMember{id: 7, user: User{id: 7, username: "annabel"}, nick: "Anna"})
+	g.putMember(GuildMember{id: 8, user: User{id: 8, username: "bob"}, nick: "Annie"})
+
+	// a changed nick drops the old name from the index
+	g.putMember(GuildMember{id: 8, user: User{id: 8, username: "bob"}, nick: "Robert"})
+	assert.Equal(t, []snowflake.Snowflake{7}, g.MembersWithNamePrefix("ann"))
+	assert.Equal(t, []snowflake.Snowflake{8}, g.MembersWithNamePrefix("rob"))
+
+	g.dropMember(7)
+	assert.Empty(t, g.MembersWithNamePrefix("ann"))
+	assert.Len(t, g.index.names, 2)
+}
+
+func TestGuild_HighestRole(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putRole(Role{id: 2, position: 1})
+	g.putRole(Role{id: 3, position: 2})
+	g.putRole(Role{id: 4, position: 2})
+	g.putMember(GuildMember{id: 7, roles: []snowflake.Snowflake{2, 3}})
+	g.putMember(GuildMember{id: 8, roles: []snowflake.Snowflake{3, 4}})
+	g.putMember(GuildMember{id: 9, roles: []snowflake.Snowflake{5}})
+	g.putMember(GuildMember{id: 10})
+
+	tests := []struct {
+		name   string
+		uid    snowflake.Snowflake
+		want   snowflake.Snowflake
+		wantOK bool
+	}{
+		{name: "highest position", uid: 7, want: 3, wantOK: true},
+		{name: "position tie goes to the lower id", uid: 8, want: 3, wantOK: true},
+		{name: "only unknown roles", uid: 9},
+		{name: "no roles", uid: 10},
+		{name: "unknown member", uid: 11},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := g.HighestRole(tt.uid)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestState_GuildsWithUser(t *testing.T) {
+	t.Parallel()
+
+	s := newState()
+
+	for _, gid := range []int64{1, 2} {
+		_, err := s.UpsertGuildFromElementMap(map[string]etfapi.Element{
+			"id": mustElement(etfapi.NewSmallBigElement(gid)),
+			"members": mustElement(etfapi.NewListElement([]etfapi.Element{
+				mustElement(etfapi.NewMapElement(memberElementMap(7, "Ann"))),
+			})),
+		})
+		require.NoError(t, err)
+	}
+
+	assert.ElementsMatch(t, []snowflake.Snowflake{1, 2}, s.GuildsWithUser(7))
+	assert.Empty(t, s.GuildsWithUser(8))
+
+	join := memberElementMap(8, "Bob")
+	join["guild_id"] = mustElement(etfapi.NewSmallBigElement(2))
+	_, err := s.UpsertGuildMemberFromElementMap(join)
+	require.NoError(t, err)
+	assert.Equal(t, []snowflake.Snowflake{2}, s.GuildsWithUser(8))
+
+	leave := memberElementMap(7, "")
+	leave["guild_id"] = mustElement(etfapi.NewSmallBigElement(1))
+	_, err = s.RemoveGuildMemberFromElementMap(leave)
+	require.NoError(t, err)
+	assert.Equal(t, []snowflake.Snowflake{2}, s.GuildsWithUser(7))
+
+	leave["guild_id"] = mustElement(etfapi.NewSmallBigElement(2))
+	_, err = s.RemoveGuildMemberFromElementMap(leave)
+	require.NoError(t, err)
+	assert.Empty(t, s.GuildsWithUser(7))
+	assert.NotContains(t, s.userGuilds, snowflake.Snowflake(7))
+}
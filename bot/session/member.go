@@ -11,9 +11,52 @@ import (
 type GuildMember struct {
 	id    snowflake.Snowflake
 	user  User
+	nick  string
 	roles []snowflake.Snowflake
 }
 
+// ID returns the user id of the guild member
+func (m *GuildMember) ID() snowflake.Snowflake {
+	return m.id
+}
+
+// User returns the user information of the guild member
+func (m *GuildMember) User() User {
+	return m.user
+}
+
+// Nick returns the guild nickname of the member (or an empty string if none is set)
+func (m *GuildMember) Nick() string {
+	return m.nick
+}
+
+// DisplayName returns the nickname of the member if one is set, and the username otherwise
+func (m *GuildMember) DisplayName() string {
+	if m.nick != "" {
+		return m.nick
+	}
+
+	return m.user.username
+}
+
+// Roles returns a copy of the role ids the member is known to have
+func (m *GuildMember) Roles() []snowflake.Snowflake {
+	rids := make([]snowflake.Snowflake, len(m.roles))
+	copy(rids, m.roles)
+	return rids
+}
+
+// HasRole determines if the member is known to have the role with the provided id
+func (m *GuildMember) HasRole(rid snowflake.Snowflake) bool {
+	for _, rid2 := range m.roles {
+		if rid2 == rid {
+			return true
+		}
+	}
+
+	return false
+}
+
 // UpdateFromElementMap updates the information from the given data
 //
 // This will not remove data; it will only add and change data
@@ -45,6 +88,17 @@ func (m *GuildMember) UpdateFromElementMap(eMap map[string]etfapi.Element) error
 		}
 	}
 
+	if e, ok := eMap["nick"]; ok {
+		if e.IsNil() {
+			m.nick = ""
+		} else {
+			m.nick, err = e.ToString()
+			if err != nil {
+				return errors.Wrap(err, "could not get nick")
+			}
+		}
+	}
+
 	if rList, ok := eMap["roles"]; ok {
 		rEList, err = rList.ToList()
 		if err != nil {
@@ -0,0 +1,396 @@
+package session
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// These are the names of the change events generated from the message cache
+const (
+	EventMessageEdited  = "MESSAGE_EDITED"
+	EventMessageDeleted = "MESSAGE_DELETED"
+)
+
+// MessageCacheOptions configures a MessageCache
+//
+// Zero values select the defaults: 50 messages per channel, 10000 messages and 32MiB total, and no expiry
+type MessageCacheOptions struct {
+	// PerChannel is the number of messages kept for each channel
+	PerChannel int
+	// MaxMessages is the number of messages kept across all channels
+	MaxMessages int
+	// MaxBytes is the approximate memory budget, in bytes, of the messages kept across all channels;
+	// it is estimated from the content, embeds, and attachments of each message
+	MaxBytes int
+	// TTL is how long a message is kept after it was cached (forever if 0)
+	TTL time.Duration
+}
+
+// messageOverhead approximates the memory used by a cached message apart from its strings
+const messageOverhead = 512
+
+type cachedMessage struct {
+	msg     entity.Message
+	size    int
+	added   time.Time
+	global  *list.Element
+	channel *list.Element
+}
+
+// MessageCache keeps the most recent messages seen in each channel so that edits
+// and deletions can be reported along with the prior message content
+//
+// Messages are evicted oldest-first when a channel holds more than PerChannel messages,
+// when the cache holds more than MaxMessages messages or MaxBytes bytes in total, or once they
+// are older than TTL. A MessageCache is safe for concurrent use
+type MessageCache struct {
+	lock *sync.Mutex
+	opts MessageCacheOptions
+	now  func() time.Time
+
+	bytes    int
+	messages map[snowflake.Snowflake]*cachedMessage
+	channels map[snowflake.Snowflake]*list.List
+	order    *list.List
+}
+
+// NewMessageCache creates a new, empty MessageCache
+func NewMessageCache(opts MessageCacheOptions) *MessageCache {
+	if opts.PerChannel <= 0 {
+		opts.PerChannel = 50
+	}
+
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = 10000
+	}
+
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 32 << 20
+	}
+
+	return &MessageCache{
+		lock:     &sync.Mutex{},
+		opts:     opts,
+		now:      time.Now,
+		messages: map[snowflake.Snowflake]*cachedMessage{},
+		channels: map[snowflake.Snowflake]*list.List{},
+		order:    list.New(),
+	}
+}
+
+// Len returns the number of messages currently in the cache
+func (c *MessageCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+	return len(c.messages)
+}
+
+// Add stores a message in the cache, evicting older messages as necessary
+func (c *MessageCache) Add(m entity.Message) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+
+	if cm, ok := c.messages[m.IDSnowflake]; ok {
+		c.replace(cm, m)
+		c.evict()
+		return
+	}
+
+	chList, ok := c.channels[m.ChannelIDSnowflake]
+	if !ok {
+		chList = list.New()
+		c.channels[m.ChannelIDSnowflake] = chList
+	}
+
+	cm := &cachedMessage{msg: m, size: messageSize(m), added: c.now()}
+	cm.global = c.order.PushBack(cm)
+	cm.channel = chList.PushBack(cm)
+	c.messages[m.IDSnowflake] = cm
+	c.bytes += cm.size
+
+	for chList.Len() > c.opts.PerChannel {
+		c.remove(chList.Front().Value.(*cachedMessage))
+	}
+
+	c.evict()
+}
+
+// Bytes returns the approximate memory used by the messages currently in the cache
+func (c *MessageCache) Bytes() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+	return c.bytes
+}
+
+// Message finds a cached message by channel and message id
+//
+// The second return value will be false if the message is not in the cache
+func (c *MessageCache) Message(cid, mid snowflake.Snowflake) (entity.Message, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+
+	cm, ok := c.messages[mid]
+	if !ok || cm.msg.ChannelIDSnowflake != cid {
+		return entity.Message{}, false
+	}
+
+	return cm.msg, true
+}
+
+// ChannelMessages returns the cached messages of a channel, oldest first
+func (c *MessageCache) ChannelMessages(cid snowflake.Snowflake) []entity.Message {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+
+	chList, ok := c.channels[cid]
+	if !ok {
+		return nil
+	}
+
+	msgs := make([]entity.Message, 0, chList.Len())
+	for e := chList.Front(); e != nil; e = e.Next() {
+		msgs = append(msgs, e.Value.(*cachedMessage).msg)
+	}
+
+	return msgs
+}
+
+// UpdateFromElementMap applies MESSAGE_UPDATE data to a cached message
+//
+// If the message was not cached, the returned event will have Cached set to false and
+// New will contain only the data present in the update
+func (c *MessageCache) UpdateFromElementMap(eMap map[string]etfapi.Element) (MessageEdited, error) {
+	var evt MessageEdited
+
+	mid, err := etfapi.SnowflakeFromUnknownElement(eMap["id"])
+	if err != nil {
+		return evt, errors.Wrap(err, "could not get message id")
+	}
+
+	evt.Channel, err = etfapi.SnowflakeFromUnknownElement(eMap["channel_id"])
+	if err != nil {
+		return evt, errors.Wrap(err, "could not get channel id")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+
+	var m entity.Message
+	if cm, ok := c.messages[mid]; ok {
+		evt.Cached = true
+		evt.Old = cm.msg
+		m = cm.msg
+	} else {
+		m.IDSnowflake, m.IDString = mid, mid.ToString()
+		m.ChannelIDSnowflake, m.ChannelIDString = evt.Channel, evt.Channel.ToString()
+	}
+
+	if err = m.UpdateFromElementMap(eMap); err != nil {
+		return evt, errors.Wrap(err, "could not apply message update")
+	}
+
+	if cm, ok := c.messages[mid]; ok {
+		c.replace(cm, m)
+		c.evict()
+	}
+
+	evt.Guild = m.GuildIDSnowflake
+	evt.New = m
+	return evt, nil
+}
+
+// DeleteFromElementMap removes the messages referenced by MESSAGE_DELETE or MESSAGE_DELETE_BULK data
+// from the cache, returning an event for each message with its prior content if it was cached
+func (c *MessageCache) DeleteFromElementMap(eMap map[string]etfapi.Element) ([]MessageDeleted, error) {
+	var gid snowflake.Snowflake
+	var mids []snowflake.Snowflake
+
+	cid, err := etfapi.SnowflakeFromUnknownElement(eMap["channel_id"])
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get channel id")
+	}
+
+	if e, ok := eMap["guild_id"]; ok && !e.IsNil() {
+		gid, err = etfapi.SnowflakeFromUnknownElement(e)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get guild id")
+		}
+	}
+
+	if e, ok := eMap["ids"]; ok {
+		for _, e2 := range e.Vals {
+			mid, err := etfapi.SnowflakeFromUnknownElement(e2)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not get message id")
+			}
+			mids = append(mids, mid)
+		}
+	} else {
+		mid, err := etfapi.SnowflakeFromUnknownElement(eMap["id"])
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get message id")
+		}
+		mids = append(mids, mid)
+	}
+
+	evts := make([]MessageDeleted, 0, len(mids))
+	for _, mid := range mids {
+		m, ok := c.Delete(cid, mid)
+		evts = append(evts, MessageDeleted{Guild: gid, Channel: cid, MessageID: mid, Message: m, Cached: ok})
+	}
+
+	return evts, nil
+}
+
+// Delete removes a message from the cache, returning it if it was present
+func (c *MessageCache) Delete(cid, mid snowflake.Snowflake) (entity.Message, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.expire()
+
+	cm, ok := c.messages[mid]
+	if !ok || cm.msg.ChannelIDSnowflake != cid {
+		return entity.Message{}, false
+	}
+
+	c.remove(cm)
+	return cm.msg, true
+}
+
+// DeleteChannel removes all the messages of a channel from the cache
+func (c *MessageCache) DeleteChannel(cid snowflake.Snowflake) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	chList, ok := c.channels[cid]
+	if !ok {
+		return
+	}
+
+	for chList.Len() > 0 {
+		c.remove(chList.Front().Value.(*cachedMessage))
+	}
+}
+
+// expire evicts the messages older than the TTL; the caller must hold the lock
+func (c *MessageCache) expire() {
+	if c.opts.TTL <= 0 {
+		return
+	}
+
+	cutoff := c.now().Add(-c.opts.TTL)
+	for c.order.Len() > 0 {
+		cm := c.order.Front().Value.(*cachedMessage)
+		if cm.added.After(cutoff) {
+			return
+		}
+
+		c.remove(cm)
+	}
+}
+
+// evict removes the oldest messages until the cache is within MaxMessages and MaxBytes; the caller must hold the lock
+func (c *MessageCache) evict() {
+	for c.order.Len() > 0 && (c.order.Len() > c.opts.MaxMessages || c.bytes > c.opts.MaxBytes) {
+		c.remove(c.order.Front().Value.(*cachedMessage))
+	}
+}
+
+// replace swaps the content of a cached message, keeping its place in the cache; the caller must hold the lock
+func (c *MessageCache) replace(cm *cachedMessage, m entity.Message) {
+	size := messageSize(m)
+	c.bytes += size - cm.size
+	cm.msg, cm.size = m, size
+}
+
+// remove evicts a single message; the caller must hold the lock
+func (c *MessageCache) remove(cm *cachedMessage) {
+	c.bytes -= cm.size
+	c.order.Remove(cm.global)
+
+	cid := cm.msg.ChannelIDSnowflake
+	if chList, ok := c.channels[cid]; ok {
+		chList.Remove(cm.channel)
+		if chList.Len() == 0 {
+			delete(c.channels, cid)
+		}
+	}
+
+	delete(c.messages, cm.msg.IDSnowflake)
+}
+
+// messageSize estimates the memory used by a message from its content, embeds, and attachments
+func messageSize(m entity.Message) int {
+	size := messageOverhead + len(m.Content)
+
+	for _, e := range m.Embeds {
+		size += messageOverhead + len(e.Title) + len(e.Description) + len(e.URL) +
+			len(e.Footer.Text) + len(e.Footer.IconURL) + len(e.Author.Name) + len(e.Author.URL) + len(e.Author.IconURL) +
+			len(e.Image.URL) + len(e.Thumbnail.URL) + len(e.Video.URL)
+
+		for _, f := range e.Fields {
+			size += len(f.Name) + len(f.Value)
+		}
+	}
+
+	for _, a := range m.Attachments {
+		size += len(a.Filename) + len(a.URL) + len(a.ProxyURL)
+	}
+
+	return size
+}
+
+// MessageEdited is the event generated when a message is edited
+//
+// If Cached is false, the prior content of the message was not known, Old is the
+// zero value, and New contains only the data present in the update
+type MessageEdited struct {
+	Guild   snowflake.Snowflake
+	Channel snowflake.Snowflake
+	Old     entity.Message
+	New     entity.Message
+	Cached  bool
+}
+
+// EventName returns the name of the event
+func (e MessageEdited) EventName() string { return EventMessageEdited }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e MessageEdited) GuildID() snowflake.Snowflake { return e.Guild }
+
+// MessageDeleted is the event generated when a message is deleted (individually or in bulk)
+//
+// If Cached is false, the content of the message was not known and Message is the zero value
+type MessageDeleted struct {
+	Guild     snowflake.Snowflake
+	Channel   snowflake.Snowflake
+	MessageID snowflake.Snowflake
+	Message   entity.Message
+	Cached    bool
+}
+
+// EventName returns the name of the event
+func (e MessageDeleted) EventName() string { return EventMessageDeleted }
+
+// GuildID returns the id of the guild the event occurred in (0 for private channels)
+func (e MessageDeleted) GuildID() snowflake.Snowflake { return e.Guild }
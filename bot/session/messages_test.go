@@ -0,0 +1,185 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func testMessage(cid, mid snowflake.Snowflake) entity.Message {
+	return entity.Message{
+		IDSnowflake:        mid,
+		IDString:           mid.ToString(),
+		ChannelIDSnowflake: cid,
+		ChannelIDString:    cid.ToString(),
+		Content:            "message " + mid.ToString(),
+	}
+}
+
+func messageIDs(msgs []entity.Message) []snowflake.Snowflake {
+	ids := make([]snowflake.Snowflake, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.IDSnowflake)
+	}
+	return ids
+}
+
+func TestMessageCache_eviction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     MessageCacheOptions
+		adds     [][2]snowflake.Snowflake
+		wantLen  int
+		wantByCh map[snowflake.Snowflake][]snowflake.Snowflake
+	}{
+		{
+			name:    "per channel",
+			opts:    MessageCacheOptions{PerChannel: 2},
+			adds:    [][2]snowflake.Snowflake{{1, 10}, {1, 11}, {2, 20}, {1, 12}},
+			wantLen: 3,
+			wantByCh: map[snowflake.Snowflake][]snowflake.Snowflake{
+				1: {11, 12},
+				2: {20},
+			},
+		},
+		{
+			name:    "message count across channels",
+			opts:    MessageCacheOptions{PerChannel: 5, MaxMessages: 3},
+			adds:    [][2]snowflake.Snowflake{{1, 10}, {2, 20}, {1, 11}, {3, 30}, {2, 21}},
+			wantLen: 3,
+			wantByCh: map[snowflake.Snowflake][]snowflake.Snowflake{
+				1: {11},
+				2: {21},
+				3: {30},
+			},
+		},
+		{
+			name:    "memory budget across channels",
+			opts:    MessageCacheOptions{PerChannel: 5, MaxBytes: 2 * (messageOverhead + 10)},
+			adds:    [][2]snowflake.Snowflake{{1, 10}, {2, 20}, {1, 11}},
+			wantLen: 2,
+			wantByCh: map[snowflake.Snowflake][]snowflake.Snowflake{
+				1: {11},
+				2: {20},
+			},
+		},
+		{
+			name:    "re-adding a cached message does not count twice",
+			opts:    MessageCacheOptions{PerChannel: 2},
+			adds:    [][2]snowflake.Snowflake{{1, 10}, {1, 11}, {1, 11}, {1, 10}},
+			wantLen: 2,
+			wantByCh: map[snowflake.Snowflake][]snowflake.Snowflake{
+				1: {10, 11},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewMessageCache(tt.opts)
+			for _, a := range tt.adds {
+				c.Add(testMessage(a[0], a[1]))
+			}
+
+			assert.Equal(t, tt.wantLen, c.Len())
+			for cid, want := range tt.wantByCh {
+				assert.Equal(t, want, messageIDs(c.ChannelMessages(cid)), "channel %d", cid)
+			}
+		})
+	}
+}
+
+func TestMessageCache_Bytes(t *testing.T) {
+	t.Parallel()
+
+	small := messageOverhead + len("message 10")
+	c := NewMessageCache(MessageCacheOptions{MaxBytes: 6 * small})
+
+	c.Add(testMessage(1, 10))
+	c.Add(testMessage(1, 11))
+	assert.Equal(t, 2*small, c.Bytes())
+
+	// embeds and attachments count towards the budget
+	big := testMessage(2, 20)
+	big.Embeds = []entity.Embed{{
+		Description: strings.Repeat("x", small),
+		Fields:      []entity.EmbedField{{Name: "a", Value: "b"}},
+	}}
+	big.Attachments = []entity.Attachment{{Filename: "a.png", URL: "https://cdn/a.png"}}
+	bigSize := 2*messageOverhead + len("message 20") + small + 2 + len("a.png") + len("https://cdn/a.png")
+	assert.Equal(t, bigSize, messageSize(big))
+
+	c.Add(big)
+	assert.Equal(t, 2*small+bigSize, c.Bytes())
+
+	// an edit that grows a message evicts the oldest ones
+	big.Content += strings.Repeat("y", 1200)
+	c.Add(big)
+	assert.Nil(t, c.ChannelMessages(1))
+	assert.Equal(t, []snowflake.Snowflake{20}, messageIDs(c.ChannelMessages(2)))
+	assert.Equal(t, bigSize+1200, c.Bytes())
+
+	_, ok := c.Delete(2, 20)
+	assert.True(t, ok)
+	assert.Equal(t, 0, c.Bytes())
+
+	// a message over the whole budget is not kept
+	c.Add(entity.Message{IDSnowflake: 30, ChannelIDSnowflake: 3, Content: strings.Repeat("z", 6*small)})
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 0, c.Bytes())
+}
+
+func TestMessageCache_ttl(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	c := NewMessageCache(MessageCacheOptions{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Add(testMessage(1, 10))
+	now = now.Add(40 * time.Second)
+	c.Add(testMessage(1, 11))
+
+	_, ok := c.Message(1, 10)
+	assert.True(t, ok)
+
+	// the first message expires, the second has 20s left
+	now = now.Add(30 * time.Second)
+	_, ok = c.Message(1, 10)
+	assert.False(t, ok)
+
+	m, ok := c.Message(1, 11)
+	assert.True(t, ok)
+	assert.Equal(t, "message 11", m.Content)
+
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 0, c.Len())
+	assert.Nil(t, c.ChannelMessages(1))
+}
+
+func TestMessageCache_Delete(t *testing.T) {
+	t.Parallel()
+
+	c := NewMessageCache(MessageCacheOptions{})
+	c.Add(testMessage(1, 10))
+	c.Add(testMessage(1, 11))
+
+	// the channel must match
+	_, ok := c.Delete(2, 10)
+	assert.False(t, ok)
+
+	m, ok := c.Delete(1, 10)
+	assert.True(t, ok)
+	assert.Equal(t, snowflake.Snowflake(10), m.IDSnowflake)
+	assert.Equal(t, []snowflake.Snowflake{11}, messageIDs(c.ChannelMessages(1)))
+}
@@ -18,6 +18,45 @@ type Role struct {
 	id          snowflake.Snowflake
 	name        string
 	permissions int64
+	position    int
+	color       int
+	hoist       bool
+	mentionable bool
+}
+
+// ID returns the role's ID
+func (r *Role) ID() snowflake.Snowflake {
+	return r.id
+}
+
+// Name returns the role's name
+func (r *Role) Name() string {
+	return r.name
+}
+
+// Permissions returns the role's permission bitset
+func (r *Role) Permissions() int64 {
+	return r.permissions
+}
+
+// Position returns the role's position in the guild hierarchy
+func (r *Role) Position() int {
+	return r.position
+}
+
+// Color returns the role's color
+func (r *Role) Color() int {
+	return r.color
+}
+
+// Hoisted determines if the role is displayed separately in the member list
+func (r *Role) Hoisted() bool {
+	return r.hoist
+}
+
+// Mentionable determines if the role can be mentioned
+func (r *Role) Mentionable() bool {
+	return r.mentionable
 }
 
 // IsAdmin determines if a role is a server admin
@@ -55,6 +94,38 @@ func (r *Role) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
 		}
 	}
 
+	e2, ok = eMap["position"]
+	if ok {
+		r.position, err = e2.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get position")
+		}
+	}
+
+	e2, ok = eMap["color"]
+	if ok {
+		r.color, err = e2.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get color")
+		}
+	}
+
+	e2, ok = eMap["hoist"]
+	if ok {
+		r.hoist, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get hoist")
+		}
+	}
+
+	e2, ok = eMap["mentionable"]
+	if ok {
+		r.mentionable, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get mentionable")
+		}
+	}
+
 	return nil
 }
 
@@ -56,6 +56,22 @@ func (s *Session) GuildIDs() []snowflake.Snowflake {
 	return s.state.GuildIDs()
 }
 
+// GuildCount returns the number of guilds in the current session state
+func (s *Session) GuildCount() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.state.GuildCount()
+}
+
+// GuildsWithUser returns the ids of the guilds the user with the provided id shares with the bot
+func (s *Session) GuildsWithUser(uid snowflake.Snowflake) []snowflake.Snowflake {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.state.GuildsWithUser(uid)
+}
+
 // GuildOfChannel returns the id of the guild that owns the channel with the provided id, if one is known
 //
 // The second return value will be false if no such guild was found
@@ -90,6 +106,87 @@ func (s *Session) IsGuildAdmin(gid, uid snowflake.Snowflake) bool {
 	return g.IsAdmin(uid)
 }
 
+// UserID returns the id of the bot user of the session (or 0 if the session is not yet ready)
+func (s *Session) UserID() snowflake.Snowflake {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.state.user.id
+}
+
+// CanModerate determines if the member with id actor can kick, ban, time out, or rename
+// the member with id target in the guild with id gid (see Guild.CanModerate)
+//
+// This will be false if the guild is not known
+func (s *Session) CanModerate(gid, actor, target snowflake.Snowflake) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	g, ok := s.state.Guild(gid)
+	if !ok {
+		return false
+	}
+
+	return g.CanModerate(actor, target)
+}
+
+// CanManageRole determines if the member with id actor can grant or remove the role with
+// id rid in the guild with id gid (see Guild.CanManageRole)
+//
+// This will be false if the guild is not known
+func (s *Session) CanManageRole(gid, actor, rid snowflake.Snowflake) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	g, ok := s.state.Guild(gid)
+	if !ok {
+		return false
+	}
+
+	return g.CanManageRole(actor, rid)
+}
+
+// BotHierarchy answers role hierarchy questions about the bot user of a session
+//
+// It can be given to jsonapi.DiscordJSONClient.SetHierarchyChecker. Until the session is ready or the
+// guild is known, it allows everything, leaving the decision to discord
+type BotHierarchy struct {
+	s *Session
+}
+
+// BotHierarchy returns the role hierarchy checks for the bot user of the session
+func (s *Session) BotHierarchy() BotHierarchy {
+	return BotHierarchy{s: s}
+}
+
+// CanModerate determines if the bot can kick, ban, time out, or rename the member with id uid
+func (h BotHierarchy) CanModerate(gid, uid snowflake.Snowflake) bool {
+	botID := h.s.UserID()
+	if botID == 0 || botID == uid {
+		return true
+	}
+
+	if _, ok := h.s.Guild(gid); !ok {
+		return true
+	}
+
+	return h.s.CanModerate(gid, botID, uid)
+}
+
+// CanManageRole determines if the bot can grant or remove the role with id rid
+func (h BotHierarchy) CanManageRole(gid, rid snowflake.Snowflake) bool {
+	botID := h.s.UserID()
+	if botID == 0 {
+		return true
+	}
+
+	if _, ok := h.s.Guild(gid); !ok {
+		return true
+	}
+
+	return h.s.CanManageRole(gid, botID, rid)
+}
+
 // UpsertGuildFromElement updates data in the session state for a guild based on the given Element
 func (s *Session) UpsertGuildFromElement(e etfapi.Element) (snowflake.Snowflake, error) {
 	s.lock.Lock()
@@ -107,23 +204,48 @@ func (s *Session) UpsertGuildFromElementMap(eMap map[string]etfapi.Element) (sno
 }
 
 // UpsertGuildMemberFromElementMap updates data in the session state for a guild member based on the given data
-func (s *Session) UpsertGuildMemberFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, error) {
+func (s *Session) UpsertGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	return s.state.UpsertGuildMemberFromElementMap(eMap)
 }
 
+// UpdateGuildMemberFromElementMap updates data in the session state for a guild member based on the data
+// of a member update (an uncached member is not reported as having joined)
+func (s *Session) UpdateGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpdateGuildMemberFromElementMap(eMap)
+}
+
+// RemoveGuildMemberFromElementMap removes a guild member from the session state based on the given data
+func (s *Session) RemoveGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.RemoveGuildMemberFromElementMap(eMap)
+}
+
 // UpsertGuildRoleFromElementMap updates data in the session state for a guild role based on the given data
-func (s *Session) UpsertGuildRoleFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, error) {
+func (s *Session) UpsertGuildRoleFromElementMap(eMap map[string]etfapi.Element) (RoleDiff, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	return s.state.UpsertGuildRoleFromElementMap(eMap)
 }
 
+// RemoveGuildRoleFromElementMap removes a guild role from the session state based on the given data
+func (s *Session) RemoveGuildRoleFromElementMap(eMap map[string]etfapi.Element) (RoleDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.RemoveGuildRoleFromElementMap(eMap)
+}
+
 // UpsertChannelFromElement updates data in the session state for a channel based on the given Element
-func (s *Session) UpsertChannelFromElement(e etfapi.Element) (snowflake.Snowflake, error) {
+func (s *Session) UpsertChannelFromElement(e etfapi.Element) (ChannelDiff, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
@@ -131,13 +253,98 @@ func (s *Session) UpsertChannelFromElement(e etfapi.Element) (snowflake.Snowflak
 }
 
 // UpsertChannelFromElementMap updates data in the session state for a channel based on the given data
-func (s *Session) UpsertChannelFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, snowflake.Snowflake, error) {
+func (s *Session) UpsertChannelFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	return s.state.UpsertChannelFromElementMap(eMap)
 }
 
+// RemoveChannelFromElementMap removes a channel from the session state based on the given data
+func (s *Session) RemoveChannelFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.RemoveChannelFromElementMap(eMap)
+}
+
+// SyncThreadsFromElementMap updates the active threads of a guild in the session state from THREAD_LIST_SYNC data
+func (s *Session) SyncThreadsFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, []ChannelDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.SyncThreadsFromElementMap(eMap)
+}
+
+// UpdateThreadMemberFromElementMap updates the bot's thread membership in the session state from THREAD_MEMBER_UPDATE data
+func (s *Session) UpdateThreadMemberFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpdateThreadMemberFromElementMap(eMap)
+}
+
+// UpdateThreadMembersFromElementMap updates thread membership information in the session state from THREAD_MEMBERS_UPDATE data
+func (s *Session) UpdateThreadMembersFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpdateThreadMembersFromElementMap(eMap)
+}
+
+// UpsertVoiceStateFromElementMap updates a member's voice state in the session state from VOICE_STATE_UPDATE data
+func (s *Session) UpsertVoiceStateFromElementMap(eMap map[string]etfapi.Element) (VoiceDiff, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpsertVoiceStateFromElementMap(eMap)
+}
+
+// VoiceChannelOf finds the id of the voice channel the user with the provided id is connected to in a guild
+//
+// The second return value will be false if the user is not known to be in a voice channel
+func (s *Session) VoiceChannelOf(gid, uid snowflake.Snowflake) (snowflake.Snowflake, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	g, ok := s.state.Guild(gid)
+	if !ok {
+		return 0, false
+	}
+
+	return g.VoiceChannelOf(uid)
+}
+
+// MembersInVoiceChannel returns the ids of the members connected to the voice channel with the provided id
+func (s *Session) MembersInVoiceChannel(cid snowflake.Snowflake) []snowflake.Snowflake {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	gid, ok := s.state.GuildOfChannel(cid)
+	if !ok {
+		return nil
+	}
+
+	g, _ := s.state.Guild(gid)
+	return g.MembersInVoiceChannel(cid)
+}
+
+// UpdateGuildEmojisFromElementMap replaces the emojis of a guild in the session state from GUILD_EMOJIS_UPDATE data
+func (s *Session) UpdateGuildEmojisFromElementMap(eMap map[string]etfapi.Element) (EmojisUpdated, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpdateGuildEmojisFromElementMap(eMap)
+}
+
+// UpdateGuildStickersFromElementMap replaces the stickers of a guild in the session state from GUILD_STICKERS_UPDATE data
+func (s *Session) UpdateGuildStickersFromElementMap(eMap map[string]etfapi.Element) (StickersUpdated, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.state.UpdateGuildStickersFromElementMap(eMap)
+}
+
 // UpdateFromReady updates data in the session state from a session ready message, and updates the session id
 func (s *Session) UpdateFromReady(data map[string]etfapi.Element) error {
 	s.lock.Lock()
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func TestSession_BotHierarchy(t *testing.T) {
+	t.Parallel()
+
+	s := NewSession()
+	h := s.BotHierarchy()
+
+	// before the session is ready, discord decides
+	assert.True(t, h.CanModerate(1, 3))
+	assert.True(t, h.CanManageRole(1, 20))
+
+	s.state.user.id = 2
+
+	g := newTestGuild()
+	g.putRole(Role{id: 10, position: 1})
+	g.putRole(Role{id: 20, position: 2})
+	g.putRole(Role{id: 30, position: 3})
+	g.putMember(GuildMember{id: 2, roles: []snowflake.Snowflake{20}})
+	g.putMember(GuildMember{id: 3, roles: []snowflake.Snowflake{10}})
+	g.putMember(GuildMember{id: 4, roles: []snowflake.Snowflake{30}})
+	s.state.guilds[g.id] = *g
+
+	assert.True(t, h.CanModerate(1, 3))
+	assert.False(t, h.CanModerate(1, 4))
+	assert.True(t, h.CanModerate(1, 2), "the bot itself")
+	assert.True(t, h.CanModerate(99, 4), "unknown guild")
+
+	assert.True(t, h.CanManageRole(1, 10))
+	assert.False(t, h.CanManageRole(1, 20))
+	assert.False(t, h.CanManageRole(1, 30))
+	assert.True(t, h.CanManageRole(99, 30), "unknown guild")
+}
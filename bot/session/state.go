@@ -15,6 +15,7 @@ type state struct {
 	user            User
 	guilds          map[snowflake.Snowflake]Guild
 	privateChannels map[snowflake.Snowflake]Channel
+	userGuilds      map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}
 }
 
 // newState constructs a new, empty state
@@ -22,6 +23,33 @@ func newState() *state {
 	return &state{
 		guilds:          map[snowflake.Snowflake]Guild{},
 		privateChannels: map[snowflake.Snowflake]Channel{},
+		userGuilds:      map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}{},
+	}
+}
+
+// indexUserGuild records that the user with id uid is a member of the guild with id gid
+func (s *state) indexUserGuild(uid, gid snowflake.Snowflake) {
+	gids, ok := s.userGuilds[uid]
+	if !ok {
+		gids = map[snowflake.Snowflake]struct{}{}
+		s.userGuilds[uid] = gids
+	}
+	gids[gid] = struct{}{}
+}
+
+// unindexUserGuild records that the user with id uid is no longer a member of the guild with id gid
+func (s *state) unindexUserGuild(uid, gid snowflake.Snowflake) {
+	gids := s.userGuilds[uid]
+	delete(gids, gid)
+	if len(gids) == 0 {
+		delete(s.userGuilds, uid)
+	}
+}
+
+// indexGuildMembers records the membership of every known member of the guild
+func (s *state) indexGuildMembers(g *Guild) {
+	for uid := range g.members {
+		s.indexUserGuild(uid, g.id)
 	}
 }
 
@@ -86,6 +114,7 @@ func (s *state) UpdateFromReady(data map[string]etfapi.Element) error {
 			}
 		}
 		s.guilds[gid] = g
+		s.indexGuildMembers(&g)
 	}
 
 	return nil
@@ -100,10 +129,13 @@ func (s *state) UpsertGuildFromElement(e etfapi.Element) (snowflake.Snowflake, e
 
 	g, ok := s.guilds[id]
 	if !ok {
-		s.guilds[id], err = GuildFromElement(e)
+		g, err = GuildFromElement(e)
 		if err != nil {
 			return id, errors.Wrap(err, "UpsertGuildFromElement could not insert guild into the session")
 		}
+		s.guilds[id] = g
+		s.indexGuildMembers(&g)
+
 		return id, nil
 	}
 
@@ -112,6 +144,9 @@ func (s *state) UpsertGuildFromElement(e etfapi.Element) (snowflake.Snowflake, e
 		return id, errors.Wrap(err, "UpsertGuildFromElement could not update guild into the session")
 	}
 	s.guilds[id] = g
+	if _, ok := eMap["members"]; ok {
+		s.indexGuildMembers(&g)
+	}
 
 	return id, nil
 }
@@ -135,6 +170,7 @@ func (s *state) UpsertGuildFromElementMap(eMap map[string]etfapi.Element) (snowf
 			return id, errors.Wrap(err, "UpsertGuildFromElementMap could not insert guild into the session")
 		}
 		s.guilds[id] = g
+		s.indexGuildMembers(&g)
 
 		return id, nil
 	}
@@ -145,198 +181,326 @@ func (s *state) UpsertGuildFromElementMap(eMap map[string]etfapi.Element) (snowf
 	}
 
 	s.guilds[id] = g
+	if _, ok := eMap["members"]; ok {
+		s.indexGuildMembers(&g)
+	}
+
 	return id, nil
 }
 
-// UpsertGuildMemberFromElementMap updates data in the session state for a guild member based on the given data
-func (s *state) UpsertGuildMemberFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, error) {
+// guildFromElementMap finds the guild referenced by the "guild_id" field of the given data
+func (s *state) guildFromElementMap(eMap map[string]etfapi.Element) (Guild, error) {
 	e, ok := eMap["guild_id"]
 	if !ok {
-		return 0, errors.Wrap(ErrMissingData, "UpsertGuildMemberFromElementMap could not find guild id map element")
+		return Guild{}, errors.Wrap(ErrMissingData, "could not find guild id map element")
 	}
 
-	id, err := etfapi.SnowflakeFromElement(e)
+	gid, err := etfapi.SnowflakeFromElement(e)
 	if err != nil {
-		return 0, errors.Wrap(err, "UpsertGuildMemberFromElementMap could not find guild id")
+		return Guild{id: gid}, errors.Wrap(err, "could not find guild id")
 	}
 
-	g, ok := s.guilds[id]
+	g, ok := s.guilds[gid]
 	if !ok {
-		return id, errors.Wrap(ErrNotFound, "UpsertGuildMemberFromElementMap could not find the guild to add a member to")
+		return Guild{id: gid}, errors.Wrap(ErrNotFound, "could not find the guild", "guild_id", gid.ToString())
 	}
 
-	e, ok = eMap["user"]
-	if !ok {
-		return id, errors.Wrap(ErrMissingData, "UpsertGuildMemberFromElementMap could not find user element")
-	}
+	return g, nil
+}
 
-	eMap, err = e.ToMap()
+// UpsertGuildMemberFromElementMap updates data in the session state for a guild member who joined based on the given data
+func (s *state) UpsertGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	return s.upsertGuildMember(eMap, true, "UpsertGuildMemberFromElementMap")
+}
+
+// UpdateGuildMemberFromElementMap updates data in the session state for an updated guild member based on the given data
+func (s *state) UpdateGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	return s.upsertGuildMember(eMap, false, "UpdateGuildMemberFromElementMap")
+}
+
+func (s *state) upsertGuildMember(eMap map[string]etfapi.Element, joined bool, caller string) (MemberDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
 	if err != nil {
-		return id, errors.Wrap(err, "Up[sertGuildMemberFromElementMap could not convert user element to a map")
+		return MemberDiff{Guild: g.id}, errors.Wrap(err, caller+" could not find the guild to add a member to")
 	}
 
-	if _, err = g.UpsertMemberFromElementMap(eMap); err != nil {
-		return id, errors.Wrap(err, "UpsertGuildMemberFromElementMap could not upsert guild member into the session")
+	if _, ok := eMap["user"]; !ok {
+		return MemberDiff{Guild: g.id}, errors.Wrap(ErrMissingData, caller+" could not find user element")
 	}
 
-	s.guilds[id] = g
-	return id, nil
+	diff, err := g.upsertMember(eMap, joined)
+	if err != nil {
+		return diff, errors.Wrap(err, caller+" could not upsert guild member into the session")
+	}
+
+	s.guilds[g.id] = g
+	s.indexUserGuild(diff.New.id, g.id)
+	return diff, nil
 }
 
-// UpsertGuildRoleFromElementMap updates data in the session state for a guild role based on the given data
-func (s *state) UpsertGuildRoleFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, error) {
-	e, ok := eMap["guild_id"]
+// RemoveGuildMemberFromElementMap removes a guild member from the session state based on the given data
+func (s *state) RemoveGuildMemberFromElementMap(eMap map[string]etfapi.Element) (MemberDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return MemberDiff{Guild: g.id}, errors.Wrap(err, "RemoveGuildMemberFromElementMap could not find the guild to remove a member from")
+	}
+
+	e, ok := eMap["user"]
 	if !ok {
-		return 0, errors.Wrap(ErrMissingData, "UpsertGuildRoleFromElementMap could not find guild id map element")
+		return MemberDiff{Guild: g.id}, errors.Wrap(ErrMissingData, "RemoveGuildMemberFromElementMap could not find user element")
 	}
 
-	id, err := etfapi.SnowflakeFromElement(e)
+	_, uid, err := etfapi.MapAndIDFromElement(e)
 	if err != nil {
-		return 0, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not find guild id")
+		return MemberDiff{Guild: g.id}, errors.Wrap(err, "RemoveGuildMemberFromElementMap could not find the user id")
 	}
 
-	g, ok := s.guilds[id]
-	if !ok {
-		return id, errors.Wrap(ErrNotFound, "UpsertGuildRoleFromElementMap could not find the guild to add a role to")
+	diff := g.RemoveMember(uid)
+	s.guilds[g.id] = g
+	s.unindexUserGuild(uid, g.id)
+	return diff, nil
+}
+
+// UpsertGuildRoleFromElementMap updates data in the session state for a guild role based on the given data
+func (s *state) UpsertGuildRoleFromElementMap(eMap map[string]etfapi.Element) (RoleDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return RoleDiff{Guild: g.id}, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not find the guild to add a role to")
 	}
 
-	e, ok = eMap["role"]
+	e, ok := eMap["role"]
 	if !ok {
-		return id, errors.Wrap(ErrMissingData, "UpsertGuildRoleFromElementMap could not find the role element")
+		return RoleDiff{Guild: g.id}, errors.Wrap(ErrMissingData, "UpsertGuildRoleFromElementMap could not find the role element")
 	}
 
 	eMap, err = e.ToMap()
 	if err != nil {
-		return id, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not convert role element into a map")
+		return RoleDiff{Guild: g.id}, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not convert role element into a map")
 	}
 
-	if _, err = g.UpsertRoleFromElementMap(eMap); err != nil {
-		return id, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not upsert guild role into the session")
+	diff, err := g.UpsertRoleFromElementMap(eMap)
+	if err != nil {
+		return diff, errors.Wrap(err, "UpsertGuildRoleFromElementMap could not upsert guild role into the session")
 	}
 
-	s.guilds[id] = g
-	return id, nil
+	s.guilds[g.id] = g
+	return diff, nil
+}
+
+// RemoveGuildRoleFromElementMap removes a guild role from the session state based on the given data
+func (s *state) RemoveGuildRoleFromElementMap(eMap map[string]etfapi.Element) (RoleDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return RoleDiff{Guild: g.id}, errors.Wrap(err, "RemoveGuildRoleFromElementMap could not find the guild to remove a role from")
+	}
+
+	e, ok := eMap["role_id"]
+	if !ok {
+		return RoleDiff{Guild: g.id}, errors.Wrap(ErrMissingData, "RemoveGuildRoleFromElementMap could not find the role_id element")
+	}
+
+	rid, err := etfapi.SnowflakeFromUnknownElement(e)
+	if err != nil {
+		return RoleDiff{Guild: g.id}, errors.Wrap(err, "RemoveGuildRoleFromElementMap could not find the role id")
+	}
+
+	diff := g.RemoveRole(rid)
+	s.guilds[g.id] = g
+	return diff, nil
 }
 
 // UpsertChannelFromElement updates data in the session state for a channel based on the given Element
-func (s *state) UpsertChannelFromElement(e etfapi.Element) (snowflake.Snowflake, error) {
-	eMap, id, err := etfapi.MapAndIDFromElement(e)
+func (s *state) UpsertChannelFromElement(e etfapi.Element) (ChannelDiff, error) {
+	eMap, err := e.ToMap()
+	if err != nil {
+		return ChannelDiff{}, errors.Wrap(err, "could not inflate element to find channel")
+	}
+
+	return s.UpsertChannelFromElementMap(eMap)
+}
+
+// UpsertChannelFromElementMap updates data in the session state for a channel based on the given data
+func (s *state) UpsertChannelFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	e, ok := eMap["id"]
+	if !ok {
+		return ChannelDiff{}, errors.Wrap(ErrMissingData, "UpsertChannelFromElementMap could not find channel id map element")
+	}
+
+	id, err := etfapi.SnowflakeFromElement(e)
 	if err != nil {
-		return 0, errors.Wrap(err, "could not inflate element to find channel")
+		return ChannelDiff{}, errors.Wrap(err, "UpsertChannelFromElementMap could not find channel id")
 	}
 
 	gidE, ok := eMap["guild_id"]
-	if !ok || e.IsNil() { // private channel
+	if !ok || gidE.IsNil() { // private channel
 		c, found := s.privateChannels[id]
 		if !found {
-			s.privateChannels[id], err = ChannelFromElement(e)
+			c, err = ChannelFromElementMap(eMap)
 			if err != nil {
-				return 0, errors.Wrap(err, "could not insert channel into the session")
+				return ChannelDiff{}, errors.Wrap(err, "could not insert channel into the session")
 			}
-			return 0, nil
+			s.privateChannels[id] = c
+
+			return ChannelDiff{New: c, Created: true}, nil
 		}
 
+		diff := ChannelDiff{Old: c}
 		err = c.UpdateFromElementMap(eMap)
 		if err != nil {
-			return 0, errors.Wrap(err, "could not update channel into the session")
+			return diff, errors.Wrap(err, "could not update channel into the session")
 		}
 		s.privateChannels[id] = c
+		diff.New = c
 
-		return 0, nil
+		return diff, nil
 	}
 
-	gid, err := etfapi.SnowflakeFromElement(gidE)
+	g, err := s.guildFromElementMap(eMap)
 	if err != nil {
-		return 0, errors.Wrap(err, "could not get guild_id from element")
-	}
-
-	g, ok := s.guilds[gid]
-	if !ok {
-		return gid, errors.Wrap(ErrNotFound, "could not find the guild_id")
-	}
-
-	c, ok := g.channels[id]
-	if !ok { // new channel
-		g.channels[id], err = ChannelFromElement(e)
-		if err != nil {
-			return gid, errors.Wrap(err, "could not insert channel into the session")
-		}
-
-		s.guilds[gid] = g
-		return gid, nil
+		return ChannelDiff{Guild: g.id, New: Channel{id: id}}, errors.Wrap(err, "UpsertChannelFromElementMap could not find the guild to add a channel to")
 	}
 
-	if err = c.UpdateFromElementMap(eMap); err != nil {
-		return gid, errors.Wrap(err, "could not update channel into the session")
+	diff, err := g.UpsertChannelFromElementMap(eMap)
+	if err != nil {
+		return diff, errors.Wrap(err, "could not upsert channel into the session")
 	}
-	g.channels[id] = c
-	s.guilds[gid] = g
+	s.guilds[g.id] = g
 
-	return gid, nil
+	return diff, nil
 }
 
-// UpsertChannelFromElementMap updates data in the session state for a channel based on the given data
-func (s *state) UpsertChannelFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, snowflake.Snowflake, error) {
-	var id snowflake.Snowflake
-
+// RemoveChannelFromElementMap removes a channel from the session state based on the given data
+func (s *state) RemoveChannelFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
 	e, ok := eMap["id"]
 	if !ok {
-		return 0, 0, errors.Wrap(ErrMissingData, "UpsertChannelFromElementMap could not find channel id map element")
+		return ChannelDiff{}, errors.Wrap(ErrMissingData, "RemoveChannelFromElementMap could not find channel id map element")
 	}
 
 	id, err := etfapi.SnowflakeFromElement(e)
 	if err != nil {
-		return 0, 0, errors.Wrap(err, "UpsertChannelFromElementMap could not find channel id")
+		return ChannelDiff{}, errors.Wrap(err, "RemoveChannelFromElementMap could not find channel id")
 	}
 
 	gidE, ok := eMap["guild_id"]
 	if !ok || gidE.IsNil() { // private channel
 		c, found := s.privateChannels[id]
 		if !found {
-			s.privateChannels[id], err = ChannelFromElementMap(eMap)
-			if err != nil {
-				return 0, 0, errors.Wrap(err, "could not insert channel into the session")
-			}
-			return 0, 0, nil
+			return ChannelDiff{Old: Channel{id: id}}, nil
 		}
 
-		err = c.UpdateFromElementMap(eMap)
-		if err != nil {
-			return 0, 0, errors.Wrap(err, "could not update channel into the session")
-		}
-		s.privateChannels[id] = c
+		delete(s.privateChannels, id)
+		return ChannelDiff{Old: c, Deleted: true}, nil
+	}
 
-		return 0, 0, nil
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return ChannelDiff{Guild: g.id, Old: Channel{id: id}}, errors.Wrap(err, "RemoveChannelFromElementMap could not find the guild to remove a channel from")
 	}
 
-	gid, err := etfapi.SnowflakeFromElement(gidE)
+	diff := g.RemoveChannel(id)
+	s.guilds[g.id] = g
+
+	return diff, nil
+}
+
+// SyncThreadsFromElementMap updates the active threads of a guild in the session state from THREAD_LIST_SYNC data
+func (s *state) SyncThreadsFromElementMap(eMap map[string]etfapi.Element) (snowflake.Snowflake, []ChannelDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
 	if err != nil {
-		return 0, 0, errors.Wrap(err, "could not get guild_id from element")
+		return g.id, nil, errors.Wrap(err, "SyncThreadsFromElementMap could not find the guild to sync threads of")
 	}
 
-	g, ok := s.guilds[gid]
-	if !ok {
-		return gid, id, errors.Wrap(ErrNotFound, "could not find the guild_id")
+	diffs, err := g.SyncThreadsFromElementMap(eMap)
+	s.guilds[g.id] = g
+	if err != nil {
+		return g.id, diffs, errors.Wrap(err, "SyncThreadsFromElementMap could not sync threads into the session")
 	}
 
-	c, ok := g.channels[id]
-	if !ok { // new channel
-		g.channels[id], err = ChannelFromElementMap(eMap)
-		if err != nil {
-			return gid, id, errors.Wrap(err, "could not insert channel into the session")
-		}
+	return g.id, diffs, nil
+}
 
-		s.guilds[gid] = g
-		return gid, id, nil
+// UpdateThreadMemberFromElementMap updates the bot's thread membership in the session state from THREAD_MEMBER_UPDATE data
+func (s *state) UpdateThreadMemberFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return ChannelDiff{Guild: g.id}, errors.Wrap(err, "UpdateThreadMemberFromElementMap could not find the guild of the thread")
 	}
 
-	if err = c.UpdateFromElementMap(eMap); err != nil {
-		return gid, id, errors.Wrap(err, "could not update channel into the session")
+	diff, err := g.UpdateThreadMemberFromElementMap(eMap)
+	if err != nil {
+		return diff, errors.Wrap(err, "UpdateThreadMemberFromElementMap could not update thread member in the session")
 	}
-	g.channels[id] = c
-	s.guilds[gid] = g
 
-	return gid, id, nil
+	s.guilds[g.id] = g
+	return diff, nil
+}
+
+// UpdateThreadMembersFromElementMap updates thread membership information in the session state from THREAD_MEMBERS_UPDATE data
+func (s *state) UpdateThreadMembersFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return ChannelDiff{Guild: g.id}, errors.Wrap(err, "UpdateThreadMembersFromElementMap could not find the guild of the thread")
+	}
+
+	diff, err := g.UpdateThreadMembersFromElementMap(eMap, s.user.id)
+	if err != nil {
+		return diff, errors.Wrap(err, "UpdateThreadMembersFromElementMap could not update thread members in the session")
+	}
+
+	s.guilds[g.id] = g
+	return diff, nil
+}
+
+// UpsertVoiceStateFromElementMap updates a member's voice state in the session state from VOICE_STATE_UPDATE data
+func (s *state) UpsertVoiceStateFromElementMap(eMap map[string]etfapi.Element) (VoiceDiff, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return VoiceDiff{Guild: g.id}, errors.Wrap(err, "UpsertVoiceStateFromElementMap could not find the guild of the voice state")
+	}
+
+	diff, uid, err := g.upsertVoiceState(eMap)
+	s.guilds[g.id] = g
+	if err != nil {
+		return diff, errors.Wrap(err, "UpsertVoiceStateFromElementMap could not upsert voice state into the session")
+	}
+
+	if uid != 0 {
+		s.indexUserGuild(uid, g.id)
+	}
+
+	return diff, nil
+}
+
+// UpdateGuildEmojisFromElementMap replaces the emojis of a guild in the session state from GUILD_EMOJIS_UPDATE data
+func (s *state) UpdateGuildEmojisFromElementMap(eMap map[string]etfapi.Element) (EmojisUpdated, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return EmojisUpdated{Guild: g.id}, errors.Wrap(err, "UpdateGuildEmojisFromElementMap could not find the guild to update emojis of")
+	}
+
+	evt, err := g.ReplaceEmojisFromElement(eMap["emojis"])
+	if err != nil {
+		return evt, errors.Wrap(err, "UpdateGuildEmojisFromElementMap could not update guild emojis in the session")
+	}
+
+	s.guilds[g.id] = g
+	return evt, nil
+}
+
+// UpdateGuildStickersFromElementMap replaces the stickers of a guild in the session state from GUILD_STICKERS_UPDATE data
+func (s *state) UpdateGuildStickersFromElementMap(eMap map[string]etfapi.Element) (StickersUpdated, error) {
+	g, err := s.guildFromElementMap(eMap)
+	if err != nil {
+		return StickersUpdated{Guild: g.id}, errors.Wrap(err, "UpdateGuildStickersFromElementMap could not find the guild to update stickers of")
+	}
+
+	evt, err := g.ReplaceStickersFromElement(eMap["stickers"])
+	if err != nil {
+		return evt, errors.Wrap(err, "UpdateGuildStickersFromElementMap could not update guild stickers in the session")
+	}
+
+	s.guilds[g.id] = g
+	return evt, nil
 }
 
 // GuildOfChannel returns the id of the guild that owns the channel with the provided id, if one is known
@@ -360,6 +524,21 @@ func (s *state) Guild(gid snowflake.Snowflake) (Guild, bool) {
 	return g, ok
 }
 
+// GuildCount returns the number of guilds in the current session state
+func (s *state) GuildCount() int {
+	return len(s.guilds)
+}
+
+// GuildsWithUser returns the ids of the guilds the user with the provided id is known to be a member of
+func (s *state) GuildsWithUser(uid snowflake.Snowflake) []snowflake.Snowflake {
+	gids := make([]snowflake.Snowflake, 0, len(s.userGuilds[uid]))
+	for gid := range s.userGuilds[uid] {
+		gids = append(gids, gid)
+	}
+
+	return gids
+}
+
 // GuildIDs returns the ids of all the guilds in the current session state
 func (s *state) GuildIDs() []snowflake.Snowflake {
 	gids := make([]snowflake.Snowflake, 0, len(s.guilds))
@@ -0,0 +1,373 @@
+package session
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ThreadMetadata represents the known thread-specific information about a channel
+type ThreadMetadata struct {
+	archived            bool
+	locked              bool
+	autoArchiveDuration int
+	archiveTimestamp    string
+	memberCount         int
+	messageCount        int
+	joined              bool
+	memberFlags         int
+}
+
+// Archived determines if the thread is archived
+func (t ThreadMetadata) Archived() bool {
+	return t.archived
+}
+
+// Locked determines if the thread is locked (only moderators may unarchive it)
+func (t ThreadMetadata) Locked() bool {
+	return t.locked
+}
+
+// AutoArchiveDuration returns the number of minutes of inactivity after which the thread is archived
+func (t ThreadMetadata) AutoArchiveDuration() int {
+	return t.autoArchiveDuration
+}
+
+// ArchiveTimestamp returns the timestamp at which the archive status of the thread last changed
+func (t ThreadMetadata) ArchiveTimestamp() string {
+	return t.archiveTimestamp
+}
+
+// MemberCount returns the approximate number of members in the thread (stops counting at 50)
+func (t ThreadMetadata) MemberCount() int {
+	return t.memberCount
+}
+
+// MessageCount returns the approximate number of messages in the thread (stops counting at 50)
+func (t ThreadMetadata) MessageCount() int {
+	return t.messageCount
+}
+
+// Joined determines if the bot user is a member of the thread
+func (t ThreadMetadata) Joined() bool {
+	return t.joined
+}
+
+// MemberFlags returns the notification flags of the bot's thread membership
+func (t ThreadMetadata) MemberFlags() int {
+	return t.memberFlags
+}
+
+// UpdateFromElementMap updates the thread information from the given channel data
+//
+// This will not remove known data, only replace it
+func (t *ThreadMetadata) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
+	var err error
+
+	if e, ok := eMap["thread_metadata"]; ok && !e.IsNil() {
+		var mMap map[string]etfapi.Element
+		mMap, err = e.ToMap()
+		if err != nil {
+			return errors.Wrap(err, "could not inflate thread_metadata to map")
+		}
+
+		if e2, ok := mMap["archived"]; ok {
+			t.archived, err = e2.ToBool()
+			if err != nil {
+				return errors.Wrap(err, "could not get archived")
+			}
+		}
+
+		if e2, ok := mMap["locked"]; ok && !e2.IsNil() {
+			t.locked, err = e2.ToBool()
+			if err != nil {
+				return errors.Wrap(err, "could not get locked")
+			}
+		}
+
+		if e2, ok := mMap["auto_archive_duration"]; ok {
+			t.autoArchiveDuration, err = e2.ToInt()
+			if err != nil {
+				return errors.Wrap(err, "could not get auto_archive_duration")
+			}
+		}
+
+		if e2, ok := mMap["archive_timestamp"]; ok && !e2.IsNil() {
+			t.archiveTimestamp, err = e2.ToString()
+			if err != nil {
+				return errors.Wrap(err, "could not get archive_timestamp")
+			}
+		}
+	}
+
+	if e, ok := eMap["member_count"]; ok {
+		t.memberCount, err = e.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get member_count")
+		}
+	}
+
+	if e, ok := eMap["message_count"]; ok {
+		t.messageCount, err = e.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get message_count")
+		}
+	}
+
+	if e, ok := eMap["member"]; ok && !e.IsNil() {
+		var mMap map[string]etfapi.Element
+		mMap, err = e.ToMap()
+		if err != nil {
+			return errors.Wrap(err, "could not inflate thread member to map")
+		}
+
+		if err = t.updateMembershipFromElementMap(mMap); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateMembershipFromElementMap marks the bot as a thread member from the given thread member data
+func (t *ThreadMetadata) updateMembershipFromElementMap(eMap map[string]etfapi.Element) error {
+	t.joined = true
+
+	if e, ok := eMap["flags"]; ok {
+		var err error
+		t.memberFlags, err = e.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get thread member flags")
+		}
+	}
+
+	return nil
+}
+
+// ActiveThreads returns the ids of the known unarchived threads in the guild
+func (g *Guild) ActiveThreads() []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	tids := []snowflake.Snowflake{}
+	for _, t := range []ChannelType{GuildNewsThread, GuildPublicThread, GuildPrivateThread} {
+		for _, tid := range g.index.channelsByType[t] {
+			if !g.channels[tid].thread.archived {
+				tids = append(tids, tid)
+			}
+		}
+	}
+
+	return tids
+}
+
+// ThreadsOfChannel returns the ids of the known threads whose parent is the channel with the provided id
+func (g *Guild) ThreadsOfChannel(cid snowflake.Snowflake) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	tids := []snowflake.Snowflake{}
+	for _, tid := range g.index.channelsByParent[cid] {
+		if g.channels[tid].channelType.IsThread() {
+			tids = append(tids, tid)
+		}
+	}
+
+	return tids
+}
+
+// SyncThreadsFromElementMap replaces the known active threads of the guild with the given data
+//
+// The data should be a THREAD_LIST_SYNC payload. If it contains "channel_ids", only threads
+// of those parent channels are synced; otherwise every thread in the guild is. Threads in
+// the synced scope which are missing from the data are removed.
+func (g *Guild) SyncThreadsFromElementMap(eMap map[string]etfapi.Element) ([]ChannelDiff, error) {
+	threads, ok := eMap["threads"]
+	if !ok || threads.IsNil() {
+		return nil, errors.Wrap(ErrMissingData, "could not find threads element")
+	}
+
+	var parents map[snowflake.Snowflake]bool
+
+	if e, ok := eMap["channel_ids"]; ok && !e.IsNil() {
+		parents = map[snowflake.Snowflake]bool{}
+		for _, e2 := range e.Vals {
+			pid, err := etfapi.SnowflakeFromUnknownElement(e2)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not get synced channel id")
+			}
+			parents[pid] = true
+		}
+	}
+
+	var joined map[snowflake.Snowflake]map[string]etfapi.Element
+	if e, ok := eMap["members"]; ok && !e.IsNil() {
+		joined = map[snowflake.Snowflake]map[string]etfapi.Element{}
+		for _, e2 := range e.Vals {
+			mMap, err := e2.ToMap()
+			if err != nil {
+				return nil, errors.Wrap(err, "could not inflate thread member to map")
+			}
+
+			tid, err := etfapi.SnowflakeFromUnknownElement(mMap["id"])
+			if err != nil {
+				return nil, errors.Wrap(err, "could not get thread member thread id")
+			}
+			joined[tid] = mMap
+		}
+	}
+
+	return g.syncThreads(threads.Vals, parents, joined)
+}
+
+// syncThreads upserts the given thread elements and removes threads in scope that were not present
+//
+// If parents is nil, every thread is in scope. If joined is nil, thread memberships are left as-is
+func (g *Guild) syncThreads(threads []etfapi.Element, parents map[snowflake.Snowflake]bool, joined map[snowflake.Snowflake]map[string]etfapi.Element) ([]ChannelDiff, error) {
+	diffs := make([]ChannelDiff, 0, len(threads))
+	seen := make(map[snowflake.Snowflake]bool, len(threads))
+
+	for _, e := range threads {
+		tMap, err := e.ToMap()
+		if err != nil {
+			return diffs, errors.Wrap(err, "could not inflate thread to map")
+		}
+
+		diff, err := g.UpsertChannelFromElementMap(tMap)
+		if err != nil {
+			return diffs, errors.Wrap(err, "could not upsert thread")
+		}
+
+		if joined != nil {
+			c := g.channels[diff.New.id]
+			c.thread.joined = false
+			c.thread.memberFlags = 0
+			if mMap, ok := joined[c.id]; ok {
+				if err = c.thread.updateMembershipFromElementMap(mMap); err != nil {
+					return diffs, err
+				}
+			}
+			g.putChannel(c)
+			diff.New = c
+		}
+
+		seen[diff.New.id] = true
+		if diff.Changed() {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	if g.index == nil {
+		return diffs, nil
+	}
+
+	stale := []snowflake.Snowflake{}
+	for _, t := range []ChannelType{GuildNewsThread, GuildPublicThread, GuildPrivateThread} {
+		for _, tid := range g.index.channelsByType[t] {
+			if seen[tid] {
+				continue
+			}
+
+			if parents != nil && !parents[g.channels[tid].parentID] {
+				continue
+			}
+
+			stale = append(stale, tid)
+		}
+	}
+
+	for _, tid := range stale {
+		diffs = append(diffs, g.RemoveChannel(tid))
+	}
+
+	return diffs, nil
+}
+
+// UpdateThreadMemberFromElementMap updates the bot's membership of a thread from a THREAD_MEMBER_UPDATE payload
+func (g *Guild) UpdateThreadMemberFromElementMap(eMap map[string]etfapi.Element) (ChannelDiff, error) {
+	diff := ChannelDiff{Guild: g.id}
+
+	tid, err := etfapi.SnowflakeFromUnknownElement(eMap["id"])
+	if err != nil {
+		return diff, errors.Wrap(err, "could not get thread id")
+	}
+
+	c, ok := g.channels[tid]
+	if !ok {
+		return diff, errors.Wrap(ErrNotFound, "could not find thread", "thread_id", tid.ToString())
+	}
+
+	diff.Old = c
+	if err = c.thread.updateMembershipFromElementMap(eMap); err != nil {
+		return diff, err
+	}
+
+	g.putChannel(c)
+	diff.New = c
+	return diff, nil
+}
+
+// UpdateThreadMembersFromElementMap updates the membership information of a thread from a
+// THREAD_MEMBERS_UPDATE payload; botID is used to detect changes to the bot's own membership
+func (g *Guild) UpdateThreadMembersFromElementMap(eMap map[string]etfapi.Element, botID snowflake.Snowflake) (ChannelDiff, error) {
+	diff := ChannelDiff{Guild: g.id}
+
+	tid, err := etfapi.SnowflakeFromUnknownElement(eMap["id"])
+	if err != nil {
+		return diff, errors.Wrap(err, "could not get thread id")
+	}
+
+	c, ok := g.channels[tid]
+	if !ok {
+		return diff, errors.Wrap(ErrNotFound, "could not find thread", "thread_id", tid.ToString())
+	}
+	diff.Old = c
+
+	if e, ok := eMap["member_count"]; ok {
+		c.thread.memberCount, err = e.ToInt()
+		if err != nil {
+			return diff, errors.Wrap(err, "could not get member_count")
+		}
+	}
+
+	if e, ok := eMap["added_members"]; ok && !e.IsNil() {
+		for _, e2 := range e.Vals {
+			mMap, err := e2.ToMap()
+			if err != nil {
+				return diff, errors.Wrap(err, "could not inflate added thread member to map")
+			}
+
+			uid, err := etfapi.SnowflakeFromUnknownElement(mMap["user_id"])
+			if err != nil {
+				return diff, errors.Wrap(err, "could not get added thread member user id")
+			}
+
+			if uid == botID {
+				if err = c.thread.updateMembershipFromElementMap(mMap); err != nil {
+					return diff, err
+				}
+			}
+		}
+	}
+
+	if e, ok := eMap["removed_member_ids"]; ok && !e.IsNil() {
+		for _, e2 := range e.Vals {
+			uid, err := etfapi.SnowflakeFromUnknownElement(e2)
+			if err != nil {
+				return diff, errors.Wrap(err, "could not get removed thread member user id")
+			}
+
+			if uid == botID {
+				c.thread.joined = false
+				c.thread.memberFlags = 0
+			}
+		}
+	}
+
+	g.putChannel(c)
+	diff.New = c
+	return diff, nil
+}
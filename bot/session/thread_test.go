@@ -0,0 +1,56 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// mustElement unwraps the result of an etfapi element constructor, for building test payloads
+func mustElement(e etfapi.Element, err error) etfapi.Element {
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func threadElement(id, parentID int64) etfapi.Element {
+	return mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+		"id":        mustElement(etfapi.NewSmallBigElement(id)),
+		"type":      mustElement(etfapi.NewInt8Element(int(GuildPublicThread))),
+		"parent_id": mustElement(etfapi.NewSmallBigElement(parentID)),
+	}))
+}
+
+func TestGuild_SyncThreadsFromElementMap(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuild()
+	g.putChannel(Channel{id: 11, channelType: GuildPublicThread, parentID: 1})
+	g.putChannel(Channel{id: 12, channelType: GuildPublicThread, parentID: 1})
+	g.putChannel(Channel{id: 21, channelType: GuildPublicThread, parentID: 2})
+
+	// a payload without threads is rejected rather than treated as "no threads"
+	_, err := g.SyncThreadsFromElementMap(map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(1)),
+	})
+	assert.ErrorIs(t, err, ErrMissingData)
+	assert.Equal(t, []snowflake.Snowflake{11, 12, 21}, g.ActiveThreads())
+
+	diffs, err := g.SyncThreadsFromElementMap(map[string]etfapi.Element{
+		"guild_id":    mustElement(etfapi.NewSmallBigElement(1)),
+		"channel_ids": mustElement(etfapi.NewListElement([]etfapi.Element{mustElement(etfapi.NewSmallBigElement(1))})),
+		"threads":     mustElement(etfapi.NewListElement([]etfapi.Element{threadElement(12, 1)})),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []snowflake.Snowflake{12, 21}, g.ActiveThreads())
+
+	if assert.Len(t, diffs, 1) {
+		assert.True(t, diffs[0].Deleted)
+		assert.Equal(t, snowflake.Snowflake(11), diffs[0].Old.id)
+	}
+}
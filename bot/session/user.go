@@ -15,6 +15,26 @@ type User struct {
 	avatar        string
 }
 
+// ID returns the user's ID
+func (u *User) ID() snowflake.Snowflake {
+	return u.id
+}
+
+// Username returns the user's username
+func (u *User) Username() string {
+	return u.username
+}
+
+// Discriminator returns the user's discriminator
+func (u *User) Discriminator() string {
+	return u.discriminator
+}
+
+// Avatar returns the user's avatar hash
+func (u *User) Avatar() string {
+	return u.avatar
+}
+
 // UpdateFromElementMap updates the information about a user from the given data
 //
 // This will not remove information, only change and add information
@@ -0,0 +1,366 @@
+package session
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// These are the names of the change events generated from voice state updates
+const (
+	EventVoiceJoined       = "VOICE_JOINED"
+	EventVoiceLeft         = "VOICE_LEFT"
+	EventVoiceMoved        = "VOICE_MOVED"
+	EventVoiceStateUpdated = "VOICE_STATE_UPDATED"
+)
+
+// VoiceState represents the known voice connection status of a guild member
+type VoiceState struct {
+	userID         snowflake.Snowflake
+	channelID      snowflake.Snowflake
+	sessionID      string
+	deaf           bool
+	mute           bool
+	selfDeaf       bool
+	selfMute       bool
+	selfStream     bool
+	selfVideo      bool
+	suppress       bool
+	requestToSpeak string
+}
+
+// UserID returns the id of the user the voice state belongs to
+func (v VoiceState) UserID() snowflake.Snowflake {
+	return v.userID
+}
+
+// ChannelID returns the id of the voice channel the user is connected to
+func (v VoiceState) ChannelID() snowflake.Snowflake {
+	return v.channelID
+}
+
+// SessionID returns the voice session id
+func (v VoiceState) SessionID() string {
+	return v.sessionID
+}
+
+// Deaf determines if the user is deafened by the server
+func (v VoiceState) Deaf() bool {
+	return v.deaf
+}
+
+// Mute determines if the user is muted by the server
+func (v VoiceState) Mute() bool {
+	return v.mute
+}
+
+// SelfDeaf determines if the user has deafened themselves
+func (v VoiceState) SelfDeaf() bool {
+	return v.selfDeaf
+}
+
+// SelfMute determines if the user has muted themselves
+func (v VoiceState) SelfMute() bool {
+	return v.selfMute
+}
+
+// SelfStream determines if the user is streaming (using "Go Live")
+func (v VoiceState) SelfStream() bool {
+	return v.selfStream
+}
+
+// SelfVideo determines if the user's camera is enabled
+func (v VoiceState) SelfVideo() bool {
+	return v.selfVideo
+}
+
+// Suppress determines if the user's permission to speak is denied (stage channels)
+func (v VoiceState) Suppress() bool {
+	return v.suppress
+}
+
+// RequestToSpeakTimestamp returns the time at which the user requested to speak (stage channels),
+// or an empty string if there is no pending request
+func (v VoiceState) RequestToSpeakTimestamp() string {
+	return v.requestToSpeak
+}
+
+// UpdateFromElementMap updates the voice state from the given data
+func (v *VoiceState) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
+	var err error
+
+	v.userID, err = etfapi.SnowflakeFromUnknownElement(eMap["user_id"])
+	if err != nil {
+		return errors.Wrap(err, "could not get user_id")
+	}
+
+	v.channelID = 0
+	if e, ok := eMap["channel_id"]; ok && !e.IsNil() {
+		v.channelID, err = etfapi.SnowflakeFromUnknownElement(e)
+		if err != nil {
+			return errors.Wrap(err, "could not get channel_id")
+		}
+	}
+
+	if e, ok := eMap["session_id"]; ok {
+		v.sessionID, err = e.ToString()
+		if err != nil {
+			return errors.Wrap(err, "could not get session_id")
+		}
+	}
+
+	flags := []struct {
+		key string
+		val *bool
+	}{
+		{"deaf", &v.deaf},
+		{"mute", &v.mute},
+		{"self_deaf", &v.selfDeaf},
+		{"self_mute", &v.selfMute},
+		{"self_stream", &v.selfStream},
+		{"self_video", &v.selfVideo},
+		{"suppress", &v.suppress},
+	}
+
+	for _, f := range flags {
+		e, ok := eMap[f.key]
+		if !ok || e.IsNil() {
+			continue
+		}
+
+		*f.val, err = e.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get "+f.key)
+		}
+	}
+
+	v.requestToSpeak = ""
+	if e, ok := eMap["request_to_speak_timestamp"]; ok && !e.IsNil() {
+		v.requestToSpeak, err = e.ToString()
+		if err != nil {
+			return errors.Wrap(err, "could not get request_to_speak_timestamp")
+		}
+	}
+
+	return nil
+}
+
+// VoiceStateFromElement creates a new VoiceState object from the given etf Element
+func VoiceStateFromElement(e etfapi.Element) (VoiceState, error) {
+	var v VoiceState
+
+	eMap, err := e.ToMap()
+	if err != nil {
+		return v, errors.Wrap(err, "could not inflate voice state to map")
+	}
+
+	err = v.UpdateFromElementMap(eMap)
+	return v, err
+}
+
+// VoiceDiff describes the change to a member's voice state caused by a session update
+//
+// Old is the zero value when the member was not connected, and New is the zero value
+// when the member disconnected
+type VoiceDiff struct {
+	Guild snowflake.Snowflake
+	Old   VoiceState
+	New   VoiceState
+}
+
+// UserID returns the id of the user the diff is about
+func (d VoiceDiff) UserID() snowflake.Snowflake {
+	if d.New.userID != 0 {
+		return d.New.userID
+	}
+
+	return d.Old.userID
+}
+
+// Events generates the change events described by the diff
+func (d VoiceDiff) Events() []ChangeEvent {
+	switch {
+	case d.Old.channelID == 0 && d.New.channelID != 0:
+		return []ChangeEvent{VoiceJoined{Guild: d.Guild, State: d.New}}
+	case d.Old.channelID != 0 && d.New.channelID == 0:
+		return []ChangeEvent{VoiceLeft{Guild: d.Guild, State: d.Old}}
+	case d.Old.channelID != d.New.channelID:
+		return []ChangeEvent{VoiceMoved{Guild: d.Guild, Old: d.Old, New: d.New}}
+	case d.Old != d.New:
+		return []ChangeEvent{VoiceStateUpdated{Guild: d.Guild, Old: d.Old, New: d.New}}
+	default:
+		return nil
+	}
+}
+
+// VoiceJoined is the event generated when a member connects to a voice channel
+type VoiceJoined struct {
+	Guild snowflake.Snowflake
+	State VoiceState
+}
+
+// EventName returns the name of the event
+func (e VoiceJoined) EventName() string { return EventVoiceJoined }
+
+// GuildID returns the id of the guild the event occurred in
+func (e VoiceJoined) GuildID() snowflake.Snowflake { return e.Guild }
+
+// VoiceLeft is the event generated when a member disconnects from voice
+type VoiceLeft struct {
+	Guild snowflake.Snowflake
+	State VoiceState
+}
+
+// EventName returns the name of the event
+func (e VoiceLeft) EventName() string { return EventVoiceLeft }
+
+// GuildID returns the id of the guild the event occurred in
+func (e VoiceLeft) GuildID() snowflake.Snowflake { return e.Guild }
+
+// VoiceMoved is the event generated when a member moves from one voice channel to another
+type VoiceMoved struct {
+	Guild snowflake.Snowflake
+	Old   VoiceState
+	New   VoiceState
+}
+
+// EventName returns the name of the event
+func (e VoiceMoved) EventName() string { return EventVoiceMoved }
+
+// GuildID returns the id of the guild the event occurred in
+func (e VoiceMoved) GuildID() snowflake.Snowflake { return e.Guild }
+
+// VoiceStateUpdated is the event generated when a member's voice flags change without moving channels
+type VoiceStateUpdated struct {
+	Guild snowflake.Snowflake
+	Old   VoiceState
+	New   VoiceState
+}
+
+// EventName returns the name of the event
+func (e VoiceStateUpdated) EventName() string { return EventVoiceStateUpdated }
+
+// GuildID returns the id of the guild the event occurred in
+func (e VoiceStateUpdated) GuildID() snowflake.Snowflake { return e.Guild }
+
+// VoiceState finds the voice state of the member with the provided id, if they are connected
+//
+// The second return value will be false if the member is not known to be in a voice channel
+func (g *Guild) VoiceState(uid snowflake.Snowflake) (VoiceState, bool) {
+	v, ok := g.voiceStates[uid]
+	return v, ok
+}
+
+// VoiceChannelOf finds the id of the voice channel the member with the provided id is connected to
+//
+// The second return value will be false if the member is not known to be in a voice channel
+func (g *Guild) VoiceChannelOf(uid snowflake.Snowflake) (snowflake.Snowflake, bool) {
+	v, ok := g.voiceStates[uid]
+	return v.channelID, ok
+}
+
+// MembersInVoiceChannel returns the ids of the members connected to the voice channel with the provided id
+func (g *Guild) MembersInVoiceChannel(cid snowflake.Snowflake) []snowflake.Snowflake {
+	if g.index == nil {
+		return nil
+	}
+
+	set := g.index.voiceByChannel[cid]
+	uids := make([]snowflake.Snowflake, 0, len(set))
+	for uid := range set {
+		uids = append(uids, uid)
+	}
+
+	return uids
+}
+
+// UpsertVoiceStateFromElementMap updates the voice state of a member from VOICE_STATE_UPDATE data
+//
+// A voice state without a channel removes the member's voice state
+func (g *Guild) UpsertVoiceStateFromElementMap(eMap map[string]etfapi.Element) (VoiceDiff, error) {
+	diff, _, err := g.upsertVoiceState(eMap)
+	return diff, err
+}
+
+// upsertVoiceState is UpsertVoiceStateFromElementMap, also returning the id of the member upserted from
+// the embedded member data (0 if there was none)
+func (g *Guild) upsertVoiceState(eMap map[string]etfapi.Element) (VoiceDiff, snowflake.Snowflake, error) {
+	diff := VoiceDiff{Guild: g.id}
+
+	var v VoiceState
+	if err := v.UpdateFromElementMap(eMap); err != nil {
+		return diff, 0, err
+	}
+
+	diff.Old = g.voiceStates[v.userID]
+	g.putVoiceState(v)
+	if v.channelID != 0 {
+		diff.New = v
+	}
+
+	if e, ok := eMap["member"]; ok && !e.IsNil() {
+		mMap, err := e.ToMap()
+		if err != nil {
+			return diff, 0, errors.Wrap(err, "could not inflate voice state member to map")
+		}
+
+		mDiff, err := g.UpdateMemberFromElementMap(mMap)
+		if err != nil {
+			return diff, 0, errors.Wrap(err, "could not upsert voice state member")
+		}
+
+		return diff, mDiff.New.id, nil
+	}
+
+	return diff, 0, nil
+}
+
+// replaceVoiceStates replaces all of the known voice states of the guild
+func (g *Guild) replaceVoiceStates(states []VoiceState) {
+	g.ensureIndex()
+
+	g.voiceStates = make(map[snowflake.Snowflake]VoiceState, len(states))
+	g.index.voiceByChannel = map[snowflake.Snowflake]map[snowflake.Snowflake]struct{}{}
+
+	for _, v := range states {
+		g.putVoiceState(v)
+	}
+}
+
+func (g *Guild) putVoiceState(v VoiceState) {
+	g.ensureIndex()
+
+	if g.voiceStates == nil {
+		g.voiceStates = map[snowflake.Snowflake]VoiceState{}
+	}
+
+	if old, ok := g.voiceStates[v.userID]; ok {
+		g.index.removeVoiceState(old)
+		delete(g.voiceStates, v.userID)
+	}
+
+	if v.channelID == 0 {
+		return
+	}
+
+	g.voiceStates[v.userID] = v
+	g.index.addVoiceState(v)
+}
+
+func (ix *guildIndex) addVoiceState(v VoiceState) {
+	set, ok := ix.voiceByChannel[v.channelID]
+	if !ok {
+		set = map[snowflake.Snowflake]struct{}{}
+		ix.voiceByChannel[v.channelID] = set
+	}
+	set[v.userID] = struct{}{}
+}
+
+func (ix *guildIndex) removeVoiceState(v VoiceState) {
+	set := ix.voiceByChannel[v.channelID]
+	delete(set, v.userID)
+	if len(set) == 0 {
+		delete(ix.voiceByChannel, v.channelID)
+	}
+}
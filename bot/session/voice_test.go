@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func TestState_UpsertVoiceStateFromElementMap_indexesMember(t *testing.T) {
+	t.Parallel()
+
+	s := newState()
+	g := newTestGuild()
+	g.voiceStates = map[snowflake.Snowflake]VoiceState{}
+	s.guilds[g.id] = *g
+
+	diff, err := s.UpsertVoiceStateFromElementMap(map[string]etfapi.Element{
+		"guild_id":   mustElement(etfapi.NewSmallBigElement(1)),
+		"user_id":    mustElement(etfapi.NewSmallBigElement(7)),
+		"channel_id": mustElement(etfapi.NewSmallBigElement(3)),
+		"member": mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+			"user": mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+				"id":       mustElement(etfapi.NewSmallBigElement(7)),
+				"username": mustElement(etfapi.NewStringElement("someone")),
+			})),
+		})),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, snowflake.Snowflake(3), diff.New.ChannelID())
+
+	// the member was first seen through the voice state, and is still indexed
+	assert.Equal(t, []snowflake.Snowflake{1}, s.GuildsWithUser(7))
+
+	g2 := s.guilds[1]
+	_, ok := g2.Member(7)
+	assert.True(t, ok)
+}
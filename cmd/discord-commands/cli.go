@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/spf13/pflag"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/commandsync"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// errNoSuchCommand is returned by delete when no registered command matches
+var errNoSuchCommand = errors.New("no such command")
+
+// errAmbiguousName is returned by delete when more than one registered command has the name
+var errAmbiguousName = errors.New("more than one command has that name, delete it by --id")
+
+type cli struct {
+	conf   config
+	stdout io.Writer
+	stderr io.Writer
+	deps   *dependencies
+	client *jsonapi.DiscordJSONClient
+	syncer *commandsync.Syncer
+}
+
+func newCLI(c config, stdout, stderr io.Writer) *cli {
+	deps := newDependencies(c, stderr)
+
+	return &cli{
+		conf:   c,
+		stdout: stdout,
+		stderr: stderr,
+		deps:   deps,
+		client: deps.jsc,
+		syncer: commandsync.NewSyncer(deps, deps.jsc, c.appID),
+	}
+}
+
+// flags creates the flag set of a subcommand
+func (c *cli) flags(name string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(fmt.Sprintf("%s %s", AppName, name), pflag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parse parses the arguments of a subcommand, which takes no positional arguments
+func (c *cli) parse(fs *pflag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return err
+		}
+		return errors.Wrap(errUsage, err.Error())
+	}
+
+	if fs.NArg() > 0 {
+		return errors.Wrap(errUsage, "unexpected arguments", "args", strings.Join(fs.Args(), " "))
+	}
+
+	return nil
+}
+
+func (c *cli) scope() string {
+	if c.conf.guild == 0 {
+		return "global"
+	}
+
+	return "guild " + c.conf.guild.ToString()
+}
+
+func (c *cli) registered(ctx context.Context) ([]entity.ApplicationCommand, error) {
+	if c.conf.guild == 0 {
+		cmds, err := c.client.GetGlobalCommands(ctx, c.conf.appID)
+		return cmds, errors.Wrap(err, "could not get global commands")
+	}
+
+	cmds, err := c.client.GetGuildCommands(ctx, c.conf.appID, c.conf.guild)
+	return cmds, errors.Wrap(err, "could not get guild commands", "gid", c.conf.guild.ToString())
+}
+
+func (c *cli) plan(ctx context.Context, manifest []entity.ApplicationCommand) (commandsync.Plan, error) {
+	if c.conf.guild == 0 {
+		return c.syncer.PlanGlobal(ctx, manifest)
+	}
+
+	return c.syncer.PlanGuild(ctx, c.conf.guild, manifest)
+}
+
+func (c *cli) writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal output")
+	}
+
+	_, err = fmt.Fprintln(w, string(indentJSON(b, "  ")))
+	return err
+}
+
+// indentJSON lays out compact json (as produced by json.Marshal) with one value per line
+func indentJSON(b []byte, indent string) []byte {
+	buf := bytes.Buffer{}
+	buf.Grow(len(b) * 2)
+
+	depth := 0
+	inString, escaped := false, false
+	newline := func() {
+		buf.WriteByte('\n')
+		for i := 0; i < depth; i++ {
+			buf.WriteString(indent)
+		}
+	}
+
+	for i, c := range b {
+		if inString {
+			buf.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			buf.WriteByte(c)
+		case '{', '[':
+			buf.WriteByte(c)
+			// keep empty objects and arrays on one line
+			if i+1 < len(b) && (b[i+1] == '}' || b[i+1] == ']') {
+				continue
+			}
+			depth++
+			newline()
+		case '}', ']':
+			if i > 0 && b[i-1] != '{' && b[i-1] != '[' {
+				depth--
+				newline()
+			}
+			buf.WriteByte(c)
+		case ',':
+			buf.WriteByte(c)
+			newline()
+		case ':':
+			buf.WriteString(": ")
+		case ' ', '\t', '\n', '\r':
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// readManifest reads a json array of command definitions (as written by export)
+func readManifest(path string) ([]entity.ApplicationCommand, error) {
+	if path == "" {
+		return nil, errors.Wrap(errUsage, "a manifest is required (--manifest)")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read manifest", "path", path)
+	}
+
+	var cmds []entity.ApplicationCommand
+	if err := json.Unmarshal(b, &cmds); err != nil {
+		return nil, errors.Wrap(err, "could not parse manifest", "path", path)
+	}
+
+	return cmds, nil
+}
+
+// manifestCommand strips the fields that discord assigns from a registered command
+func manifestCommand(cmd entity.ApplicationCommand) entity.ApplicationCommand {
+	cmd.ID, cmd.IDSnowflake = "", 0
+	cmd.ApplicationID, cmd.ApplicationIDSnowflake = "", 0
+	cmd.GuildID, cmd.GuildIDSnowflake = "", 0
+	cmd.Version, cmd.VersionSnowflake = "", 0
+	return cmd
+}
+
+func commandType(t entity.ApplicationCommandType) string {
+	switch t {
+	case entity.CmdTypeChatInput:
+		return "slash"
+	case entity.CmdTypeUser:
+		return "user"
+	case entity.CmdTypeMessage:
+		return "message"
+	default:
+		return fmt.Sprintf("type %d", int(t))
+	}
+}
+
+func (c *cli) list(ctx context.Context, args []string) error {
+	fs := c.flags("list")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	cmds, err := c.registered(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+
+	if c.conf.format == "json" {
+		return c.writeJSON(c.stdout, cmds)
+	}
+
+	fmt.Fprintf(c.stdout, "%s commands: %d\n", c.scope(), len(cmds))
+	for _, cmd := range cmds {
+		fmt.Fprintf(c.stdout, "  %s\t%s\t%s\t%s\n", cmd.IDSnowflake.ToString(), commandType(cmd.Type), cmd.Name, cmd.Description)
+	}
+
+	return nil
+}
+
+func (c *cli) export(ctx context.Context, args []string) error {
+	fs := c.flags("export")
+	out := fs.StringP("output", "o", "", "the file to write the manifest to (stdout if empty)")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	cmds, err := c.registered(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+
+	manifest := make([]entity.ApplicationCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		manifest = append(manifest, manifestCommand(cmd))
+	}
+
+	if *out == "" {
+		return c.writeJSON(c.stdout, manifest)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return errors.Wrap(err, "could not create manifest", "path", *out)
+	}
+
+	if err := c.writeJSON(f, manifest); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "could not write manifest", "path", *out)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "could not write manifest", "path", *out)
+	}
+
+	if c.conf.format == "text" {
+		fmt.Fprintf(c.stdout, "exported %d %s commands to %s\n", len(manifest), c.scope(), *out)
+	}
+
+	return nil
+}
+
+type planAction struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	ID      string   `json:"id,omitempty"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type planOutput struct {
+	Scope     string       `json:"scope"`
+	GuildID   string       `json:"guild_id,omitempty"`
+	Applied   bool         `json:"applied"`
+	Actions   []planAction `json:"actions"`
+	Unchanged []string     `json:"unchanged"`
+}
+
+func (c *cli) writePlan(p commandsync.Plan, applied bool) error {
+	if c.conf.format == "text" {
+		_, err := io.WriteString(c.stdout, p.String())
+		return err
+	}
+
+	out := planOutput{
+		Scope:     "global",
+		Applied:   applied,
+		Actions:   make([]planAction, 0, len(p.Actions)),
+		Unchanged: p.Unchanged,
+	}
+
+	if p.GuildID != 0 {
+		out.Scope = "guild"
+		out.GuildID = p.GuildID.ToString()
+	}
+
+	if out.Unchanged == nil {
+		out.Unchanged = []string{}
+	}
+
+	for _, a := range p.Actions {
+		pa := planAction{Kind: a.Kind.String(), Name: a.Name, Changes: a.Changes}
+		if a.ID != 0 {
+			pa.ID = a.ID.ToString()
+		}
+		out.Actions = append(out.Actions, pa)
+	}
+
+	return c.writeJSON(c.stdout, out)
+}
+
+// filterActions keeps only the actions whose kind is wanted
+func filterActions(p commandsync.Plan, keep func(commandsync.ActionKind) bool) commandsync.Plan {
+	actions := make([]commandsync.Action, 0, len(p.Actions))
+	for _, a := range p.Actions {
+		if keep(a.Kind) {
+			actions = append(actions, a)
+		}
+	}
+
+	p.Actions = actions
+	return p
+}
+
+func (c *cli) diff(ctx context.Context, args []string) error {
+	fs := c.flags("diff")
+	manifestPath := fs.String("manifest", "", "the manifest to compare against the registered commands")
+	exitCode := fs.Bool("exit-code", false, "exit with status 3 if there are changes")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.plan(ctx, manifest)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writePlan(p, false); err != nil {
+		return err
+	}
+
+	if *exitCode && !p.Empty() {
+		return errChanges
+	}
+
+	return nil
+}
+
+func (c *cli) apply(ctx context.Context, args []string) error {
+	fs := c.flags("apply")
+	manifestPath := fs.String("manifest", "", "the manifest to apply")
+	dryRun := fs.Bool("dry-run", false, "only show the changes that would be made")
+	prune := fs.Bool("prune", false, "also delete the registered commands that are not in the manifest")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.plan(ctx, manifest)
+	if err != nil {
+		return err
+	}
+
+	if !*prune {
+		p = filterActions(p, func(k commandsync.ActionKind) bool { return k != commandsync.ActionDelete })
+	}
+
+	return c.run(ctx, p, *dryRun)
+}
+
+func (c *cli) prune(ctx context.Context, args []string) error {
+	fs := c.flags("prune")
+	manifestPath := fs.String("manifest", "", "the manifest of the commands to keep")
+	dryRun := fs.Bool("dry-run", false, "only show the commands that would be deleted")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readManifest(*manifestPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.plan(ctx, manifest)
+	if err != nil {
+		return err
+	}
+
+	return c.run(ctx, filterActions(p, func(k commandsync.ActionKind) bool { return k == commandsync.ActionDelete }), *dryRun)
+}
+
+// run applies a plan (unless dryRun), then reports it
+func (c *cli) run(ctx context.Context, p commandsync.Plan, dryRun bool) error {
+	if !dryRun {
+		if _, err := c.syncer.Apply(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	return c.writePlan(p, !dryRun)
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	fs := c.flags("delete")
+	id := fs.String("id", "", "the id of the command to delete")
+	name := fs.String("name", "", "the name of the command to delete")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	if (*id == "") == (*name == "") {
+		return errors.Wrap(errUsage, "exactly one of --id and --name is required")
+	}
+
+	cmds, err := c.registered(ctx)
+	if err != nil {
+		return err
+	}
+
+	var found []entity.ApplicationCommand
+	for _, cmd := range cmds {
+		if cmd.ID == *id || cmd.Name == *name {
+			found = append(found, cmd)
+		}
+	}
+
+	switch {
+	case len(found) == 0:
+		return errors.Wrap(errNoSuchCommand, "could not delete", "id", *id, "name", *name)
+	case len(found) > 1:
+		return errors.Wrap(errAmbiguousName, "could not delete", "name", *name)
+	}
+
+	cmd := found[0]
+	if c.conf.guild == 0 {
+		err = c.client.DeleteGlobalCommand(ctx, c.conf.appID, cmd.IDSnowflake)
+	} else {
+		err = c.client.DeleteGuildCommand(ctx, c.conf.appID, c.conf.guild, cmd.IDSnowflake)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.conf.format == "json" {
+		return c.writeJSON(c.stdout, planAction{Kind: commandsync.ActionDelete.String(), Name: cmd.Name, ID: cmd.IDSnowflake.ToString()})
+	}
+
+	fmt.Fprintf(c.stdout, "deleted %s command %s (%s)\n", c.scope(), cmd.Name, cmd.IDSnowflake.ToString())
+	return nil
+}
+
+func (c *cli) permissions(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errUsage, "missing permissions command (list or set)")
+	}
+
+	if c.conf.guild == 0 {
+		return errors.Wrap(errUsage, "command permissions are per guild (--guild)")
+	}
+
+	switch args[0] {
+	case "list":
+		fs := c.flags("permissions list")
+		if err := c.parse(fs, args[1:]); err != nil {
+			return err
+		}
+
+		perms, err := c.client.GetGuildCommandPermissions(ctx, c.conf.appID, c.conf.guild)
+		if err != nil {
+			return err
+		}
+
+		return c.writePermissions(ctx, perms)
+
+	case "set":
+		fs := c.flags("permissions set")
+		path := fs.String("file", "", "a json array of command permissions to overwrite the guild's with")
+		if err := c.parse(fs, args[1:]); err != nil {
+			return err
+		}
+
+		if *path == "" {
+			return errors.Wrap(errUsage, "a permissions file is required (--file)")
+		}
+
+		b, err := os.ReadFile(*path)
+		if err != nil {
+			return errors.Wrap(err, "could not read permissions", "path", *path)
+		}
+
+		var perms []entity.ApplicationCommandPermissions
+		if err := json.Unmarshal(b, &perms); err != nil {
+			return errors.Wrap(err, "could not parse permissions", "path", *path)
+		}
+
+		perms, err = c.client.BulkOverwriteGuildCommandPermissions(ctx, c.conf.appID, c.conf.guild, perms)
+		if err != nil {
+			return err
+		}
+
+		return c.writePermissions(ctx, perms)
+
+	default:
+		return errors.Wrap(errUsage, "unknown permissions command", "command", args[0])
+	}
+}
+
+func permissionType(t entity.CommandPermissionType) string {
+	switch t {
+	case entity.CommandPermissionRole:
+		return "role"
+	case entity.CommandPermissionUser:
+		return "user"
+	default:
+		return fmt.Sprintf("type %d", int(t))
+	}
+}
+
+func (c *cli) writePermissions(ctx context.Context, perms []entity.ApplicationCommandPermissions) error {
+	if c.conf.format == "json" {
+		if perms == nil {
+			perms = []entity.ApplicationCommandPermissions{}
+		}
+		return c.writeJSON(c.stdout, perms)
+	}
+
+	// name the commands where possible; the permissions only carry their ids
+	names := map[snowflake.Snowflake]string{}
+	if cmds, err := c.registered(ctx); err == nil {
+		for _, cmd := range cmds {
+			names[cmd.IDSnowflake] = cmd.Name
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "%s command permissions: %d\n", c.scope(), len(perms))
+	for _, p := range perms {
+		name, ok := names[p.IDSnowflake]
+		if !ok {
+			name = "?"
+		}
+
+		fmt.Fprintf(c.stdout, "  %s\t%s\n", p.IDSnowflake.ToString(), name)
+		for _, perm := range p.Permissions {
+			fmt.Fprintf(c.stdout, "    %s %s\tallow=%v\n", permissionType(perm.Type), perm.IDString, perm.Permission)
+		}
+	}
+
+	return nil
+}
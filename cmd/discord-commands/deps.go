@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+	"golang.org/x/time/rate"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/httpclient"
+)
+
+// logger writes log lines to stderr (stdout is kept for the command output), and only when verbose
+type logger struct {
+	w       io.Writer
+	verbose bool
+}
+
+func (l logger) Log(keyvals ...interface{}) error {
+	if !l.verbose {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(l.w, keyvals...)
+	return err
+}
+
+func (l logger) Message(msg string, keyvals ...interface{}) {
+	_ = l.Log(append([]interface{}{"msg", msg}, keyvals...)...)
+}
+
+func (l logger) Err(msg string, err error, keyvals ...interface{}) {
+	_ = l.Log(append([]interface{}{"msg", msg, "err", err}, keyvals...)...)
+}
+
+func (l logger) Printf(f string, args ...interface{}) {
+	_ = l.Log("msg", fmt.Sprintf(f, args...))
+}
+
+type nopSpanExporter struct{}
+
+func (nopSpanExporter) ExportSpans(context.Context, []telemetry.ReadOnlySpan) error { return nil }
+func (nopSpanExporter) Shutdown(context.Context) error                              { return nil }
+
+type dependencies struct {
+	logger    logger
+	telemeter *telemetry.Telemeter
+	doer      httpclient.Doer
+	http      *httpclient.HTTPClient
+	msgrl     *rate.Limiter
+	cregrl    *rate.Limiter
+	jsc       *jsonapi.DiscordJSONClient
+}
+
+func (d *dependencies) Logger() jsonapi.Logger                        { return d.logger }
+func (d *dependencies) Telemetry() *telemetry.Telemeter               { return d.telemeter }
+func (d *dependencies) HTTPDoer() httpclient.Doer                     { return d.doer }
+func (d *dependencies) HTTPClient() jsonapi.HTTPClient                { return d.http }
+func (d *dependencies) MessageRateLimiter() *rate.Limiter             { return d.msgrl }
+func (d *dependencies) CommandRegistrationRateLimiter() *rate.Limiter { return d.cregrl }
+func (d *dependencies) DiscordJSONClient() *jsonapi.DiscordJSONClient { return d.jsc }
+
+func newDependencies(c config, stderr io.Writer) *dependencies {
+	deps := &dependencies{
+		logger:    logger{w: stderr, verbose: c.verbose},
+		telemeter: telemetry.NewTelemeter(AppName, BuildVersion, "cli", nopSpanExporter{}, nonrecording.NewNoopMeterProvider(), 0),
+		doer:      &http.Client{Timeout: 30 * time.Second},
+		msgrl:     rate.NewLimiter(rate.Every(60*time.Second), 120),
+		cregrl:    rate.NewLimiter(rate.Every(5*time.Second), 5),
+	}
+
+	deps.http = httpclient.NewHTTPClient(deps)
+	deps.http.SetHeaders(http.Header{
+		"Authorization": []string{fmt.Sprintf("Bot %s", c.token)},
+		"Content-Type":  []string{"application/json"},
+		"User-Agent":    []string{fmt.Sprintf("DiscordBot (https://github.com/gsmcwhirter/discord-bot-lib, %s)", BuildVersion)},
+	})
+
+	deps.jsc = jsonapi.NewDiscordJSONClient(deps, c.apiURL)
+
+	return deps
+}
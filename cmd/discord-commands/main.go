@@ -0,0 +1,169 @@
+// Command discord-commands lists, exports, diffs and applies the application commands of a discord bot
+//
+// Usage:
+//
+//	discord-commands [global flags] <command> [flags]
+//
+// Commands:
+//
+//	list                          list the registered commands
+//	export [-o file]              write the registered commands as a manifest (a json array of commands)
+//	diff --manifest file          show the changes that applying the manifest would make
+//	apply --manifest file         create and edit commands to match the manifest (--prune also deletes the others)
+//	prune --manifest file         delete the commands that are not in the manifest
+//	delete --id id | --name name  delete a single command
+//	permissions list              list the command permissions of a guild
+//	permissions set --file file   overwrite the command permissions of a guild
+//	version                       print the version
+//
+// The global flags select the application, the bot token, the api url (so that it can run against
+// a stand-in server), the guild (global commands if not given) and the output format (text or json).
+// They default to the DISCORD_APP_ID, DISCORD_BOT_TOKEN and DISCORD_API_URL environment variables.
+//
+// The exit status is 0 on success, 1 on failure, 2 for bad usage, and 3 when diff --exit-code finds changes
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/spf13/pflag"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// Build information, set with -ldflags
+var (
+	AppName      = "discord-commands"
+	BuildVersion = "dev"
+	BuildSHA     = "unknown"
+	BuildDate    = "unknown"
+)
+
+// DefaultAPIURL is the discord api used when neither --api-url nor DISCORD_API_URL is given
+const DefaultAPIURL = "https://discord.com/api/v10"
+
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitChanges = 3
+)
+
+// errUsage marks errors in the command line
+var errUsage = errors.New("usage error")
+
+// errChanges is returned by diff --exit-code when the manifest differs from the registered commands
+var errChanges = errors.New("commands differ from the manifest")
+
+type config struct {
+	apiURL  string
+	appID   string
+	token   string
+	guild   snowflake.Snowflake
+	format  string
+	verbose bool
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return def
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := pflag.NewFlagSet(AppName, pflag.ContinueOnError)
+	fs.SetOutput(stderr)
+	// everything from the command on belongs to the command
+	fs.SetInterspersed(false)
+
+	var c config
+	var guild string
+	fs.StringVar(&c.apiURL, "api-url", envOr("DISCORD_API_URL", DefaultAPIURL), "the discord api url")
+	fs.StringVar(&c.appID, "app", os.Getenv("DISCORD_APP_ID"), "the application (client) id")
+	fs.StringVar(&c.token, "token", os.Getenv("DISCORD_BOT_TOKEN"), "the bot token")
+	fs.StringVar(&guild, "guild", "", "the guild whose commands to manage (global commands if empty)")
+	fs.StringVar(&c.format, "format", "text", "the output format: text or json")
+	fs.BoolVarP(&c.verbose, "verbose", "v", false, "log requests to stderr")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s [global flags] <list|export|diff|apply|prune|delete|permissions|version> [flags]\n\nglobal flags:\n", AppName)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	err := dispatch(ctx, &c, guild, fs.Args(), stdout, stderr)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, pflag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errChanges):
+		return exitChanges
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "%s: %v\n", AppName, err)
+		fs.Usage()
+		return exitUsage
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", AppName, err)
+		return exitFailed
+	}
+}
+
+func dispatch(ctx context.Context, c *config, guild string, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errors.Wrap(errUsage, "missing command")
+	}
+
+	if args[0] == "version" {
+		fmt.Fprintf(stdout, "%s %s (%s, built %s)\n", AppName, BuildVersion, BuildSHA, BuildDate)
+		return nil
+	}
+
+	if c.format != "text" && c.format != "json" {
+		return errors.Wrap(errUsage, "unknown format", "format", c.format)
+	}
+
+	if c.appID == "" || c.token == "" {
+		return errors.Wrap(errUsage, "the application id and bot token are required (--app and --token)")
+	}
+
+	if guild != "" {
+		var err error
+		if c.guild, err = snowflake.FromString(guild); err != nil {
+			return errors.Wrap(errUsage, "bad guild id", "guild", guild)
+		}
+	}
+
+	cli := newCLI(*c, stdout, stderr)
+
+	switch args[0] {
+	case "list":
+		return cli.list(ctx, args[1:])
+	case "export":
+		return cli.export(ctx, args[1:])
+	case "diff":
+		return cli.diff(ctx, args[1:])
+	case "apply":
+		return cli.apply(ctx, args[1:])
+	case "prune":
+		return cli.prune(ctx, args[1:])
+	case "delete":
+		return cli.delete(ctx, args[1:])
+	case "permissions":
+		return cli.permissions(ctx, args[1:])
+	default:
+		return errors.Wrap(errUsage, "unknown command", "command", args[0])
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+)
+
+// standIn is a stand-in for the discord api, keeping the global commands of one application in memory
+type standIn struct {
+	mu     sync.Mutex
+	cmds   []map[string]interface{}
+	nextID int
+	calls  []string
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bot token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const base = "/applications/42/commands"
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, base), "/")
+
+	switch {
+	case r.URL.Path == "/applications/42/guilds/1/commands/permissions" && r.Method == http.MethodGet:
+		s.write(w, []map[string]interface{}{{
+			"id": "101", "application_id": "42", "guild_id": "1",
+			"permissions": []map[string]interface{}{{"id": "7", "type": 1, "permission": true}},
+		}})
+
+	case !strings.HasPrefix(r.URL.Path, base):
+		w.WriteHeader(http.StatusNotFound)
+
+	case r.Method == http.MethodGet && id == "":
+		s.write(w, s.cmds)
+
+	case r.Method == http.MethodPost && id == "":
+		cmd := s.read(r)
+		s.nextID++
+		cmd["id"] = fmt.Sprint(100 + s.nextID)
+		cmd["application_id"] = "42"
+		s.cmds = append(s.cmds, cmd)
+		s.write(w, cmd)
+
+	case r.Method == http.MethodPatch:
+		for i := range s.cmds {
+			if s.cmds[i]["id"] == id {
+				cmd := s.read(r)
+				cmd["id"], cmd["application_id"] = id, "42"
+				s.cmds[i] = cmd
+				s.write(w, cmd)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+
+	case r.Method == http.MethodDelete:
+		for i := range s.cmds {
+			if s.cmds[i]["id"] == id {
+				s.cmds = append(s.cmds[:i], s.cmds[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *standIn) read(r *http.Request) map[string]interface{} {
+	var cmd map[string]interface{}
+	_ = json.UnmarshalFromReader(r.Body, &cmd)
+	return cmd
+}
+
+func (s *standIn) write(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	b, _ := json.Marshal(v)
+	_, _ = w.Write(b)
+}
+
+func (s *standIn) takeCalls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+type runner struct {
+	t   *testing.T
+	url string
+}
+
+func (r runner) run(args ...string) (code int, stdout, stderr string) {
+	r.t.Helper()
+
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), append([]string{"--api-url", r.url, "--app", "42", "--token", "token"}, args...), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const manifestV1 = `[
+  {"type": 1, "name": "ping", "description": "Ping the bot", "default_permission": true},
+  {"type": 1, "name": "event", "description": "Manage events", "default_permission": true, "options": [
+    {"type": 3, "name": "kind", "description": "The kind", "required": true, "choices": [{"name": "Raid", "value": "raid"}]}
+  ]}
+]`
+
+const manifestV2 = `[
+  {"type": 1, "name": "event", "description": "Manage raids", "default_permission": true, "options": [
+    {"type": 3, "name": "kind", "description": "The kind", "required": true, "choices": [{"name": "Raid", "value": "raid"}]}
+  ]}
+]`
+
+func TestRun_workflow(t *testing.T) {
+	t.Parallel()
+
+	api := &standIn{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	r := runner{t: t, url: srv.URL}
+	v1 := writeFile(t, "v1.json", manifestV1)
+	v2 := writeFile(t, "v2.json", manifestV2)
+
+	// diff against nothing registered
+	code, out, errOut := r.run("diff", "--manifest", v1, "--exit-code")
+	if code != exitChanges || !strings.Contains(out, "global commands: 2 to create, 0 to edit, 0 to delete, 0 unchanged") {
+		t.Fatalf("diff = %d, %q, %q", code, out, errOut)
+	}
+
+	// apply creates both, and the same diff is then clean
+	if code, out, errOut = r.run("apply", "--manifest", v1); code != exitOK {
+		t.Fatalf("apply = %d, %q, %q", code, out, errOut)
+	}
+
+	if code, out, errOut = r.run("diff", "--manifest", v1, "--exit-code"); code != exitOK || !strings.Contains(out, "0 to create, 0 to edit, 0 to delete, 2 unchanged") {
+		t.Fatalf("diff after apply = %d, %q, %q", code, out, errOut)
+	}
+
+	// list shows them, sorted by name
+	code, out, _ = r.run("list")
+	if code != exitOK || !strings.Contains(out, "global commands: 2") || strings.Index(out, "event") > strings.Index(out, "ping") {
+		t.Errorf("list = %d, %q", code, out)
+	}
+
+	// export round-trips as a manifest without the assigned ids
+	exported := filepath.Join(t.TempDir(), "exported.json")
+	if code, _, errOut = r.run("export", "-o", exported); code != exitOK {
+		t.Fatalf("export = %d, %q", code, errOut)
+	}
+
+	b, err := os.ReadFile(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), `"id"`) || strings.Contains(string(b), "application_id") {
+		t.Errorf("export wrote assigned ids: %s", b)
+	}
+
+	if code, out, _ = r.run("diff", "--manifest", exported, "--exit-code"); code != exitOK {
+		t.Errorf("diff of the export = %d, %q", code, out)
+	}
+
+	// apply without -prune edits but keeps commands missing from the manifest
+	api.takeCalls()
+	code, out, errOut = r.run("--format", "json", "apply", "--manifest", v2)
+	if code != exitOK {
+		t.Fatalf("apply v2 = %d, %q, %q", code, out, errOut)
+	}
+
+	var plan planOutput
+	if err := json.Unmarshal([]byte(out), &plan); err != nil {
+		t.Fatalf("apply v2 output %q: %v", out, err)
+	}
+
+	if !plan.Applied || plan.Scope != "global" || len(plan.Actions) != 1 || plan.Actions[0].Kind != "edit" || plan.Actions[0].Name != "event" {
+		t.Errorf("apply v2 plan = %+v", plan)
+	}
+
+	if calls := api.takeCalls(); len(calls) != 2 || calls[1] != "PATCH /applications/42/commands/102" {
+		t.Errorf("apply v2 calls = %v", calls)
+	}
+
+	// prune -dry-run changes nothing; prune deletes the stale command
+	if code, out, _ = r.run("prune", "--manifest", v2, "--dry-run"); code != exitOK || !strings.Contains(out, "- delete ping") {
+		t.Errorf("prune -dry-run = %d, %q", code, out)
+	}
+
+	if calls := api.takeCalls(); len(calls) != 1 {
+		t.Errorf("prune -dry-run calls = %v", calls)
+	}
+
+	if code, _, errOut = r.run("prune", "--manifest", v2); code != exitOK {
+		t.Fatalf("prune = %d, %q", code, errOut)
+	}
+
+	if code, out, _ = r.run("diff", "--manifest", v2, "--exit-code"); code != exitOK {
+		t.Errorf("diff after prune = %d, %q", code, out)
+	}
+
+	// delete by name
+	if code, out, errOut = r.run("delete", "--name", "event"); code != exitOK || !strings.Contains(out, "deleted global command event (102)") {
+		t.Errorf("delete = %d, %q, %q", code, out, errOut)
+	}
+
+	if code, _, errOut = r.run("delete", "--name", "event"); code != exitFailed || !strings.Contains(errOut, "no such command") {
+		t.Errorf("delete again = %d, %q", code, errOut)
+	}
+}
+
+func TestRun_permissions(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(&standIn{})
+	defer srv.Close()
+
+	r := runner{t: t, url: srv.URL}
+
+	code, out, errOut := r.run("--guild", "1", "permissions", "list")
+	if code != exitOK || !strings.Contains(out, "guild 1 command permissions: 1") || !strings.Contains(out, "role 7\tallow=true") {
+		t.Errorf("permissions list = %d, %q, %q", code, out, errOut)
+	}
+
+	if code, _, _ = r.run("permissions", "list"); code != exitUsage {
+		t.Errorf("permissions list without a guild = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestRun_usage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "no command", args: nil, want: exitUsage},
+		{name: "unknown command", args: []string{"frob"}, want: exitUsage},
+		{name: "unknown format", args: []string{"--format", "yaml", "list"}, want: exitUsage},
+		{name: "missing manifest", args: []string{"diff"}, want: exitUsage},
+		{name: "bad guild", args: []string{"--guild", "x", "list"}, want: exitUsage},
+		{name: "delete needs one of id and name", args: []string{"delete"}, want: exitUsage},
+		{name: "version", args: []string{"version"}, want: exitOK},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := runner{t: t, url: "http://127.0.0.1:0"}
+			if code, _, errOut := r.run(tt.args...); code != tt.want {
+				t.Errorf("run(%v) = %d, want %d (%q)", tt.args, code, tt.want, errOut)
+			}
+		})
+	}
+}
+
+func TestIndentJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "scalar", in: `1`, want: `1`},
+		{name: "empty", in: `{"a":[],"b":{}}`, want: "{\n  \"a\": [],\n  \"b\": {}\n}"},
+		{name: "nested", in: `[{"a":1,"b":[true,null]}]`, want: "[\n  {\n    \"a\": 1,\n    \"b\": [\n      true,\n      null\n    ]\n  }\n]"},
+		{name: "strings", in: `{"a":"x,{\"y\":[1]}\\"}`, want: "{\n  \"a\": \"x,{\\\"y\\\":[1]}\\\\\"\n}"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := string(indentJSON([]byte(tt.in), "  ")); got != tt.want {
+				t.Errorf("indentJSON(%s) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
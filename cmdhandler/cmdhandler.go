@@ -38,6 +38,7 @@ type CommandHandler struct {
 	caseSensitive         bool
 
 	helpHandler MessageHandler
+	middleware  []MessageMiddleware
 }
 
 // NewCommandHandler creates a new CommandHandler from the given parser
@@ -152,6 +153,13 @@ func (ch *CommandHandler) SetHandler(cmd string, handler MessageHandler) {
 	ch.commands[strings.ToLower(cmd)] = handler
 }
 
+// Use adds middleware around the handlers of the commands (including help); the first is the outermost
+//
+// A CommandHandler set as the handler of a command is a command group, with its own middleware
+func (ch *CommandHandler) Use(mw ...MessageMiddleware) {
+	ch.middleware = append(ch.middleware, mw...)
+}
+
 func (ch *CommandHandler) getHandler(cmd string) (MessageHandler, bool) {
 	if ch.caseSensitive {
 		h, ok := ch.commands[cmd]
@@ -197,8 +205,10 @@ func (ch *CommandHandler) HandleMessage(msg Message) (Response, error) {
 
 			var s Response
 
-			s, err = subHandler.HandleMessage(NewWithTokens(msg, rest, msg.ContentErr()))
-			s.IncludeError(parser.ErrUnknownCommand)
+			s, err = ch.call(cmd2, subHandler, NewWithTokens(msg, rest, msg.ContentErr()))
+			if s != nil {
+				s.IncludeError(parser.ErrUnknownCommand)
+			}
 			return s, err
 		}
 
@@ -208,7 +218,7 @@ func (ch *CommandHandler) HandleMessage(msg Message) (Response, error) {
 	subHandler, cmdExists := ch.getHandler(cmd)
 
 	if (err == nil || err == parser.ErrNotACommand) && cmd == "" && cmdExists {
-		return subHandler.HandleMessage(NewWithTokens(msg, rest, msg.ContentErr()))
+		return ch.call(cmd, subHandler, NewWithTokens(msg, rest, msg.ContentErr()))
 	}
 
 	if err != nil {
@@ -222,5 +232,10 @@ func (ch *CommandHandler) HandleMessage(msg Message) (Response, error) {
 		return r, ErrMissingHandler
 	}
 
-	return subHandler.HandleMessage(NewWithTokens(msg, rest, msg.ContentErr()))
+	return ch.call(cmd, subHandler, NewWithTokens(msg, rest, msg.ContentErr()))
+}
+
+// call runs the handler of a command inside the middleware, noting the command on the message (see MessageCommand)
+func (ch *CommandHandler) call(cmd string, h MessageHandler, msg Message) (Response, error) {
+	return WithMessageMiddleware(h, ch.middleware...).HandleMessage(withMessageCommand(msg, cmd))
 }
@@ -0,0 +1,322 @@
+package cmdhandler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrCollectorExpired is the error returned when a collector times out
+var ErrCollectorExpired = errors.New("collector expired")
+
+// collectorBuffer is the number of interactions a Collect stream holds before further interactions
+// fall through to the component routes
+const collectorBuffer = 16
+
+// ComponentFilter decides whether a component interaction should be collected (a nil filter collects all)
+type ComponentFilter func(*Interaction) bool
+
+// FromUser creates a ComponentFilter that collects only the interactions of the given user
+func FromUser(uid snowflake.Snowflake) ComponentFilter {
+	return func(ix *Interaction) bool {
+		return ix.UserID() == uid
+	}
+}
+
+// ResponseEditor edits the original response to an interaction (jsonapi.DiscordJSONClient is one)
+type ResponseEditor interface {
+	EditOriginalResponse(context.Context, jsonapi.InteractionWebhook, jsonapi.MessageResponse) (entity.Message, error)
+}
+
+// Collector is a stream of component interactions on a message (see InteractionDispatcher.Collect)
+//
+// Interactions handed to a collector are not dispatched to the component routes, so the code
+// reading from the collector must respond to them
+type Collector struct {
+	mid       snowflake.Snowflake
+	filter    ComponentFilter
+	remaining int
+
+	ch   chan *Interaction
+	done chan struct{}
+
+	closed bool  // guarded by collectors.mu
+	err    error // set before done and ch are closed
+}
+
+// Interactions returns the channel of collected interactions, which is closed when the collector ends
+func (c *Collector) Interactions() <-chan *Interaction {
+	return c.ch
+}
+
+// Err returns the reason the collector ended, once the Interactions channel is closed
+//
+// This is ErrCollectorExpired after a timeout (possibly wrapping a failure to disable the components),
+// the context error if the context ended first, or nil if the collector received all it asked for
+func (c *Collector) Err() error {
+	return c.err
+}
+
+type componentExpiry struct {
+	editor     ResponseEditor
+	webhook    jsonapi.InteractionWebhook
+	components []entity.Component
+}
+
+// collectors is the registry of active collectors on an InteractionDispatcher
+type collectors struct {
+	mu        sync.Mutex
+	byMessage map[snowflake.Snowflake][]*Collector
+	expiries  map[snowflake.Snowflake]componentExpiry
+}
+
+// add registers a collector
+func (cs *collectors) add(c *Collector) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.byMessage == nil {
+		cs.byMessage = map[snowflake.Snowflake][]*Collector{}
+	}
+
+	cs.byMessage[c.mid] = append(cs.byMessage[c.mid], c)
+}
+
+// deliver hands the interaction to the first matching collector on its message, reporting whether one took it
+//
+// The filters are user code, so they run outside the lock, against a snapshot of the collectors
+func (cs *collectors) deliver(ix *Interaction) bool {
+	if ix.Message == nil {
+		return false
+	}
+
+	cs.mu.Lock()
+	candidates := append([]*Collector(nil), cs.byMessage[ix.Message.IDSnowflake]...)
+	cs.mu.Unlock()
+
+	for _, c := range candidates {
+		if c.filter != nil && !c.filter(ix) {
+			continue
+		}
+
+		if cs.offer(c, ix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// offer sends the interaction to a collector if it is still open and has room, reporting whether it took it
+func (cs *collectors) offer(c *Collector, ix *Interaction) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// the collector may have ended while its filter ran
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.ch <- ix:
+	default:
+		return false
+	}
+
+	if c.remaining > 0 {
+		c.remaining--
+		if c.remaining == 0 {
+			_, _ = cs.unregisterLocked(c)
+			c.finish(nil)
+		}
+	}
+
+	return true
+}
+
+// remove unregisters a collector, returning the expiry to apply if it was the last one on its message
+//
+// It reports false if the collector had already been unregistered
+func (cs *collectors) remove(c *Collector, err error) (componentExpiry, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	exp, ok := cs.unregisterLocked(c)
+	if !ok {
+		return componentExpiry{}, false
+	}
+
+	if !errors.Is(err, ErrCollectorExpired) {
+		return componentExpiry{}, true
+	}
+
+	return exp, true
+}
+
+// unregisterLocked unregisters a collector, reporting false if it had already been unregistered
+//
+// If it was the last collector on its message, the expiry registered for the message (if any) is
+// dropped and returned, whatever the reason the collector ended
+func (cs *collectors) unregisterLocked(c *Collector) (componentExpiry, bool) {
+	if c.closed {
+		return componentExpiry{}, false
+	}
+	c.closed = true
+
+	list := cs.byMessage[c.mid]
+	for i := range list {
+		if list[i] == c {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+
+	if len(list) > 0 {
+		cs.byMessage[c.mid] = list
+		return componentExpiry{}, true
+	}
+
+	delete(cs.byMessage, c.mid)
+
+	exp := cs.expiries[c.mid]
+	delete(cs.expiries, c.mid)
+
+	return exp, true
+}
+
+// finish ends an unregistered collector (nothing can send to it anymore)
+func (c *Collector) finish(err error) {
+	c.err = err
+	close(c.done)
+	close(c.ch)
+}
+
+// DisableOnExpiry arranges for components to be disabled when the collectors on a message time out
+//
+// The components are those of the original response to the interaction identified by w (the message
+// the collectors wait on); they are sent back disabled through editor.EditOriginalResponse once the
+// last collector on the message expires. The arrangement is dropped when the last collector ends for
+// any reason, so it must be made before the collectors are started
+func (i *InteractionDispatcher) DisableOnExpiry(mid snowflake.Snowflake, editor ResponseEditor, w jsonapi.InteractionWebhook, components []entity.Component) {
+	i.collectors.mu.Lock()
+	defer i.collectors.mu.Unlock()
+
+	if i.collectors.expiries == nil {
+		i.collectors.expiries = map[snowflake.Snowflake]componentExpiry{}
+	}
+
+	i.collectors.expiries[mid] = componentExpiry{
+		editor:     editor,
+		webhook:    w,
+		components: components,
+	}
+}
+
+// Collect starts collecting the component interactions on a message that match the filter, until the
+// timeout passes or the context ends
+func (i *InteractionDispatcher) Collect(ctx context.Context, mid snowflake.Snowflake, filter ComponentFilter, timeout time.Duration) *Collector {
+	return i.collect(ctx, mid, filter, timeout, 0)
+}
+
+// Await waits for the next component interaction on a message that matches the filter
+//
+// It returns ErrCollectorExpired if none arrives before the timeout, or the context error if the context
+// ends first. The caller must respond to the returned interaction
+func (i *InteractionDispatcher) Await(ctx context.Context, mid snowflake.Snowflake, filter ComponentFilter, timeout time.Duration) (*Interaction, error) {
+	c := i.collect(ctx, mid, filter, timeout, 1)
+
+	ix, ok := <-c.Interactions()
+	if !ok {
+		return nil, c.Err()
+	}
+
+	return ix, nil
+}
+
+func (i *InteractionDispatcher) collect(ctx context.Context, mid snowflake.Snowflake, filter ComponentFilter, timeout time.Duration, limit int) *Collector {
+	buf := collectorBuffer
+	if limit > 0 {
+		buf = limit
+	}
+
+	c := &Collector{
+		mid:       mid,
+		filter:    filter,
+		remaining: limit,
+		ch:        make(chan *Interaction, buf),
+		done:      make(chan struct{}),
+	}
+	i.collectors.add(c)
+
+	go i.expire(ctx, c, timeout)
+
+	return c
+}
+
+func (i *InteractionDispatcher) expire(ctx context.Context, c *Collector, timeout time.Duration) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	var err error
+	select {
+	case <-c.done:
+		return
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-t.C:
+		err = ErrCollectorExpired
+	}
+
+	exp, ok := i.collectors.remove(c, err)
+	if !ok {
+		return
+	}
+
+	if exp.editor != nil {
+		if derr := disableComponents(ctx, exp); derr != nil {
+			err = errors.Wrap(err, "could not disable components", "disable_error", derr.Error())
+		}
+	}
+
+	c.finish(err)
+}
+
+// disabledComponents returns a copy of the components with every button and select disabled
+func disabledComponents(cs []entity.Component) []entity.Component {
+	out := make([]entity.Component, len(cs))
+	for i, c := range cs {
+		if c.Type == entity.ComponentActionRow {
+			c.Components = disabledComponents(c.Components)
+		} else if (c.Type == entity.ComponentButton && c.Style != entity.ButtonLink) || c.Type.IsSelect() {
+			c.Disabled = true
+		}
+		out[i] = c
+	}
+
+	return out
+}
+
+// componentsEdit is a message edit that only replaces the components of a message
+type componentsEdit struct {
+	Components []entity.Component `json:"components"`
+}
+
+func (e componentsEdit) MarshalToJSON() ([]byte, error) {
+	return json.MarshalToBuffer(e)
+}
+
+func (e componentsEdit) ToMessage() JSONMarshaler {
+	return e
+}
+
+func disableComponents(ctx context.Context, exp componentExpiry) error {
+	_, err := exp.editor.EditOriginalResponse(ctx, exp.webhook, componentsEdit{Components: disabledComponents(exp.components)})
+	return errors.Wrap(err, "could not edit the original response")
+}
@@ -0,0 +1,310 @@
+package cmdhandler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+type recordingEditor struct {
+	mu    sync.Mutex
+	edits []jsonapi.MessageResponse
+}
+
+func (e *recordingEditor) EditOriginalResponse(ctx context.Context, w jsonapi.InteractionWebhook, r jsonapi.MessageResponse) (entity.Message, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.edits = append(e.edits, r)
+	return entity.Message{}, nil
+}
+
+func componentClick(mid, uid snowflake.Snowflake, customID string) *Interaction {
+	return &Interaction{
+		Interaction: entity.Interaction{
+			Type:    entity.InteractionMessageComponent,
+			User:    &entity.User{IDSnowflake: uid},
+			Message: &entity.Message{IDSnowflake: mid},
+			Data: &entity.InteractionData{
+				CustomID:      customID,
+				ComponentType: entity.ComponentButton,
+			},
+		},
+	}
+}
+
+func TestInteractionDispatcher_Await(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	type result struct {
+		ix  *Interaction
+		err error
+	}
+	res := make(chan result, 1)
+
+	go func() {
+		ix, err := ixd.Await(context.Background(), 10, FromUser(2), time.Second)
+		res <- result{ix, err}
+	}()
+
+	// wait for the collector to be registered
+	for {
+		ixd.collectors.mu.Lock()
+		n := len(ixd.collectors.byMessage[10])
+		ixd.collectors.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, _, err := ixd.Dispatch(componentClick(10, 3, "yes")); !errors.Is(err, ErrMissingHandler) {
+		t.Errorf("Dispatch() of a filtered-out click error = %v, want ErrMissingHandler", err)
+	}
+
+	if r, _, err := ixd.Dispatch(componentClick(10, 2, "yes")); r != nil || err != nil {
+		t.Errorf("Dispatch() of a collected click = %v, %v, want nil, nil", r, err)
+	}
+
+	got := <-res
+	if got.err != nil || got.ix == nil || got.ix.CustomID() != "yes" || got.ix.UserID() != 2 {
+		t.Errorf("Await() = %v, %v, want the click of user 2", got.ix, got.err)
+	}
+}
+
+func TestInteractionDispatcher_AwaitExpired(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	ed := &recordingEditor{}
+	ixd.DisableOnExpiry(10, ed, jsonapi.InteractionWebhook{}, []entity.Component{
+		entity.ActionRow(entity.Button(entity.ButtonPrimary, "Yes", "yes"), entity.LinkButton("Docs", "https://example.com")),
+	})
+
+	if _, err := ixd.Await(context.Background(), 10, nil, 10*time.Millisecond); !errors.Is(err, ErrCollectorExpired) {
+		t.Fatalf("Await() error = %v, want ErrCollectorExpired", err)
+	}
+
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	if len(ed.edits) != 1 {
+		t.Fatalf("expected 1 edit, got %d", len(ed.edits))
+	}
+
+	row := ed.edits[0].(componentsEdit).Components[0]
+	if !row.Components[0].Disabled || row.Components[1].Disabled {
+		t.Errorf("expected only the non-link button to be disabled, got %+v", row.Components)
+	}
+}
+
+func TestInteractionDispatcher_DisableOnExpiry_cleanup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		end     func(ixd *InteractionDispatcher, cancel context.CancelFunc)
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name: "limit reached",
+			end: func(ixd *InteractionDispatcher, cancel context.CancelFunc) {
+				_, _, _ = ixd.Dispatch(componentClick(10, 2, "yes"))
+			},
+			timeout: time.Minute,
+		},
+		{
+			name:    "context cancelled",
+			end:     func(ixd *InteractionDispatcher, cancel context.CancelFunc) { cancel() },
+			timeout: time.Minute,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "timeout",
+			end:     func(ixd *InteractionDispatcher, cancel context.CancelFunc) {},
+			timeout: 10 * time.Millisecond,
+			wantErr: ErrCollectorExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ixd, err := NewInteractionDispatcher(nil)
+			if err != nil {
+				t.Fatalf("NewInteractionDispatcher() error = %v", err)
+			}
+
+			ed := &recordingEditor{}
+			ixd.DisableOnExpiry(10, ed, jsonapi.InteractionWebhook{}, []entity.Component{
+				entity.ActionRow(entity.Button(entity.ButtonPrimary, "Yes", "yes")),
+			})
+
+			otherCtx, otherCancel := context.WithCancel(context.Background())
+			defer otherCancel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// a second collector on the message keeps the arrangement alive until it ends too
+			other := ixd.Collect(otherCtx, 10, FromUser(3), time.Minute)
+			c := ixd.collect(ctx, 10, FromUser(2), tt.timeout, 1)
+
+			tt.end(ixd, cancel)
+			for range c.Interactions() {
+			}
+			if !errors.Is(c.Err(), tt.wantErr) || (tt.wantErr == nil && c.Err() != nil) {
+				t.Fatalf("Err() = %v, want %v", c.Err(), tt.wantErr)
+			}
+
+			ixd.collectors.mu.Lock()
+			_, kept := ixd.collectors.expiries[10]
+			ixd.collectors.mu.Unlock()
+
+			if !kept {
+				t.Fatal("expiry dropped while another collector is still waiting")
+			}
+
+			// the last collector ending without a timeout drops the arrangement without disabling anything
+			otherCancel()
+			for range other.Interactions() {
+			}
+
+			ixd.collectors.mu.Lock()
+			defer ixd.collectors.mu.Unlock()
+
+			if len(ixd.collectors.expiries) != 0 || len(ixd.collectors.byMessage) != 0 {
+				t.Errorf("registry not cleaned up: %d expiries, %d messages", len(ixd.collectors.expiries), len(ixd.collectors.byMessage))
+			}
+
+			ed.mu.Lock()
+			defer ed.mu.Unlock()
+
+			if len(ed.edits) != 0 {
+				t.Errorf("got %d edits, want none", len(ed.edits))
+			}
+		})
+	}
+}
+
+func TestInteractionDispatcher_DisableOnExpiry_limitReached(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	ed := &recordingEditor{}
+	ixd.DisableOnExpiry(10, ed, jsonapi.InteractionWebhook{}, []entity.Component{
+		entity.ActionRow(entity.Button(entity.ButtonPrimary, "Yes", "yes")),
+	})
+
+	c := ixd.collect(context.Background(), 10, nil, time.Minute, 1)
+	if _, _, err := ixd.Dispatch(componentClick(10, 2, "yes")); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+
+	for range c.Interactions() {
+	}
+
+	ixd.collectors.mu.Lock()
+	defer ixd.collectors.mu.Unlock()
+
+	if len(ixd.collectors.expiries) != 0 {
+		t.Errorf("expiry kept after the only collector got all it asked for")
+	}
+}
+
+func TestInteractionDispatcher_filterOutsideLock(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// a filter may use the dispatcher, for example to start another collector
+	var nested *Collector
+	c := ixd.Collect(ctx, 10, func(ix *Interaction) bool {
+		nested = ixd.Collect(ctx, 11, nil, time.Minute)
+		return true
+	}, time.Minute)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := ixd.Dispatch(componentClick(10, 2, "yes"))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dispatch() deadlocked on a filter that uses the dispatcher")
+	}
+
+	if ix := <-c.Interactions(); ix.UserID() != 2 {
+		t.Errorf("collected the click of user %v, want 2", ix.UserID())
+	}
+
+	if nested == nil {
+		t.Errorf("the filter did not start its collector")
+	}
+}
+
+func TestInteractionDispatcher_collectorEndsDuringFilter(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the collector ends while its filter runs, so the interaction must not be sent to it
+	var c *Collector
+	c = ixd.Collect(ctx, 10, func(ix *Interaction) bool {
+		cancel()
+		<-c.done
+		return true
+	}, time.Minute)
+
+	if _, _, err := ixd.Dispatch(componentClick(10, 2, "yes")); !errors.Is(err, ErrMissingHandler) {
+		t.Errorf("Dispatch() error = %v, want ErrMissingHandler", err)
+	}
+
+	if _, ok := <-c.Interactions(); ok {
+		t.Errorf("an ended collector received an interaction")
+	}
+
+	if !errors.Is(c.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", c.Err())
+	}
+}
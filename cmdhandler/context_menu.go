@@ -0,0 +1,106 @@
+package cmdhandler
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrUnresolvedTarget is returned when a context menu interaction does not include its resolved target
+var ErrUnresolvedTarget = errors.New("context menu target was not resolved")
+
+// TargetUser returns the user a user command was invoked on, with their guild member (nil outside of guilds)
+func (ix *Interaction) TargetUser() (entity.User, *entity.GuildMember, error) {
+	if ix.Data == nil || ix.Data.Type != entity.CmdTypeUser {
+		return entity.User{}, nil, errors.Wrap(ErrUnresolvedTarget, "not a user command")
+	}
+
+	tid := ix.targetID()
+
+	u, ok := ix.Data.Resolved.Users[tid]
+	if !ok {
+		return entity.User{}, nil, errors.Wrap(ErrUnresolvedTarget, "missing user", "target_id", tid.ToString())
+	}
+
+	m, ok := ix.Data.Resolved.Members[tid]
+	if !ok {
+		return u, nil, nil
+	}
+
+	// resolved members do not include their user
+	if m.User == nil {
+		user := u
+		m.User = &user
+	}
+
+	return u, &m, nil
+}
+
+// TargetMessage returns the message a message command was invoked on
+func (ix *Interaction) TargetMessage() (entity.Message, error) {
+	if ix.Data == nil || ix.Data.Type != entity.CmdTypeMessage {
+		return entity.Message{}, errors.Wrap(ErrUnresolvedTarget, "not a message command")
+	}
+
+	tid := ix.targetID()
+
+	m, ok := ix.Data.Resolved.Messages[tid]
+	if !ok {
+		return entity.Message{}, errors.Wrap(ErrUnresolvedTarget, "missing message", "target_id", tid.ToString())
+	}
+
+	return m, nil
+}
+
+// targetID returns the id of the context menu target, which is only in TargetIDString for interactions
+// decoded from json
+func (ix *Interaction) targetID() snowflake.Snowflake {
+	if ix.Data.TargetIDSnowflake != 0 {
+		return ix.Data.TargetIDSnowflake
+	}
+
+	tid, err := snowflake.FromString(ix.Data.TargetIDString)
+	if err != nil {
+		return 0
+	}
+
+	return tid
+}
+
+// UserCommandHandlerFunc handles a user context menu command, given the target user and their guild member
+// (nil outside of guilds)
+type UserCommandHandlerFunc func(ix *Interaction, target entity.User, member *entity.GuildMember) (Response, []Response, error)
+
+// MessageCommandHandlerFunc handles a message context menu command, given the target message
+type MessageCommandHandlerFunc func(ix *Interaction, target entity.Message) (Response, []Response, error)
+
+// NewUserCommand creates a user context menu command (shown under "Apps" when right-clicking a user)
+func NewUserCommand(name string, f UserCommandHandlerFunc) InteractionCommandHandler {
+	return &interactionCommandHandler{
+		command: entity.ApplicationCommand{Type: entity.CmdTypeUser, Name: name, DefaultPermission: true},
+		handler: NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			u, m, err := ix.TargetUser()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return f(ix, u, m)
+		}),
+	}
+}
+
+// NewMessageCommand creates a message context menu command (shown under "Apps" when right-clicking a message)
+func NewMessageCommand(name string, f MessageCommandHandlerFunc) InteractionCommandHandler {
+	return &interactionCommandHandler{
+		command: entity.ApplicationCommand{Type: entity.CmdTypeMessage, Name: name, DefaultPermission: true},
+		handler: NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			m, err := ix.TargetMessage()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return f(ix, m)
+		}),
+	}
+}
@@ -0,0 +1,204 @@
+package cmdhandler
+
+import (
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func TestInteractionDispatcher_contextMenu(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher([]InteractionCommandHandler{
+		&interactionCommandHandler{
+			command: entity.ApplicationCommand{Type: entity.CmdTypeChatInput, Name: "info", Description: "Info"},
+			handler: namedHandler("slash"),
+		},
+		NewUserCommand("info", func(ix *Interaction, u entity.User, m *entity.GuildMember) (Response, []Response, error) {
+			content := "user " + u.Username
+			if m != nil {
+				content += " aka " + m.Nick + " (" + m.User.Username + ")"
+			}
+			return &SimpleResponse{Content: content}, nil, nil
+		}),
+		NewMessageCommand("info", func(ix *Interaction, m entity.Message) (Response, []Response, error) {
+			return &SimpleResponse{Content: "message " + m.Content}, nil, nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	if got := len(ixd.GlobalCommands()); got != 3 {
+		t.Errorf("GlobalCommands() has %d commands, want 3", got)
+	}
+
+	const target = snowflake.Snowflake(42)
+
+	tests := []struct {
+		name    string
+		data    entity.InteractionData
+		want    string
+		wantErr error
+	}{
+		{
+			name: "chat input",
+			data: entity.InteractionData{Type: entity.CmdTypeChatInput, Name: "info"},
+			want: "slash",
+		},
+		{
+			name: "user in a guild",
+			data: entity.InteractionData{Type: entity.CmdTypeUser, Name: "info", TargetIDSnowflake: target, Resolved: entity.ResolvedData{
+				Users:   map[snowflake.Snowflake]entity.User{target: {Username: "ann"}},
+				Members: map[snowflake.Snowflake]entity.GuildMember{target: {Nick: "Annie"}},
+			}},
+			want: "user ann aka Annie (ann)",
+		},
+		{
+			name: "user in a dm",
+			data: entity.InteractionData{Type: entity.CmdTypeUser, Name: "info", TargetIDSnowflake: target, Resolved: entity.ResolvedData{
+				Users: map[snowflake.Snowflake]entity.User{target: {Username: "ann"}},
+			}},
+			want: "user ann",
+		},
+		{
+			name: "message",
+			data: entity.InteractionData{Type: entity.CmdTypeMessage, Name: "info", TargetIDSnowflake: target, Resolved: entity.ResolvedData{
+				Messages: map[snowflake.Snowflake]entity.Message{target: {Content: "hello"}},
+			}},
+			want: "message hello",
+		},
+		{
+			name:    "unresolved message",
+			data:    entity.InteractionData{Type: entity.CmdTypeMessage, Name: "info", TargetIDSnowflake: target},
+			wantErr: ErrUnresolvedTarget,
+		},
+		{
+			name:    "unknown type",
+			data:    entity.InteractionData{Type: entity.CmdTypeMessage, Name: "other"},
+			wantErr: ErrMissingHandler,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := tt.data
+			ix := &Interaction{
+				Interaction: entity.Interaction{Type: entity.InteractionApplicationCommand, Data: &data},
+			}
+
+			r, _, err := ixd.Dispatch(ix)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Dispatch() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+
+			if got := r.(*SimpleResponse).Content; got != tt.want {
+				t.Errorf("Dispatch() content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInteraction_targets_json(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		payload  string
+		wantUser string
+		wantNick string
+		wantMsg  string
+		wantErr  error
+	}{
+		{
+			name: "user command",
+			payload: `{"id": "1", "type": 2, "data": {"id": "5", "name": "info", "type": 2, "target_id": "80351110224678912",
+				"resolved": {
+					"users": {"80351110224678912": {"id": "80351110224678912", "username": "Nelly"}},
+					"members": {"80351110224678912": {"nick": "nelly", "roles": []}}
+				}}}`,
+			wantUser: "Nelly",
+			wantNick: "nelly",
+		},
+		{
+			name: "message command",
+			payload: `{"id": "1", "type": 2, "data": {"id": "5", "name": "info", "type": 3, "target_id": "867793854505943041",
+				"resolved": {
+					"messages": {"867793854505943041": {"id": "867793854505943041", "channel_id": "2", "content": "hello"}}
+				}}}`,
+			wantMsg: "hello",
+		},
+		{
+			name: "target not resolved",
+			payload: `{"id": "1", "type": 2, "data": {"id": "5", "name": "info", "type": 3, "target_id": "867793854505943041",
+				"resolved": {"messages": {"1": {"id": "1", "content": "other"}}}}}`,
+			wantErr: ErrUnresolvedTarget,
+		},
+		{
+			name:    "bad target id",
+			payload: `{"id": "1", "type": 2, "data": {"id": "5", "name": "info", "type": 2, "target_id": "nope", "resolved": {"users": {}}}}`,
+			wantErr: ErrUnresolvedTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ix := &Interaction{}
+			if err := json.Unmarshal([]byte(tt.payload), &ix.Interaction); err != nil {
+				t.Fatalf("could not decode the interaction: %v", err)
+			}
+
+			if ix.Data.TargetIDSnowflake != 0 {
+				t.Fatalf("TargetIDSnowflake = %v, want it unset after json decoding", ix.Data.TargetIDSnowflake)
+			}
+
+			var got string
+			var err error
+			if ix.Data.Type == entity.CmdTypeUser {
+				var u entity.User
+				var m *entity.GuildMember
+				u, m, err = ix.TargetUser()
+				got = u.Username
+				if err == nil && (m == nil || m.Nick != tt.wantNick || m.User == nil || m.User.Username != tt.wantUser) {
+					t.Errorf("TargetUser() member = %+v, want nick %q of %q", m, tt.wantNick, tt.wantUser)
+				}
+			} else {
+				var m entity.Message
+				m, err = ix.TargetMessage()
+				got = m.Content
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			if want := tt.wantUser + tt.wantMsg; got != want {
+				t.Errorf("got target %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package cmdhandler
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/i18n"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
 )
@@ -13,6 +15,8 @@ type Interaction struct {
 	entity.Interaction
 
 	Ctx context.Context
+
+	path string // set when a CommandTree routes the interaction to a subcommand
 }
 
 var _ logging.Message = (*Interaction)(nil)
@@ -49,3 +53,58 @@ func (ix *Interaction) UserID() snowflake.Snowflake {
 
 	return 0
 }
+
+// CustomID returns the custom_id of the component that triggered the interaction, if any
+func (ix *Interaction) CustomID() string {
+	if ix.Data == nil {
+		return ""
+	}
+
+	return ix.Data.CustomID
+}
+
+// CommandPath returns the full path of the command that was invoked, like "event create" or "admin roles add"
+func (ix *Interaction) CommandPath() string {
+	if ix.path != "" {
+		return ix.path
+	}
+
+	if ix.Data == nil {
+		return ""
+	}
+
+	names, _ := splitCommandPath(ix.Data.Options)
+
+	return strings.Join(append([]string{ix.Data.Name}, names...), " ")
+}
+
+// Locales returns the locales to respond in, most preferred first: the user's, then the guild's
+func (ix *Interaction) Locales() []string {
+	var locales []string
+
+	for _, l := range []string{ix.Locale, ix.GuildLocale} {
+		if l != "" {
+			locales = append(locales, l)
+		}
+	}
+
+	return locales
+}
+
+// T formats the message for the key in the user's locale, falling back to the guild's and then the catalog's
+// default locale
+//
+// The catalog comes from the interaction context (see i18n.WithCatalog and InteractionDispatcher.SetCatalog).
+// Without one, or if no locale has the message, the key is returned
+func (ix *Interaction) T(key string, args i18n.Args) string {
+	if ix.Ctx == nil {
+		return key
+	}
+
+	c, ok := i18n.GetCatalog(ix.Ctx)
+	if !ok {
+		return key
+	}
+
+	return c.Translate(key, args, ix.Locales()...)
+}
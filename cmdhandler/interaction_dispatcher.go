@@ -1,9 +1,16 @@
 package cmdhandler
 
 import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"github.com/gsmcwhirter/go-util/v10/errors"
 
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/i18n"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
 )
 
@@ -11,9 +18,70 @@ import (
 var ErrMalformedInteraction = errors.New("malformed interaction payload")
 
 // InteractionDispatcher is responsible for dispatching interaction requests to handlers
+//
+// Commands are keyed by type and name, so a chat input command and a user or message context command
+// may share a name
 type InteractionDispatcher struct {
-	globals map[string]InteractionCommandHandler
-	guilds  map[snowflake.Snowflake]map[string]InteractionCommandHandler
+	globals    map[string]InteractionCommandHandler
+	guilds     map[snowflake.Snowflake]map[string]InteractionCommandHandler
+	components customIDRoutes
+	modals     customIDRoutes
+	collectors collectors
+	catalog    *i18n.Catalog
+	middleware []Middleware
+}
+
+// customIDRoute sends interactions to a handler by custom_id prefix or pattern
+type customIDRoute struct {
+	prefix  string
+	pattern *regexp.Regexp
+	handler InteractionHandler
+}
+
+func (r customIDRoute) matches(customID string) bool {
+	if r.pattern != nil {
+		return r.pattern.MatchString(customID)
+	}
+
+	return strings.HasPrefix(customID, r.prefix)
+}
+
+// customIDRoutes is a routing table of prefix routes (longest first) followed by pattern routes (in the order learned)
+type customIDRoutes []customIDRoute
+
+func (rs *customIDRoutes) learnPrefix(prefix string, h InteractionHandler) {
+	*rs = append(*rs, customIDRoute{prefix: prefix, handler: h})
+
+	routes := *rs
+	sort.SliceStable(routes, func(a, b int) bool {
+		ra, rb := routes[a], routes[b]
+		if (ra.pattern == nil) != (rb.pattern == nil) {
+			return ra.pattern == nil
+		}
+
+		return len(ra.prefix) > len(rb.prefix)
+	})
+}
+
+func (rs *customIDRoutes) learnPattern(pattern string, h InteractionHandler) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.Wrap(err, "could not compile custom_id pattern", "pattern", pattern)
+	}
+
+	*rs = append(*rs, customIDRoute{pattern: re, handler: h})
+
+	return nil
+}
+
+func (rs customIDRoutes) route(ix *Interaction) (InteractionHandler, error) {
+	for _, r := range rs {
+		if r.matches(ix.Data.CustomID) {
+			return r.handler, nil
+		}
+	}
+
+	return nil, errors.Wrap(ErrMissingHandler, "no custom_id route", "custom_id", ix.Data.CustomID)
 }
 
 // InteractionCommandHandler is the interface for an interaction handler
@@ -78,7 +146,7 @@ func (i *InteractionDispatcher) GuildCommands() map[snowflake.Snowflake][]entity
 // LearnGlobalCommands inserts the povided global commands into the dispatch table
 func (i *InteractionDispatcher) LearnGlobalCommands(cmds []InteractionCommandHandler) error {
 	for _, ich := range cmds {
-		i.globals[ich.Command().Name] = ich
+		i.globals[handlerKey(ich.Command())] = ich
 	}
 
 	return nil
@@ -92,7 +160,7 @@ func (i *InteractionDispatcher) LearnGuildCommands(gid snowflake.Snowflake, cmds
 	}
 
 	for _, ich := range cmds {
-		gcmds[ich.Command().Name] = ich
+		gcmds[handlerKey(ich.Command())] = ich
 	}
 
 	i.guilds[gid] = gcmds
@@ -100,23 +168,126 @@ func (i *InteractionDispatcher) LearnGuildCommands(gid snowflake.Snowflake, cmds
 	return nil
 }
 
+// handlerKey is the dispatch table key of a command (commands without a type are chat input commands)
+func handlerKey(cmd entity.ApplicationCommand) string {
+	return commandKey(cmd.Type, cmd.Name)
+}
+
+func commandKey(t entity.ApplicationCommandType, name string) string {
+	if t == 0 {
+		t = entity.CmdTypeChatInput
+	}
+
+	return fmt.Sprintf("%d:%s", t, name)
+}
+
+// lookup finds the handler of an application command interaction, preferring guild commands to global ones
+func (i *InteractionDispatcher) lookup(ix *Interaction) (InteractionCommandHandler, bool) {
+	key := commandKey(ix.Data.Type, ix.Data.Name)
+
+	if handler, ok := i.guilds[ix.GuildID()][key]; ok {
+		return handler, true
+	}
+
+	handler, ok := i.globals[key]
+	return handler, ok
+}
+
+// LearnComponentHandler routes message component interactions whose custom_id starts with prefix to the handler
+//
+// When several prefixes match, the longest wins. Prefix routes are tried before pattern routes
+func (i *InteractionDispatcher) LearnComponentHandler(prefix string, h InteractionHandler) error {
+	i.components.learnPrefix(prefix, h)
+	return nil
+}
+
+// LearnComponentPattern routes message component interactions whose custom_id matches the regular
+// expression to the handler
+//
+// Pattern routes are tried in the order they were learned, after the prefix routes
+func (i *InteractionDispatcher) LearnComponentPattern(pattern string, h InteractionHandler) error {
+	return i.components.learnPattern(pattern, h)
+}
+
+// LearnModalHandler routes modal submit interactions whose custom_id starts with prefix to the handler
+//
+// Modal routes follow the same rules as component routes (see LearnComponentHandler)
+func (i *InteractionDispatcher) LearnModalHandler(prefix string, h InteractionHandler) error {
+	i.modals.learnPrefix(prefix, h)
+	return nil
+}
+
+// LearnModalPattern routes modal submit interactions whose custom_id matches the regular expression to the handler
+func (i *InteractionDispatcher) LearnModalPattern(pattern string, h InteractionHandler) error {
+	return i.modals.learnPattern(pattern, h)
+}
+
+// Use adds middleware around the handling of every dispatched interaction (commands, components and
+// modals, but not autocomplete requests or interactions taken by collectors); the first is the outermost
+func (i *InteractionDispatcher) Use(mw ...Middleware) {
+	i.middleware = append(i.middleware, mw...)
+}
+
+// SetCatalog sets the message catalog that dispatched interactions translate with (see Interaction.T)
+//
+// It is attached to the context of each interaction that does not already carry a catalog
+func (i *InteractionDispatcher) SetCatalog(c *i18n.Catalog) {
+	i.catalog = c
+}
+
+func (i *InteractionDispatcher) attachCatalog(ix *Interaction) {
+	if i.catalog == nil {
+		return
+	}
+
+	if ix.Ctx == nil {
+		ix.Ctx = context.Background()
+	}
+
+	if _, ok := i18n.GetCatalog(ix.Ctx); !ok {
+		ix.Ctx = i18n.WithCatalog(ix.Ctx, i.catalog)
+	}
+}
+
 // Dispatch sends the interaction to the appropriate dispatcher
+//
+// Message component and modal submit interactions are routed by their custom_id (see LearnComponentHandler
+// and LearnModalHandler), and others by their command type and name (a CommandTree then routes by
+// subcommand path). A component interaction taken by a collector (see Await and Collect) is not routed,
+// and Dispatch returns a nil Response for it
 func (i *InteractionDispatcher) Dispatch(ix *Interaction) (Response, []Response, error) {
 	if ix.Data == nil {
 		return nil, nil, errors.WithDetails(ErrMalformedInteraction, "reason", "nil Data")
 	}
 
-	handlers := i.guilds[ix.GuildID()]
-	handler, ok := handlers[ix.Data.Name]
-	if !ok {
-		handlers = i.globals
-		handler, ok = handlers[ix.Data.Name]
-		if !ok {
-			return nil, nil, ErrMissingHandler
+	i.attachCatalog(ix)
+
+	switch ix.Type {
+	case entity.InteractionMessageComponent:
+		if i.collectors.deliver(ix) {
+			// the code awaiting the collector responds to the interaction
+			return nil, nil, nil
 		}
+		return i.routeCustomID(ix, i.components)
+	case entity.InteractionModalSubmit:
+		return i.routeCustomID(ix, i.modals)
 	}
 
-	return handler.Handler().HandleInteraction(ix)
+	handler, ok := i.lookup(ix)
+	if !ok {
+		return nil, nil, ErrMissingHandler
+	}
+
+	return WithMiddleware(handler.Handler(), i.middleware...).HandleInteraction(ix)
+}
+
+func (i *InteractionDispatcher) routeCustomID(ix *Interaction, rs customIDRoutes) (Response, []Response, error) {
+	h, err := rs.route(ix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return WithMiddleware(h, i.middleware...).HandleInteraction(ix)
 }
 
 // Autocomplete returns autocomplete information for the command
@@ -125,14 +296,11 @@ func (i *InteractionDispatcher) Autocomplete(ix *Interaction) ([]entity.Applicat
 		return nil, errors.WithDetails(ErrMalformedInteraction, "reason", "nil Data")
 	}
 
-	handlers := i.guilds[ix.GuildID()]
-	handler, ok := handlers[ix.Data.Name]
-	if !ok {
-		handlers = i.globals
-		handler, ok = handlers[ix.Data.Name]
-		if !ok {
-			return nil, ErrMissingHandler
-		}
+	i.attachCatalog(ix)
+
+	handler, ok := i.lookup(ix)
+	if !ok || handler.AutocompleteHandler() == nil {
+		return nil, ErrMissingHandler
 	}
 
 	return handler.AutocompleteHandler().Autocomplete(ix)
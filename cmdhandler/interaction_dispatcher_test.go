@@ -0,0 +1,158 @@
+package cmdhandler
+
+import (
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/i18n"
+)
+
+func namedHandler(name string) InteractionHandler {
+	return NewInteractionHandler(func(*Interaction) (Response, []Response, error) {
+		return &SimpleResponse{Content: name}, nil, nil
+	})
+}
+
+func TestInteractionDispatcher_dispatchComponent(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	if err := ixd.LearnComponentHandler("poll:", namedHandler("poll")); err != nil {
+		t.Fatalf("LearnComponentHandler() error = %v", err)
+	}
+
+	if err := ixd.LearnComponentPattern(`^signup:\d+$`, namedHandler("signup")); err != nil {
+		t.Fatalf("LearnComponentPattern() error = %v", err)
+	}
+
+	if err := ixd.LearnComponentHandler("poll:close:", namedHandler("poll-close")); err != nil {
+		t.Fatalf("LearnComponentHandler() error = %v", err)
+	}
+
+	if err := ixd.LearnComponentPattern(`(`, namedHandler("bad")); err == nil {
+		t.Errorf("LearnComponentPattern() expected an error for a bad pattern")
+	}
+
+	tests := []struct {
+		name     string
+		customID string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "prefix",
+			customID: "poll:123:yes",
+			want:     "poll",
+		},
+		{
+			name:     "longest prefix",
+			customID: "poll:close:123",
+			want:     "poll-close",
+		},
+		{
+			name:     "pattern",
+			customID: "signup:42",
+			want:     "signup",
+		},
+		{
+			name:     "no route",
+			customID: "signup:abc",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ix := &Interaction{
+				Interaction: entity.Interaction{
+					Type: entity.InteractionMessageComponent,
+					Data: &entity.InteractionData{
+						CustomID:      tt.customID,
+						ComponentType: entity.ComponentButton,
+					},
+				},
+			}
+
+			got, _, err := ixd.Dispatch(ix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InteractionDispatcher.Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissingHandler) {
+					t.Errorf("InteractionDispatcher.Dispatch() error = %v, want ErrMissingHandler", err)
+				}
+				return
+			}
+
+			if sr, ok := got.(*SimpleResponse); !ok || sr.Content != tt.want {
+				t.Errorf("InteractionDispatcher.Dispatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInteractionDispatcher_SetCatalog(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	catalog := i18n.NewCatalog("en-US")
+	catalog.Add("en-US", map[string]string{"ping.pong": "Pong, {name}!"})
+	catalog.Add("fr", map[string]string{"ping.pong": "Pong, {name} !"})
+	ixd.SetCatalog(catalog)
+
+	if err := ixd.LearnComponentHandler("ping", NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+		return &SimpleResponse{Content: ix.T("ping.pong", i18n.Args{"name": "Ann"})}, nil, nil
+	})); err != nil {
+		t.Fatalf("LearnComponentHandler() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		locale      string
+		guildLocale string
+		want        string
+	}{
+		{name: "user locale", locale: "fr", guildLocale: "de", want: "Pong, Ann !"},
+		{name: "guild locale", guildLocale: "fr", want: "Pong, Ann !"},
+		{name: "default locale", locale: "de", want: "Pong, Ann!"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ix := &Interaction{
+				Interaction: entity.Interaction{
+					Type:        entity.InteractionMessageComponent,
+					Data:        &entity.InteractionData{CustomID: "ping"},
+					Locale:      tt.locale,
+					GuildLocale: tt.guildLocale,
+				},
+			}
+
+			r, _, err := ixd.Dispatch(ix)
+			if err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+
+			if got := r.(*SimpleResponse).Content; got != tt.want {
+				t.Errorf("Dispatch() content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
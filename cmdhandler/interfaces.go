@@ -1,7 +1,12 @@
 package cmdhandler
 
+import (
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+)
+
 // JSONMarshaler is the interface implemented by types that
 // can marshal themselves into valid JSON.
-type JSONMarshaler interface {
-	MarshalToJSON() ([]byte, error) // yes, this is intentionally different than stdlib
-}
+//
+// It is an alias of jsonapi.JSONMarshaler so that any Response can be sent through the
+// interaction webhook endpoints of jsonapi.DiscordJSONClient
+type JSONMarshaler = jsonapi.JSONMarshaler
@@ -0,0 +1,375 @@
+package cmdhandler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/errreport"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
+)
+
+// ErrHandlerPanic is returned (and reported) by the Recover middleware when a handler panics
+var ErrHandlerPanic = errors.New("handler panicked")
+
+// ErrHandlerTimeout is returned by the Timeout middleware when a handler does not finish in time
+var ErrHandlerTimeout = errors.New("handler timed out")
+
+// Logger is the interface expected for logging
+type Logger = interface {
+	Log(keyvals ...interface{}) error
+	Message(string, ...interface{})
+	Err(string, error, ...interface{})
+	Printf(string, ...interface{})
+}
+
+// Middleware wraps an InteractionHandler, to run code around it or instead of it
+//
+// Middleware can be attached to every interaction (InteractionDispatcher.Use), to the subcommands of a
+// CommandTree (CommandTree.Use), or to a single handler (WithMiddleware and WithCommandMiddleware). It does
+// not apply to autocomplete requests
+type Middleware func(InteractionHandler) InteractionHandler
+
+// MessageMiddleware wraps a MessageHandler, to run code around it or instead of it
+//
+// Message middleware can be attached to the commands of a CommandHandler (CommandHandler.Use; a nested
+// CommandHandler is a command group), or to a single handler (WithMessageMiddleware)
+type MessageMiddleware func(MessageHandler) MessageHandler
+
+// Chain composes middleware into one; the first is the outermost
+func Chain(mw ...Middleware) Middleware {
+	return func(h InteractionHandler) InteractionHandler {
+		return WithMiddleware(h, mw...)
+	}
+}
+
+// ChainMessage composes message middleware into one; the first is the outermost
+func ChainMessage(mw ...MessageMiddleware) MessageMiddleware {
+	return func(h MessageHandler) MessageHandler {
+		return WithMessageMiddleware(h, mw...)
+	}
+}
+
+// WithMiddleware wraps a handler in middleware; the first is the outermost
+func WithMiddleware(h InteractionHandler, mw ...Middleware) InteractionHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return h
+}
+
+// WithMessageMiddleware wraps a handler in message middleware; the first is the outermost
+func WithMessageMiddleware(h MessageHandler, mw ...MessageMiddleware) MessageHandler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return h
+}
+
+// WithCommandMiddleware wraps the handler of a command in middleware, leaving its definition and
+// autocomplete handler as they are
+func WithCommandMiddleware(ich InteractionCommandHandler, mw ...Middleware) InteractionCommandHandler {
+	return &interactionCommandHandler{
+		command:      ich.Command(),
+		handler:      WithMiddleware(ich.Handler(), mw...),
+		autocomplete: ich.AutocompleteHandler(),
+	}
+}
+
+// handlerName names what an interaction invoked, for logs and metrics
+func handlerName(ix *Interaction) string {
+	if id := ix.CustomID(); id != "" {
+		return id
+	}
+
+	return ix.CommandPath()
+}
+
+// metricName names what an interaction invoked, for metric tags; custom_ids often embed ids, so component
+// and modal interactions are only named by their type
+func metricName(ix *Interaction) string {
+	switch ix.Type {
+	case entity.InteractionMessageComponent:
+		return "component"
+	case entity.InteractionModalSubmit:
+		return "modal"
+	default:
+		return ix.CommandPath()
+	}
+}
+
+// MessageCommand returns the command a CommandHandler dispatched the message to, like "event create" for
+// nested command handlers
+func MessageCommand(msg Message) string {
+	if msg.Context() == nil {
+		return ""
+	}
+
+	cmd, _ := msg.Context().Value(ContextKey("command")).(string)
+	return cmd
+}
+
+// ContextKey is a wrapper type for our keys attached to a context
+type ContextKey string
+
+func withMessageCommand(msg Message, cmd string) Message {
+	ctx := msg.Context()
+	if ctx == nil {
+		return msg
+	}
+
+	cmd = strings.TrimSpace(MessageCommand(msg) + " " + cmd)
+
+	return NewWithContext(context.WithValue(ctx, ContextKey("command"), cmd), msg)
+}
+
+// handlerPanic carries a panic to another goroutine, along with the stack where it happened
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// capturePanic records the stack of a panic that is about to be passed to another goroutine
+func capturePanic(p interface{}) handlerPanic {
+	if hp, ok := p.(handlerPanic); ok {
+		return hp
+	}
+
+	return handlerPanic{value: p, stack: debug.Stack()}
+}
+
+// panicError describes a recovered panic, with the stack of the goroutine that panicked
+func panicError(p interface{}) error {
+	hp, ok := p.(handlerPanic)
+	if !ok {
+		hp = handlerPanic{value: p, stack: debug.Stack()}
+	}
+
+	msg := fmt.Sprint(hp.value)
+	if err, ok := hp.value.(error); ok {
+		msg = err.Error()
+	}
+
+	return errors.Wrap(ErrHandlerPanic, msg, "stack", string(hp.stack))
+}
+
+// Recover turns a panic in the handler into an ErrHandlerPanic error (with the stack as a detail), and reports it
+func Recover(rep errreport.Reporter) Middleware {
+	return func(next InteractionHandler) InteractionHandler {
+		return NewInteractionHandler(func(ix *Interaction) (r Response, rs []Response, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = errors.WithDetails(panicError(p), "interaction", handlerName(ix))
+					rep.Notify(ix.Context(), err)
+				}
+			}()
+
+			return next.HandleInteraction(ix)
+		})
+	}
+}
+
+// RecoverMessages turns a panic in the handler into an ErrHandlerPanic error, and reports it
+func RecoverMessages(rep errreport.Reporter) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return NewMessageHandler(func(msg Message) (r Response, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = errors.WithDetails(panicError(p), "command", MessageCommand(msg))
+					rep.Notify(msg.Context(), err)
+				}
+			}()
+
+			return next.HandleMessage(msg)
+		})
+	}
+}
+
+// Logging logs each handled interaction, with its ids, what it invoked, how long it took and any error
+func Logging(logger Logger) Middleware {
+	return func(next InteractionHandler) InteractionHandler {
+		return NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			start := time.Now()
+			r, rs, err := next.HandleInteraction(ix)
+
+			l := logging.WithMessage(ix, logger)
+			if err != nil {
+				level.Error(l).Err("interaction handler failed", err, "interaction", handlerName(ix), "elapsed", time.Since(start).String())
+			} else {
+				level.Info(l).Message("interaction handled", "interaction", handlerName(ix), "elapsed", time.Since(start).String())
+			}
+
+			return r, rs, err
+		})
+	}
+}
+
+// LoggingMessages logs each handled message, with its ids, its command, how long it took and any error
+func LoggingMessages(logger Logger) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return NewMessageHandler(func(msg Message) (Response, error) {
+			start := time.Now()
+			r, err := next.HandleMessage(msg)
+
+			l := logging.WithMessage(msg, logger)
+			if err != nil {
+				level.Error(l).Err("message handler failed", err, "command", MessageCommand(msg), "elapsed", time.Since(start).String())
+			} else {
+				level.Info(l).Message("message handled", "command", MessageCommand(msg), "elapsed", time.Since(start).String())
+			}
+
+			return r, err
+		})
+	}
+}
+
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+
+	return ctx
+}
+
+func statusTag(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
+
+// Metrics records the latency of each handled interaction (stats.HandlerLatency, in milliseconds), tagged
+// with what it invoked and whether it failed
+func Metrics(t *telemetry.Telemeter) Middleware {
+	return func(next InteractionHandler) InteractionHandler {
+		return NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			start := time.Now()
+			r, rs, err := next.HandleInteraction(ix)
+
+			_ = stats.RecordValue(orBackground(ix.Context()), t, "cmdhandler", stats.HandlerLatency, float64(time.Since(start))/float64(time.Millisecond),
+				telemetry.KVString(stats.TagCommand, metricName(ix)), telemetry.KVString(stats.TagStatus, statusTag(err)))
+
+			return r, rs, err
+		})
+	}
+}
+
+// MetricsMessages records the latency of each handled message (see Metrics)
+func MetricsMessages(t *telemetry.Telemeter) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return NewMessageHandler(func(msg Message) (Response, error) {
+			start := time.Now()
+			r, err := next.HandleMessage(msg)
+
+			_ = stats.RecordValue(orBackground(msg.Context()), t, "cmdhandler", stats.HandlerLatency, float64(time.Since(start))/float64(time.Millisecond),
+				telemetry.KVString(stats.TagCommand, MessageCommand(msg)), telemetry.KVString(stats.TagStatus, statusTag(err)))
+
+			return r, err
+		})
+	}
+}
+
+// timeoutError reports why a Timeout middleware stopped waiting: its own deadline, or the end of the parent context
+func timeoutError(ctx context.Context, d time.Duration, kind, name string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.Wrap(ErrHandlerTimeout, "handler did not finish", kind, name, "timeout", d.String())
+	}
+
+	return errors.Wrap(ctx.Err(), "handler context ended", kind, name)
+}
+
+// Timeout gives the handler a context that expires after d, and returns ErrHandlerTimeout if it has not
+// finished by then
+//
+// The handler keeps running in the background after a timeout; it should stop when its context is done.
+// Discord requires a response to an interaction within 3 seconds
+func Timeout(d time.Duration) Middleware {
+	type result struct {
+		r     Response
+		rs    []Response
+		err   error
+		panic interface{}
+	}
+
+	return func(next InteractionHandler) InteractionHandler {
+		return NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			ctx, cancel := context.WithTimeout(orBackground(ix.Context()), d)
+			defer cancel()
+
+			ix2 := *ix
+			ix2.Ctx = ctx
+
+			done := make(chan result, 1)
+			go func() {
+				// pass panics back, so that middleware outside of this one can recover them
+				defer func() {
+					if p := recover(); p != nil {
+						done <- result{panic: capturePanic(p)}
+					}
+				}()
+
+				r, rs, err := next.HandleInteraction(&ix2)
+				done <- result{r: r, rs: rs, err: err}
+			}()
+
+			select {
+			case res := <-done:
+				if res.panic != nil {
+					panic(res.panic)
+				}
+				return res.r, res.rs, res.err
+			case <-ctx.Done():
+				return nil, nil, timeoutError(ctx, d, "interaction", handlerName(ix))
+			}
+		})
+	}
+}
+
+// TimeoutMessages gives the handler a context that expires after d (see Timeout)
+func TimeoutMessages(d time.Duration) MessageMiddleware {
+	type result struct {
+		r     Response
+		err   error
+		panic interface{}
+	}
+
+	return func(next MessageHandler) MessageHandler {
+		return NewMessageHandler(func(msg Message) (Response, error) {
+			ctx, cancel := context.WithTimeout(orBackground(msg.Context()), d)
+			defer cancel()
+
+			done := make(chan result, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						done <- result{panic: capturePanic(p)}
+					}
+				}()
+
+				r, err := next.HandleMessage(NewWithContext(ctx, msg))
+				done <- result{r: r, err: err}
+			}()
+
+			select {
+			case res := <-done:
+				if res.panic != nil {
+					panic(res.panic)
+				}
+				return res.r, res.err
+			case <-ctx.Done():
+				return nil, timeoutError(ctx, d, "command", MessageCommand(msg))
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package cmdhandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/parser"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+// wrapIn is a middleware that wraps the content of the response in its name, like "name(content)"
+func wrapIn(name string) Middleware {
+	return func(next InteractionHandler) InteractionHandler {
+		return NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+			r, rs, err := next.HandleInteraction(ix)
+			if sr, ok := r.(*SimpleResponse); ok {
+				sr.Content = name + "(" + sr.Content + ")"
+			}
+			return r, rs, err
+		})
+	}
+}
+
+func wrapMessageIn(name string) MessageMiddleware {
+	return func(next MessageHandler) MessageHandler {
+		return NewMessageHandler(func(msg Message) (Response, error) {
+			r, err := next.HandleMessage(msg)
+			if sr, ok := r.(*SimpleResponse); ok {
+				sr.Content = name + "(" + sr.Content + ")"
+			}
+			return r, err
+		})
+	}
+}
+
+type recordingReporter struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *recordingReporter) AutoNotify(context.Context) {}
+func (r *recordingReporter) Recover(context.Context)    {}
+func (r *recordingReporter) Notify(_ context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func content(t *testing.T, r Response) string {
+	t.Helper()
+
+	sr, ok := r.(*SimpleResponse)
+	if !ok {
+		t.Fatalf("response = %#v, want a *SimpleResponse", r)
+	}
+	return sr.Content
+}
+
+func TestInteractionDispatcher_Use(t *testing.T) {
+	t.Parallel()
+
+	tree := newAdminTree(t)
+	tree.Use(wrapIn("tree"))
+	if err := tree.UseGroup("admin roles", wrapIn("roles")); err != nil {
+		t.Fatalf("UseGroup() error = %v", err)
+	}
+
+	if err := tree.UseGroup("admin status", wrapIn("bad")); err == nil {
+		t.Errorf("UseGroup() expected an error for a subcommand path")
+	}
+
+	ping := WithCommandMiddleware(&interactionCommandHandler{
+		command: entity.ApplicationCommand{Name: "ping", Description: "Ping"},
+		handler: namedHandler("pong"),
+	}, wrapIn("cmd"))
+
+	ixd, err := NewInteractionDispatcher([]InteractionCommandHandler{tree, ping})
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	ixd.Use(Chain(wrapIn("outer"), wrapIn("inner")))
+
+	if err := ixd.LearnComponentHandler("poll:", namedHandler("poll")); err != nil {
+		t.Fatalf("LearnComponentHandler() error = %v", err)
+	}
+
+	sub := func(name string) entity.ApplicationCommandInteractionOption {
+		return entity.ApplicationCommandInteractionOption{Name: name, Type: entity.OptTypeSubCommand}
+	}
+
+	tests := []struct {
+		name string
+		ix   entity.Interaction
+		want string
+	}{
+		{
+			name: "command",
+			ix:   entity.Interaction{Type: entity.InteractionApplicationCommand, Data: &entity.InteractionData{Name: "ping"}},
+			want: "outer(inner(cmd(pong)))",
+		},
+		{
+			name: "subcommand",
+			ix: entity.Interaction{Type: entity.InteractionApplicationCommand, Data: &entity.InteractionData{
+				Name: "admin", Options: []entity.ApplicationCommandInteractionOption{sub("status")},
+			}},
+			want: "outer(inner(tree(admin status:)))",
+		},
+		{
+			name: "subcommand in a group",
+			ix: entity.Interaction{Type: entity.InteractionApplicationCommand, Data: &entity.InteractionData{
+				Name: "admin", Options: []entity.ApplicationCommandInteractionOption{{
+					Name: "roles", Type: entity.OptTypeSubCommandGroup, Options: []entity.ApplicationCommandInteractionOption{sub("add")},
+				}},
+			}},
+			want: "outer(inner(tree(roles(admin roles add:))))",
+		},
+		{
+			name: "component",
+			ix:   entity.Interaction{Type: entity.InteractionMessageComponent, Data: &entity.InteractionData{CustomID: "poll:1"}},
+			want: "outer(inner(poll))",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, _, err := ixd.Dispatch(&Interaction{Interaction: tt.ix, Ctx: context.Background()})
+			if err != nil {
+				t.Fatalf("Dispatch() error = %v", err)
+			}
+
+			if got := content(t, r); got != tt.want {
+				t.Errorf("Dispatch() content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandHandler_Use(t *testing.T) {
+	t.Parallel()
+
+	newParser := func(indicator string, cmds ...string) parser.Parser {
+		return parser.NewParser(parser.Options{CmdIndicator: indicator, KnownCommands: cmds})
+	}
+
+	event := mustNewCommandHandler(newParser("", "create"), Options{})
+	event.Use(wrapMessageIn("group"))
+	event.SetHandler("create", NewMessageHandler(func(msg Message) (Response, error) {
+		return &SimpleResponse{Content: MessageCommand(msg) + ":" + strings.Join(msg.Contents(), " ")}, nil
+	}))
+
+	root := mustNewCommandHandler(newParser("!", "event"), Options{})
+	root.Use(wrapMessageIn("root"))
+	root.SetHandler("event", WithMessageMiddleware(event, wrapMessageIn("cmd")))
+
+	r, err := root.HandleMessage(NewSimpleMessage(context.Background(), 1, 2, 3, 4, "!event create raid"))
+	if err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	if got, want := content(t, r), "root(cmd(group(event create:raid)))"; got != want {
+		t.Errorf("HandleMessage() content = %q, want %q", got, want)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	rep := &recordingReporter{}
+	h := WithMiddleware(NewInteractionHandler(func(*Interaction) (Response, []Response, error) {
+		panic("boom")
+	}), Recover(rep))
+
+	_, _, err := h.HandleInteraction(&Interaction{Ctx: context.Background()})
+	if !errors.Is(err, ErrHandlerPanic) || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("HandleInteraction() error = %v, want a panic error", err)
+	}
+
+	if len(rep.errs) != 1 || !errors.Is(rep.errs[0], ErrHandlerPanic) {
+		t.Errorf("reported errors = %v", rep.errs)
+	}
+
+	if stack := errDetail(err, "stack"); !strings.Contains(stack, "TestRecover.func1") {
+		t.Errorf("HandleInteraction() error stack = %q, want the panicking handler", stack)
+	}
+
+	mh := WithMessageMiddleware(NewMessageHandler(func(Message) (Response, error) {
+		panic(errors.New("message boom"))
+	}), RecoverMessages(rep))
+
+	msg := NewWithContext(context.WithValue(context.Background(), ContextKey("command"), "event create"), NewSimpleMessage(context.Background(), 1, 2, 3, 4, ""))
+	_, err = mh.HandleMessage(msg)
+	if !errors.Is(err, ErrHandlerPanic) {
+		t.Errorf("HandleMessage() error = %v, want a panic error", err)
+	}
+
+	if got := errDetail(err, "command"); got != "event create" {
+		t.Errorf("HandleMessage() error command = %q, want %q", got, "event create")
+	}
+}
+
+// errDetail finds the value of a detail of an error
+func errDetail(err error, key string) string {
+	d, ok := err.(interface{ Data() []interface{} })
+	if !ok {
+		return ""
+	}
+
+	data := d.Data()
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == key {
+			return fmt.Sprint(data[i+1])
+		}
+	}
+
+	return ""
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	slow := NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+		select {
+		case <-ix.Context().Done():
+			return nil, nil, ix.Context().Err()
+		case <-time.After(time.Second):
+			return &SimpleResponse{Content: "slow"}, nil, nil
+		}
+	})
+
+	start := time.Now()
+	_, _, err := WithMiddleware(slow, Timeout(10*time.Millisecond)).HandleInteraction(&Interaction{Ctx: context.Background()})
+	if !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("HandleInteraction() error = %v, want a timeout", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("HandleInteraction() took %v", elapsed)
+	}
+
+	r, _, err := WithMiddleware(namedHandler("fast"), Timeout(time.Second)).HandleInteraction(&Interaction{Ctx: context.Background()})
+	if err != nil || content(t, r) != "fast" {
+		t.Errorf("HandleInteraction() = %v, %v", r, err)
+	}
+
+	// panics cross the timeout goroutine, so that Recover outside of Timeout still sees them
+	rep := &recordingReporter{}
+	panicky := NewInteractionHandler(func(*Interaction) (Response, []Response, error) { panic("boom") })
+	_, _, err = WithMiddleware(panicky, Recover(rep), Timeout(time.Second)).HandleInteraction(&Interaction{Ctx: context.Background()})
+	if !errors.Is(err, ErrHandlerPanic) {
+		t.Errorf("HandleInteraction() error = %v, want a panic error", err)
+	}
+
+	// the stack is that of the handler, not of the re-panic
+	if stack := errDetail(err, "stack"); !strings.Contains(stack, "TestTimeout.func") || strings.Contains(stack, "Recover.func") {
+		t.Errorf("HandleInteraction() error stack = %q, want the panicking handler", stack)
+	}
+
+	slowMsg := NewMessageHandler(func(msg Message) (Response, error) {
+		<-msg.Context().Done()
+		return nil, msg.Context().Err()
+	})
+
+	if _, err := WithMessageMiddleware(slowMsg, TimeoutMessages(10*time.Millisecond)).HandleMessage(NewSimpleMessage(context.Background(), 1, 2, 3, 4, "")); !errors.Is(err, ErrHandlerTimeout) {
+		t.Errorf("HandleMessage() error = %v, want a timeout", err)
+	}
+}
+
+type capturingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *capturingLogger) Log(keyvals ...interface{}) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	parts := make([]string, 0, len(keyvals))
+	for _, kv := range keyvals {
+		parts = append(parts, fmt.Sprint(kv))
+	}
+	l.lines = append(l.lines, strings.Join(parts, " "))
+	return nil
+}
+
+func (l *capturingLogger) Message(msg string, keyvals ...interface{}) {
+	_ = l.Log(append([]interface{}{"msg", msg}, keyvals...)...)
+}
+
+func (l *capturingLogger) Err(msg string, err error, keyvals ...interface{}) {
+	_ = l.Log(append([]interface{}{"msg", msg, "err", err}, keyvals...)...)
+}
+
+func (l *capturingLogger) Printf(f string, args ...interface{}) {
+	_ = l.Log("msg", fmt.Sprintf(f, args...))
+}
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+
+	logger := &capturingLogger{}
+	ix := &Interaction{
+		Interaction: entity.Interaction{Type: entity.InteractionApplicationCommand, Data: &entity.InteractionData{Name: "ping"}},
+		Ctx:         context.Background(),
+	}
+
+	if _, _, err := WithMiddleware(namedHandler("pong"), Logging(logger)).HandleInteraction(ix); err != nil {
+		t.Fatalf("HandleInteraction() error = %v", err)
+	}
+
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "interaction handled") || !strings.Contains(logger.lines[0], "interaction ping") {
+		t.Errorf("logged %q", logger.lines)
+	}
+}
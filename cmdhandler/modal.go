@@ -0,0 +1,169 @@
+package cmdhandler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrBadModalTarget is the error returned when BindModal is given something other than a pointer to a struct
+var ErrBadModalTarget = errors.New("modal values must be bound to a pointer to a struct")
+
+// ErrNotModalSubmit is the error returned when BindModal is given an interaction that is not a modal submission
+var ErrNotModalSubmit = errors.New("interaction is not a modal submission")
+
+// ModalResponse is a Response that opens a modal dialog instead of sending a message
+//
+// It can be returned from a slash command or component handler. Its ToMessage is a jsonapi.Modal,
+// which jsonapi.DiscordJSONClient.SendInteractionMessage sends with the modal callback type.
+// The submission is routed by CustomID (see InteractionDispatcher.LearnModalHandler), and
+// its values can be read with BindModal
+type ModalResponse struct {
+	CustomID string
+	Title    string
+	Inputs   []entity.Component
+
+	errors []error
+}
+
+// ensure that ModalResponse is a Response
+var _ Response = (*ModalResponse)(nil)
+
+// SetColor is included for the Response API but is a no-op
+func (r *ModalResponse) SetColor(color int) {}
+
+// SetEphemeral is included for the Response API but is a no-op
+func (r *ModalResponse) SetEphemeral(e bool) {}
+
+// GetColor is included for the Response API but always returns 0
+func (r *ModalResponse) GetColor() int { return 0 }
+
+// IncludeError adds an error into the response
+func (r *ModalResponse) IncludeError(err error) {
+	if err == nil {
+		return
+	}
+
+	r.errors = append(r.errors, err)
+}
+
+// HasErrors returns whether or not the response includes errors
+func (r *ModalResponse) HasErrors() bool {
+	return len(r.errors) > 0
+}
+
+// ToString generates a plain-text representation of the response
+func (r *ModalResponse) ToString() string {
+	b := strings.Builder{}
+
+	_, _ = b.WriteString(fmt.Sprintf("__**%s**__\n", r.Title))
+
+	for _, in := range r.Inputs {
+		_, _ = b.WriteString(fmt.Sprintf("%s: ...\n", in.Label))
+	}
+
+	for _, err := range r.errors {
+		_, _ = b.WriteString(fmt.Sprintf("\nError: %v", err))
+	}
+
+	return b.String()
+}
+
+// ToMessage generates the jsonapi.Modal that opens the dialog
+func (r *ModalResponse) ToMessage() JSONMarshaler {
+	return jsonapi.Modal{
+		CustomID:   r.CustomID,
+		Title:      r.Title,
+		Components: r.Inputs,
+	}
+}
+
+// Channel is included for the Response API but always returns 0
+func (r *ModalResponse) Channel() snowflake.Snowflake { return 0 }
+
+// Split returns the response unchanged, since a modal cannot be split
+func (r *ModalResponse) Split() []Response {
+	return []Response{r}
+}
+
+// MessageReactions is included for the Response API but always returns nil
+func (r *ModalResponse) MessageReactions() []string { return nil }
+
+// BindModal fills the struct pointed to by dst with the values submitted in a modal
+//
+// Fields are matched to text inputs with a `modal:"custom_id"` tag; untagged fields and inputs
+// that were left empty are skipped. Tagged fields may be strings, integers, floats or bools
+func BindModal(ix *Interaction, dst interface{}) error {
+	if ix.Type != entity.InteractionModalSubmit || ix.Data == nil {
+		return ErrNotModalSubmit
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrBadModalTarget
+	}
+	rv = rv.Elem()
+
+	vals := ix.Data.SubmittedValues()
+
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		id, ok := sf.Tag.Lookup("modal")
+		if !ok || id == "" || !sf.IsExported() {
+			continue
+		}
+
+		v, ok := vals[id]
+		if !ok || v == "" {
+			continue
+		}
+
+		if err := setModalField(rv.Field(i), v); err != nil {
+			return errors.Wrap(err, "could not bind modal value", "custom_id", id, "field", sf.Name)
+		}
+	}
+
+	return nil
+}
+
+func setModalField(f reflect.Value, v string) error {
+	switch f.Kind() { //nolint:exhaustive // only these kinds are supported
+	case reflect.String:
+		f.SetString(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	default:
+		return errors.Wrap(ErrBadModalTarget, "unsupported field kind", "kind", f.Kind().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package cmdhandler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+func modalSubmit(vals map[string]string) *Interaction {
+	rows := make([]entity.Component, 0, len(vals))
+	for id, v := range vals {
+		in := entity.TextInput(id, id, entity.TextInputShort)
+		in.Value = v
+		rows = append(rows, entity.ActionRow(in))
+	}
+
+	return &Interaction{
+		Interaction: entity.Interaction{
+			Type: entity.InteractionModalSubmit,
+			Data: &entity.InteractionData{
+				CustomID:   "signup",
+				Components: rows,
+			},
+		},
+	}
+}
+
+func TestBindModal(t *testing.T) {
+	t.Parallel()
+
+	type signup struct {
+		Name    string `modal:"name"`
+		Slots   int    `modal:"slots"`
+		Notes   string `modal:"notes"`
+		Ignored string
+	}
+
+	tests := []struct {
+		name    string
+		ix      *Interaction
+		dst     interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "values bound",
+			ix:   modalSubmit(map[string]string{"name": "Raid", "slots": " 8 ", "notes": ""}),
+			dst:  &signup{Notes: "keep"},
+			want: &signup{Name: "Raid", Slots: 8, Notes: "keep"},
+		},
+		{
+			name:    "bad int",
+			ix:      modalSubmit(map[string]string{"slots": "eight"}),
+			dst:     &signup{},
+			wantErr: true,
+		},
+		{
+			name:    "not a pointer",
+			ix:      modalSubmit(nil),
+			dst:     signup{},
+			wantErr: true,
+		},
+		{
+			name: "not a modal submission",
+			ix: &Interaction{Interaction: entity.Interaction{
+				Type: entity.InteractionMessageComponent,
+				Data: &entity.InteractionData{},
+			}},
+			dst:     &signup{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := BindModal(tt.ix, tt.dst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BindModal() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(tt.dst, tt.want) {
+				t.Errorf("BindModal() = %+v, want %+v", tt.dst, tt.want)
+			}
+		})
+	}
+}
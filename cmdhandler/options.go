@@ -0,0 +1,632 @@
+package cmdhandler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrBadOptionsTarget is the error returned when command options are described by something other than a struct
+// (or bound to something other than a pointer to a struct)
+var ErrBadOptionsTarget = errors.New("command options must be described by a pointer to a struct")
+
+// ErrBadOptionTag is the error returned when an option struct field has an invalid tag or type
+var ErrBadOptionTag = errors.New("bad option tag")
+
+var (
+	userType        = reflect.TypeOf(entity.User{})
+	guildMemberType = reflect.TypeOf(entity.GuildMember{})
+	roleType        = reflect.TypeOf(entity.Role{})
+	channelType     = reflect.TypeOf(entity.Channel{})
+	snowflakeType   = reflect.TypeOf(snowflake.Snowflake(0))
+)
+
+// OptionError describes an option value that failed validation
+type OptionError struct {
+	Option string
+	Reason string
+}
+
+func (e OptionError) String() string {
+	return fmt.Sprintf("`%s` %s", e.Option, e.Reason)
+}
+
+// ValidationError is the error returned by BindOptions when option values fail validation
+//
+// Its Response explains the problems to the user who ran the command
+type ValidationError struct {
+	Errors []OptionError
+}
+
+func (e *ValidationError) add(option, reason string, args ...interface{}) {
+	e.Errors = append(e.Errors, OptionError{Option: option, Reason: fmt.Sprintf(reason, args...)})
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, oe := range e.Errors {
+		parts = append(parts, oe.String())
+	}
+
+	return "invalid options: " + strings.Join(parts, "; ")
+}
+
+// Response creates an ephemeral response listing the problems
+func (e *ValidationError) Response() Response {
+	b := strings.Builder{}
+	_, _ = b.WriteString("Some of the options were not valid:\n")
+	for _, oe := range e.Errors {
+		_, _ = b.WriteString(fmt.Sprintf("- %s\n", oe.String()))
+	}
+
+	return &SimpleResponse{
+		Content:   b.String(),
+		Ephemeral: true,
+	}
+}
+
+// ValidationResponse returns the user-facing response for a validation error from BindOptions
+//
+// It reports false if err is not (and does not wrap) a *ValidationError
+func ValidationResponse(err error) (Response, bool) {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil, false
+	}
+
+	return verr.Response(), true
+}
+
+// optionField is a struct field that holds a command option
+type optionField struct {
+	index   int
+	pointer bool
+	target  reflect.Type
+	opt     entity.ApplicationCommandOption
+}
+
+// CommandFromStruct creates a chat input command whose options are described by the struct v (or a pointer to it)
+//
+// Each option is a field with an `option` tag and a `description` tag:
+//
+//	type RollOptions struct {
+//		Dice  int            `option:"dice,required,min=1,max=20" description:"How many dice to roll"`
+//		Sides int            `option:"sides" choices:"d6=6|d20=20" description:"The kind of dice"`
+//		Where entity.Channel `option:"where,channel_types=0|5" description:"Where to post the result"`
+//	}
+//
+// The `option` tag holds the option name (the lower-cased field name if left empty) followed by any of
+// required, autocomplete, min=N, max=N (integer and number options), min_length=N, max_length=N (string
+// options), channel_types=A|B (channel options) and type=user|role|channel (snowflake.Snowflake fields).
+// The `choices` tag lists the allowed values, each as either value or name=value.
+//
+// The option type follows the field type: strings, integers, floats and bools, entity.User and
+// entity.GuildMember (user options), entity.Role, entity.Channel, or a snowflake.Snowflake with an
+// explicit type. Fields may also be pointers to these, which are left nil when the option is not given.
+// Required options are listed before the others, as Discord requires
+func CommandFromStruct(name, description string, v interface{}) (entity.ApplicationCommand, error) {
+	opts, err := OptionsFromStruct(v)
+	if err != nil {
+		return entity.ApplicationCommand{}, errors.Wrap(err, "could not describe options", "command", name)
+	}
+
+	return entity.ApplicationCommand{
+		Type:              entity.CmdTypeChatInput,
+		Name:              name,
+		Description:       description,
+		Options:           opts,
+		DefaultPermission: true,
+	}, nil
+}
+
+// OptionsFromStruct creates the command options described by the struct v (see CommandFromStruct)
+func OptionsFromStruct(v interface{}) ([]entity.ApplicationCommandOption, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrBadOptionsTarget
+	}
+
+	fields, err := optionFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := make([]entity.ApplicationCommandOption, 0, len(fields))
+	for _, f := range fields {
+		if f.opt.Required {
+			opts = append(opts, f.opt)
+		}
+	}
+	for _, f := range fields {
+		if !f.opt.Required {
+			opts = append(opts, f.opt)
+		}
+	}
+
+	return opts, nil
+}
+
+func optionFields(t reflect.Type) ([]optionField, error) {
+	fields := make([]optionField, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("option")
+		if !ok {
+			continue
+		}
+
+		if !sf.IsExported() {
+			return nil, errors.Wrap(ErrBadOptionTag, "option field is not exported", "field", sf.Name)
+		}
+
+		f, err := parseOptionField(sf, tag)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse option field", "field", sf.Name)
+		}
+		f.index = i
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+func parseOptionField(sf reflect.StructField, tag string) (optionField, error) {
+	f := optionField{target: sf.Type}
+	if f.target.Kind() == reflect.Pointer {
+		f.pointer = true
+		f.target = f.target.Elem()
+	}
+
+	parts := strings.Split(tag, ",")
+
+	f.opt.Name = strings.TrimSpace(parts[0])
+	if f.opt.Name == "" {
+		f.opt.Name = strings.ToLower(sf.Name)
+	}
+
+	f.opt.Description = sf.Tag.Get("description")
+	if f.opt.Description == "" {
+		return f, errors.Wrap(ErrBadOptionTag, "missing description")
+	}
+
+	var explicitType string
+	settings := map[string]string{}
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		if k == "type" {
+			explicitType = v
+			continue
+		}
+		settings[k] = v
+	}
+
+	var err error
+	if f.opt.Type, err = optionTypeFor(f.target, explicitType); err != nil {
+		return f, err
+	}
+
+	for k, v := range settings {
+		switch k {
+		case "required":
+			f.opt.Required = true
+		case "autocomplete":
+			f.opt.Autocomplete = true
+		case "min", "max":
+			if f.opt.Type != entity.OptTypeInteger && f.opt.Type != entity.OptTypeNumber {
+				return f, errors.Wrap(ErrBadOptionTag, "min and max need an integer or number option", "setting", k)
+			}
+
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return f, errors.Wrap(err, "could not parse setting", "setting", k)
+			}
+
+			if k == "min" {
+				f.opt.MinValue = &n
+			} else {
+				f.opt.MaxValue = &n
+			}
+		case "min_length", "max_length":
+			if f.opt.Type != entity.OptTypeString {
+				return f, errors.Wrap(ErrBadOptionTag, "min_length and max_length need a string option", "setting", k)
+			}
+
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return f, errors.Wrap(err, "could not parse setting", "setting", k)
+			}
+
+			if k == "min_length" {
+				f.opt.MinLength = &n
+			} else {
+				f.opt.MaxLength = &n
+			}
+		case "channel_types":
+			if f.opt.Type != entity.OptTypeChannel {
+				return f, errors.Wrap(ErrBadOptionTag, "channel_types needs a channel option")
+			}
+
+			for _, s := range strings.Split(v, "|") {
+				n, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil {
+					return f, errors.Wrap(err, "could not parse channel type", "value", s)
+				}
+				f.opt.ChannelTypes = append(f.opt.ChannelTypes, entity.ChannelType(n))
+			}
+		default:
+			return f, errors.Wrap(ErrBadOptionTag, "unknown setting", "setting", k)
+		}
+	}
+
+	if choices, ok := sf.Tag.Lookup("choices"); ok {
+		if f.opt.Choices, err = parseChoices(f.opt.Type, choices); err != nil {
+			return f, err
+		}
+
+		if f.opt.Autocomplete {
+			return f, errors.Wrap(ErrBadOptionTag, "an option cannot have both choices and autocomplete")
+		}
+	}
+
+	if f.opt.Autocomplete && f.opt.Type != entity.OptTypeString && f.opt.Type != entity.OptTypeInteger && f.opt.Type != entity.OptTypeNumber {
+		return f, errors.Wrap(ErrBadOptionTag, "autocomplete needs a string, integer or number option")
+	}
+
+	return f, nil
+}
+
+func optionTypeFor(t reflect.Type, explicit string) (entity.ApplicationCommandOptionType, error) {
+	switch t {
+	case userType, guildMemberType:
+		return entity.OptTypeUser, nil
+	case roleType:
+		return entity.OptTypeRole, nil
+	case channelType:
+		return entity.OptTypeChannel, nil
+	case snowflakeType:
+		switch explicit {
+		case "user":
+			return entity.OptTypeUser, nil
+		case "role":
+			return entity.OptTypeRole, nil
+		case "channel":
+			return entity.OptTypeChannel, nil
+		default:
+			return 0, errors.Wrap(ErrBadOptionTag, "snowflake options need type=user, type=role or type=channel", "type", explicit)
+		}
+	}
+
+	if explicit != "" {
+		return 0, errors.Wrap(ErrBadOptionTag, "type is only allowed on snowflake options", "type", explicit)
+	}
+
+	switch t.Kind() { //nolint:exhaustive // only these kinds are supported
+	case reflect.String:
+		return entity.OptTypeString, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return entity.OptTypeInteger, nil
+	case reflect.Float32, reflect.Float64:
+		return entity.OptTypeNumber, nil
+	case reflect.Bool:
+		return entity.OptTypeBoolean, nil
+	default:
+		return 0, errors.Wrap(ErrBadOptionTag, "unsupported field type", "type", t.String())
+	}
+}
+
+func parseChoices(ot entity.ApplicationCommandOptionType, tag string) ([]entity.ApplicationCommandOptionChoice, error) {
+	parts := strings.Split(tag, "|")
+	choices := make([]entity.ApplicationCommandOptionChoice, 0, len(parts))
+
+	for _, p := range parts {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			value = name
+		}
+
+		c := entity.ApplicationCommandOptionChoice{Name: name, Type: ot}
+
+		var err error
+		switch ot { //nolint:exhaustive // only these types can have choices
+		case entity.OptTypeString:
+			c.ValueString = value
+		case entity.OptTypeInteger:
+			c.ValueInt, err = strconv.Atoi(value)
+		case entity.OptTypeNumber:
+			c.ValueNumber, err = strconv.ParseFloat(value, 64)
+		default:
+			return nil, errors.Wrap(ErrBadOptionTag, "choices need a string, integer or number option")
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse choice", "choice", p)
+		}
+
+		if err := c.FillValue(); err != nil {
+			return nil, errors.Wrap(err, "could not fill choice value", "choice", p)
+		}
+
+		choices = append(choices, c)
+	}
+
+	return choices, nil
+}
+
+// BindOptions fills the struct pointed to by dst with the options of a chat input command interaction
+//
+// The struct is described the same way as for CommandFromStruct. When the command was invoked through
+// a subcommand, the subcommand's options are bound. Users, members, roles and channels are filled from
+// the interaction's resolved data.
+//
+// If any option is missing or out of bounds, the returned error is a *ValidationError, whose Response
+// can be sent back to the user (see ValidationResponse)
+func BindOptions(ix *Interaction, dst interface{}) error {
+	if ix.Data == nil {
+		return errors.WithDetails(ErrMalformedInteraction, "reason", "nil Data")
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return ErrBadOptionsTarget
+	}
+	rv = rv.Elem()
+
+	fields, err := optionFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	given := map[string]entity.ApplicationCommandInteractionOption{}
+	_, leaf := splitCommandPath(ix.Data.Options)
+	for _, o := range leaf {
+		given[o.Name] = o
+	}
+
+	verr := &ValidationError{}
+
+	for _, f := range fields {
+		o, ok := given[f.opt.Name]
+		if !ok {
+			if f.opt.Required {
+				verr.add(f.opt.Name, "is required")
+			}
+			continue
+		}
+
+		if o.Type != f.opt.Type {
+			return errors.Wrap(ErrMalformedInteraction, "option type does not match", "option", o.Name, "want", int(f.opt.Type), "got", int(o.Type))
+		}
+
+		if err := resolveOptionValue(&o); err != nil {
+			return errors.Wrap(err, "could not resolve option value", "option", o.Name)
+		}
+
+		target := rv.Field(f.index)
+		if f.pointer {
+			target = reflect.New(f.target).Elem()
+		}
+
+		if reason := bindOption(target, f, o, &ix.Data.Resolved); reason != "" {
+			verr.add(f.opt.Name, reason)
+			continue
+		}
+
+		if f.pointer {
+			rv.Field(f.index).Set(target.Addr())
+		}
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// resolveOptionValue fills the typed value of an option that only carries its raw json value
+func resolveOptionValue(o *entity.ApplicationCommandInteractionOption) error {
+	if len(o.Value) == 0 {
+		return nil
+	}
+
+	switch o.Type { //nolint:exhaustive // the other types decode from json as-is
+	case entity.OptTypeUser:
+		if o.ValueUser != 0 {
+			return nil
+		}
+	case entity.OptTypeRole:
+		if o.ValueRole != 0 {
+			return nil
+		}
+	case entity.OptTypeChannel:
+		if o.ValueChannel != 0 {
+			return nil
+		}
+	}
+
+	return o.ResolveValue()
+}
+
+// bindOption sets the field to the option value, returning the reason the value is not valid, if any
+func bindOption(field reflect.Value, f optionField, o entity.ApplicationCommandInteractionOption, resolved *entity.ResolvedData) string {
+	switch f.opt.Type { //nolint:exhaustive // optionTypeFor only produces these types
+	case entity.OptTypeString:
+		if reason := checkLength(f.opt, o.ValueString); reason != "" {
+			return reason
+		}
+		if reason := checkChoice(f.opt, o.ValueString); reason != "" {
+			return reason
+		}
+		field.SetString(o.ValueString)
+	case entity.OptTypeInteger:
+		if reason := checkRange(f.opt, float64(o.ValueInt)); reason != "" {
+			return reason
+		}
+		if reason := checkChoice(f.opt, strconv.Itoa(o.ValueInt)); reason != "" {
+			return reason
+		}
+		return setInteger(field, o.ValueInt)
+	case entity.OptTypeNumber:
+		if reason := checkRange(f.opt, o.ValueNumber); reason != "" {
+			return reason
+		}
+		if reason := checkChoice(f.opt, strconv.FormatFloat(o.ValueNumber, 'f', -1, 64)); reason != "" {
+			return reason
+		}
+		if field.OverflowFloat(o.ValueNumber) {
+			return "is out of range"
+		}
+		field.SetFloat(o.ValueNumber)
+	case entity.OptTypeBoolean:
+		field.SetBool(o.ValueBool)
+	case entity.OptTypeUser:
+		return bindUser(field, f.target, o.ValueUser, resolved)
+	case entity.OptTypeRole:
+		if f.target == snowflakeType {
+			field.SetUint(uint64(o.ValueRole))
+			return ""
+		}
+
+		r, ok := resolved.Roles[o.ValueRole]
+		if !ok {
+			return "could not be found"
+		}
+		field.Set(reflect.ValueOf(r))
+	case entity.OptTypeChannel:
+		c, ok := resolved.Channels[o.ValueChannel]
+		if ok && len(f.opt.ChannelTypes) > 0 && !hasChannelType(f.opt.ChannelTypes, c.Type) {
+			return "is not an allowed kind of channel"
+		}
+
+		if f.target == snowflakeType {
+			field.SetUint(uint64(o.ValueChannel))
+			return ""
+		}
+
+		if !ok {
+			return "could not be found"
+		}
+		field.Set(reflect.ValueOf(c))
+	}
+
+	return ""
+}
+
+func bindUser(field reflect.Value, target reflect.Type, uid snowflake.Snowflake, resolved *entity.ResolvedData) string {
+	switch target {
+	case snowflakeType:
+		field.SetUint(uint64(uid))
+	case guildMemberType:
+		m, ok := resolved.Members[uid]
+		if !ok {
+			return "is not a member of this server"
+		}
+
+		if m.User == nil {
+			if u, ok := resolved.Users[uid]; ok {
+				m.User = &u
+			}
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		u, ok := resolved.Users[uid]
+		if !ok {
+			return "could not be found"
+		}
+		field.Set(reflect.ValueOf(u))
+	}
+
+	return ""
+}
+
+func setInteger(field reflect.Value, n int) string {
+	switch field.Kind() { //nolint:exhaustive // optionTypeFor only allows integer kinds here
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || field.OverflowUint(uint64(n)) {
+			return "is out of range"
+		}
+		field.SetUint(uint64(n))
+	default:
+		if field.OverflowInt(int64(n)) {
+			return "is out of range"
+		}
+		field.SetInt(int64(n))
+	}
+
+	return ""
+}
+
+func checkRange(opt entity.ApplicationCommandOption, v float64) string {
+	if opt.MinValue != nil && v < *opt.MinValue {
+		return fmt.Sprintf("must be at least %s", strconv.FormatFloat(*opt.MinValue, 'f', -1, 64))
+	}
+
+	if opt.MaxValue != nil && v > *opt.MaxValue {
+		return fmt.Sprintf("must be at most %s", strconv.FormatFloat(*opt.MaxValue, 'f', -1, 64))
+	}
+
+	return ""
+}
+
+func checkLength(opt entity.ApplicationCommandOption, v string) string {
+	n := utf8.RuneCountInString(v)
+
+	if opt.MinLength != nil && n < *opt.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", *opt.MinLength)
+	}
+
+	if opt.MaxLength != nil && n > *opt.MaxLength {
+		return fmt.Sprintf("must be at most %d characters long", *opt.MaxLength)
+	}
+
+	return ""
+}
+
+func checkChoice(opt entity.ApplicationCommandOption, v string) string {
+	if len(opt.Choices) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(opt.Choices))
+	for _, c := range opt.Choices {
+		var cv string
+		switch c.Type { //nolint:exhaustive // parseChoices only produces these types
+		case entity.OptTypeString:
+			cv = c.ValueString
+		case entity.OptTypeInteger:
+			cv = strconv.Itoa(c.ValueInt)
+		case entity.OptTypeNumber:
+			cv = strconv.FormatFloat(c.ValueNumber, 'f', -1, 64)
+		}
+
+		if cv == v {
+			return ""
+		}
+		names = append(names, c.Name)
+	}
+
+	return fmt.Sprintf("must be one of: %s", strings.Join(names, ", "))
+}
+
+func hasChannelType(types []entity.ChannelType, t entity.ChannelType) bool {
+	for _, ct := range types {
+		if ct == t {
+			return true
+		}
+	}
+
+	return false
+}
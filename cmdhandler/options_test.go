@@ -0,0 +1,246 @@
+package cmdhandler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+type eventOptions struct {
+	Title   string              `option:"title,required,min_length=3,max_length=20" description:"The event title"`
+	Slots   int                 `option:"slots,min=1,max=10" description:"How many can sign up"`
+	Kind    string              `option:"kind" choices:"Raid=raid|Dungeon=dungeon" description:"The kind of event"`
+	Where   entity.Channel      `option:"where,channel_types=0|5" description:"Where to post it"`
+	Leader  *entity.GuildMember `option:"leader" description:"Who leads it"`
+	Pings   snowflake.Snowflake `option:"pings,type=role" description:"The role to ping"`
+	Private bool                `option:"private" description:"Only invite people"`
+	Notes   string
+}
+
+func TestCommandFromStruct(t *testing.T) {
+	t.Parallel()
+
+	cmd, err := CommandFromStruct("event", "Create an event", &eventOptions{})
+	if err != nil {
+		t.Fatalf("CommandFromStruct() error = %v", err)
+	}
+
+	names := make([]string, 0, len(cmd.Options))
+	for _, o := range cmd.Options {
+		names = append(names, o.Name)
+	}
+
+	if want := []string{"title", "slots", "kind", "where", "leader", "pings", "private"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("CommandFromStruct() option names = %v, want %v", names, want)
+	}
+
+	title := cmd.Options[0]
+	if title.Type != entity.OptTypeString || !title.Required || title.MinLength == nil || *title.MinLength != 3 || title.MaxLength == nil || *title.MaxLength != 20 {
+		t.Errorf("CommandFromStruct() title = %+v", title)
+	}
+
+	slots := cmd.Options[1]
+	if slots.Type != entity.OptTypeInteger || slots.MinValue == nil || *slots.MinValue != 1 || slots.MaxValue == nil || *slots.MaxValue != 10 {
+		t.Errorf("CommandFromStruct() slots = %+v", slots)
+	}
+
+	if kind := cmd.Options[2]; len(kind.Choices) != 2 || kind.Choices[1].Name != "Dungeon" || string(kind.Choices[1].Value) != `"dungeon"` {
+		t.Errorf("CommandFromStruct() kind choices = %+v", kind.Choices)
+	}
+
+	if where := cmd.Options[3]; where.Type != entity.OptTypeChannel || !reflect.DeepEqual(where.ChannelTypes, []entity.ChannelType{0, 5}) {
+		t.Errorf("CommandFromStruct() where = %+v", where)
+	}
+
+	if cmd.Options[4].Type != entity.OptTypeUser || cmd.Options[5].Type != entity.OptTypeRole || cmd.Options[6].Type != entity.OptTypeBoolean {
+		t.Errorf("CommandFromStruct() types = %v, %v, %v", cmd.Options[4].Type, cmd.Options[5].Type, cmd.Options[6].Type)
+	}
+}
+
+func TestCommandFromStruct_badTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{
+			name: "not a struct",
+			v:    "nope",
+		},
+		{
+			name: "missing description",
+			v: &struct {
+				A string `option:"a"`
+			}{},
+		},
+		{
+			name: "min on a string",
+			v: &struct {
+				A string `option:"a,min=1" description:"a"`
+			}{},
+		},
+		{
+			name: "snowflake without type",
+			v: &struct {
+				A snowflake.Snowflake `option:"a" description:"a"`
+			}{},
+		},
+		{
+			name: "unsupported type",
+			v: &struct {
+				A []string `option:"a" description:"a"`
+			}{},
+		},
+		{
+			name: "unknown setting",
+			v: &struct {
+				A string `option:"a,bogus" description:"a"`
+			}{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := CommandFromStruct("cmd", "A command", tt.v); err == nil {
+				t.Errorf("CommandFromStruct() expected an error")
+			}
+		})
+	}
+}
+
+func commandInvocation(opts ...entity.ApplicationCommandInteractionOption) *Interaction {
+	return &Interaction{
+		Interaction: entity.Interaction{
+			Type: entity.InteractionApplicationCommand,
+			Data: &entity.InteractionData{
+				Name:    "event",
+				Options: opts,
+				Resolved: entity.ResolvedData{
+					Users: map[snowflake.Snowflake]entity.User{
+						7: {ID: "7", Username: "leader"},
+					},
+					Members: map[snowflake.Snowflake]entity.GuildMember{
+						7: {Nick: "boss"},
+					},
+					Channels: map[snowflake.Snowflake]entity.Channel{
+						3: {Name: "events", Type: entity.ChannelGuildText},
+						4: {Name: "voice", Type: entity.ChannelGuildVoice},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBindOptions(t *testing.T) {
+	t.Parallel()
+
+	str := func(name, v string) entity.ApplicationCommandInteractionOption {
+		return entity.ApplicationCommandInteractionOption{Name: name, Type: entity.OptTypeString, ValueString: v}
+	}
+	num := func(name string, v int) entity.ApplicationCommandInteractionOption {
+		return entity.ApplicationCommandInteractionOption{Name: name, Type: entity.OptTypeInteger, ValueInt: v}
+	}
+
+	tests := []struct {
+		name       string
+		opts       []entity.ApplicationCommandInteractionOption
+		want       eventOptions
+		wantErrors int
+	}{
+		{
+			name: "all options",
+			opts: []entity.ApplicationCommandInteractionOption{
+				str("title", "Raid night"),
+				num("slots", 8),
+				str("kind", "raid"),
+				{Name: "where", Type: entity.OptTypeChannel, ValueChannel: 3},
+				{Name: "leader", Type: entity.OptTypeUser, ValueUser: 7},
+				{Name: "pings", Type: entity.OptTypeRole, ValueRole: 9},
+				{Name: "private", Type: entity.OptTypeBoolean, ValueBool: true},
+			},
+			want: eventOptions{
+				Title:   "Raid night",
+				Slots:   8,
+				Kind:    "raid",
+				Where:   entity.Channel{Name: "events", Type: entity.ChannelGuildText},
+				Leader:  &entity.GuildMember{Nick: "boss", User: &entity.User{ID: "7", Username: "leader"}},
+				Pings:   9,
+				Private: true,
+			},
+		},
+		{
+			name: "only required",
+			opts: []entity.ApplicationCommandInteractionOption{
+				str("title", "Raid night"),
+			},
+			want: eventOptions{Title: "Raid night"},
+		},
+		{
+			name: "under a subcommand",
+			opts: []entity.ApplicationCommandInteractionOption{
+				{Name: "create", Type: entity.OptTypeSubCommand, Options: []entity.ApplicationCommandInteractionOption{
+					str("title", "Raid night"),
+				}},
+			},
+			want: eventOptions{Title: "Raid night"},
+		},
+		{
+			name:       "missing required",
+			opts:       []entity.ApplicationCommandInteractionOption{num("slots", 2)},
+			wantErrors: 1,
+		},
+		{
+			name: "out of bounds",
+			opts: []entity.ApplicationCommandInteractionOption{
+				str("title", "Ra"),
+				num("slots", 11),
+				str("kind", "party"),
+				{Name: "where", Type: entity.OptTypeChannel, ValueChannel: 4},
+				{Name: "leader", Type: entity.OptTypeUser, ValueUser: 8},
+			},
+			wantErrors: 5,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got eventOptions
+			err := BindOptions(commandInvocation(tt.opts...), &got)
+
+			if tt.wantErrors > 0 {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("BindOptions() error = %v, want a *ValidationError", err)
+				}
+
+				if len(verr.Errors) != tt.wantErrors {
+					t.Errorf("BindOptions() errors = %v, want %d of them", verr.Errors, tt.wantErrors)
+				}
+
+				r, ok := ValidationResponse(err)
+				if sr, isSimple := r.(*SimpleResponse); !ok || !isSimple || !sr.Ephemeral {
+					t.Errorf("ValidationResponse() = %v, %v, want an ephemeral response", r, ok)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("BindOptions() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BindOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
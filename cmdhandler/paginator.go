@@ -0,0 +1,383 @@
+package cmdhandler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrNoPages is the error returned when running a Paginator that has no pages
+var ErrNoPages = errors.New("paginator has no pages")
+
+// paginatorPrefix is the custom_id prefix of the paginator controls
+const paginatorPrefix = "paginator:"
+
+// DefaultPaginatorTimeout is how long a Paginator waits for a click before settling into its final state
+const DefaultPaginatorTimeout = 5 * time.Minute
+
+// PageFunc produces the page with the given (0-based) index
+type PageFunc func(page int) (Response, error)
+
+// PaginatorClient is the part of jsonapi.DiscordJSONClient that a Paginator uses
+type PaginatorClient interface {
+	ResponseEditor
+	SendInteractionMessage(context.Context, snowflake.Snowflake, string, jsonapi.JSONMarshaler) error
+	SendInteractionUpdate(context.Context, snowflake.Snowflake, string, jsonapi.JSONMarshaler) error
+}
+
+// Paginator is a Response that shows one page of a long response at a time, with first,
+// previous, next, last and jump controls
+//
+// The pages are either fixed (Pages, for example from another Response's Split) or produced on
+// demand (PageFunc and PageCount). A Paginator is sent like any other Response, and then Run serves
+// its controls. File attachments on the pages are not sent
+type Paginator struct {
+	Pages     []Response
+	PageFunc  PageFunc
+	PageCount int
+
+	// Page is the index of the page currently shown
+	Page int
+	// OwnerOnly restricts the controls to the user that invoked the paginator
+	OwnerOnly bool
+	// Timeout is how long to wait for a click before settling into the final state (DefaultPaginatorTimeout if 0)
+	Timeout time.Duration
+
+	color     int
+	ephemeral bool
+	errors    []error
+}
+
+// ensure that Paginator is a Response
+var _ Response = (*Paginator)(nil)
+
+// NewPaginator creates a Paginator over fixed pages
+func NewPaginator(pages []Response) *Paginator {
+	return &Paginator{Pages: pages}
+}
+
+// NewPaginatorFunc creates a Paginator over count pages produced by f
+func NewPaginatorFunc(count int, f PageFunc) *Paginator {
+	return &Paginator{PageFunc: f, PageCount: count}
+}
+
+// Count returns the number of pages
+func (p *Paginator) Count() int {
+	if p.PageFunc != nil {
+		return p.PageCount
+	}
+
+	return len(p.Pages)
+}
+
+// current produces the current page, with the paginator's settings applied
+func (p *Paginator) current() Response {
+	var page Response
+	var err error
+
+	switch {
+	case p.Page < 0 || p.Page >= p.Count():
+		page, err = &SimpleResponse{}, ErrNoPages
+	case p.PageFunc != nil:
+		page, err = p.PageFunc(p.Page)
+		if page == nil {
+			page = &SimpleResponse{}
+		}
+	default:
+		// fixed pages already carry the paginator's errors (see IncludeError)
+		return p.styled(p.Pages[p.Page])
+	}
+
+	page.IncludeError(err)
+	for _, e := range p.errors {
+		page.IncludeError(e)
+	}
+
+	return p.styled(page)
+}
+
+// styled applies the paginator's color and ephemeral settings to a page
+func (p *Paginator) styled(page Response) Response {
+	if p.color != 0 {
+		page.SetColor(p.color)
+	}
+	page.SetEphemeral(p.ephemeral)
+
+	return page
+}
+
+// SetColor sets the color of every page
+func (p *Paginator) SetColor(color int) {
+	p.color = color
+}
+
+// SetEphemeral sets a flag that indicates the pages are ephemeral for interactions
+func (p *Paginator) SetEphemeral(e bool) {
+	p.ephemeral = e
+}
+
+// GetColor returns the color set for the pages
+func (p *Paginator) GetColor() int {
+	return p.color
+}
+
+// IncludeError adds an error into every page
+func (p *Paginator) IncludeError(err error) {
+	if err == nil {
+		return
+	}
+
+	p.errors = append(p.errors, err)
+	for _, page := range p.Pages {
+		page.IncludeError(err)
+	}
+}
+
+// HasErrors returns whether or not the response includes errors
+func (p *Paginator) HasErrors() bool {
+	return len(p.errors) > 0
+}
+
+// ToString generates a plain-text representation of the current page
+func (p *Paginator) ToString() string {
+	return fmt.Sprintf("%s\n(page %d of %d)\n", p.current().ToString(), p.Page+1, p.Count())
+}
+
+// ToMessage generates the current page, with the navigation controls
+func (p *Paginator) ToMessage() JSONMarshaler {
+	return p.render(true)
+}
+
+// Channel returns the channel of the current page
+func (p *Paginator) Channel() snowflake.Snowflake {
+	return p.current().Channel()
+}
+
+// Split returns the paginator unchanged, since it only ever shows one page
+func (p *Paginator) Split() []Response {
+	return []Response{p}
+}
+
+// MessageReactions is included for the Response API but always returns nil
+func (p *Paginator) MessageReactions() []string { return nil }
+
+// render generates the current page, with or without the navigation controls
+func (p *Paginator) render(withControls bool) JSONMarshaler {
+	var controls []entity.Component
+	if withControls && p.Count() > 1 {
+		controls = p.controls()
+	}
+
+	switch m := p.current().ToMessage().(type) {
+	case jsonapi.Message:
+		m.Components = append(m.Components, controls...)
+		m.Files = nil
+		return m
+	case jsonapi.MessageWithEmbed:
+		m.Components = append(m.Components, controls...)
+		m.Files = nil
+		return m
+	default:
+		return m
+	}
+}
+
+// controls creates the navigation components for the current page
+func (p *Paginator) controls() []entity.Component {
+	last := p.Count() - 1
+
+	button := func(label, action string, disabled bool) entity.Component {
+		b := entity.Button(entity.ButtonSecondary, label, paginatorPrefix+action)
+		b.Disabled = disabled
+		return b
+	}
+
+	indicator := button(fmt.Sprintf("%d / %d", p.Page+1, p.Count()), "page", true)
+	indicator.Style = entity.ButtonPrimary
+
+	rows := []entity.Component{
+		entity.ActionRow(
+			button("«", "first", p.Page == 0),
+			button("‹", "prev", p.Page == 0),
+			indicator,
+			button("›", "next", p.Page == last),
+			button("»", "last", p.Page == last),
+		),
+	}
+
+	if p.Count() <= 2 {
+		return rows
+	}
+
+	// the jump menu offers a window of pages around the current one
+	start := p.Page - entity.MaxSelectOptions/2
+	if start > p.Count()-entity.MaxSelectOptions {
+		start = p.Count() - entity.MaxSelectOptions
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	opts := make([]entity.SelectOption, 0, entity.MaxSelectOptions)
+	for i := start; i < p.Count() && len(opts) < entity.MaxSelectOptions; i++ {
+		opts = append(opts, entity.SelectOption{
+			Label:   fmt.Sprintf("Page %d", i+1),
+			Value:   strconv.Itoa(i),
+			Default: i == p.Page,
+		})
+	}
+
+	jump := entity.StringSelect(paginatorPrefix+"jump", opts...)
+	jump.Placeholder = "Jump to page"
+
+	return append(rows, entity.ActionRow(jump))
+}
+
+// navigate applies a click on a control, reporting whether the page changed
+func (p *Paginator) navigate(ix *Interaction) bool {
+	prev := p.Page
+	last := p.Count() - 1
+
+	switch strings.TrimPrefix(ix.CustomID(), paginatorPrefix) {
+	case "first":
+		p.Page = 0
+	case "prev":
+		p.Page--
+	case "next":
+		p.Page++
+	case "last":
+		p.Page = last
+	case "jump":
+		if len(ix.Data.Values) > 0 {
+			if n, err := strconv.Atoi(ix.Data.Values[0]); err == nil {
+				p.Page = n
+			}
+		}
+	}
+
+	if p.Page > last {
+		p.Page = last
+	}
+	if p.Page < 0 {
+		p.Page = 0
+	}
+
+	return p.Page != prev
+}
+
+// Run serves the controls of the paginator shown as the original response to ix
+//
+// The original response must already have been sent or deferred; Run edits it to show the current page,
+// then updates the page in place as the controls are clicked. Once no control has been clicked for
+// Timeout the message is edited into its final state: the current page without controls. Since interaction tokens expire, Timeout should be well under jsonapi.InteractionTokenLifetime
+func (p *Paginator) Run(ctx context.Context, ixd *InteractionDispatcher, client PaginatorClient, ix *Interaction) error {
+	if p.Count() == 0 {
+		return ErrNoPages
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPaginatorTimeout
+	}
+
+	w := jsonapi.InteractionWebhookFor(ix.Interaction)
+	msg, err := client.EditOriginalResponse(ctx, w, p)
+	if err != nil {
+		return errors.Wrap(err, "could not show the first page")
+	}
+
+	if p.Count() == 1 {
+		return nil
+	}
+
+	owner := ix.UserID()
+	filter := func(cix *Interaction) bool {
+		return strings.HasPrefix(cix.CustomID(), paginatorPrefix)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	coll := ixd.Collect(cctx, msg.IDSnowflake, filter, jsonapi.InteractionTokenLifetime)
+
+	idle := time.NewTimer(timeout)
+	defer idle.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle.C:
+			break loop
+		case cix, ok := <-coll.Interactions():
+			if !ok {
+				break loop
+			}
+
+			if p.OwnerOnly && cix.UserID() != owner {
+				notice := &SimpleResponse{Content: "Only the user who ran the command can change pages.", Ephemeral: true}
+				if err := client.SendInteractionMessage(ctx, cix.IDSnowflake, cix.Token, notice.ToMessage()); err != nil {
+					return errors.Wrap(err, "could not send the owner-only notice")
+				}
+				continue
+			}
+
+			p.navigate(cix)
+			if err := client.SendInteractionUpdate(ctx, cix.IDSnowflake, cix.Token, p.ToMessage()); err != nil {
+				return errors.Wrap(err, "could not update the page")
+			}
+
+			// a component interaction's original response is the message it was on, and its token is fresher
+			w = jsonapi.InteractionWebhookFor(cix.Interaction)
+
+			if !idle.Stop() {
+				<-idle.C
+			}
+			idle.Reset(timeout)
+		}
+	}
+
+	cancel()
+
+	_, err = client.EditOriginalResponse(ctx, w, staticPage{p})
+	return errors.Wrap(err, "could not show the final page")
+}
+
+// staticPage renders a Paginator without its controls
+type staticPage struct {
+	p *Paginator
+}
+
+func (s staticPage) ToMessage() JSONMarshaler {
+	return s
+}
+
+// MarshalToJSON marshals the current page, explicitly clearing the components unless the page has its own
+// (an edit leaves out components otherwise, which would keep the controls)
+func (s staticPage) MarshalToJSON() ([]byte, error) {
+	b, err := s.p.render(false).MarshalToJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal page json")
+	}
+
+	if _, ok := m["components"]; !ok {
+		m["components"] = json.RawMessage("[]")
+	}
+
+	return json.Marshal(m)
+}
@@ -0,0 +1,113 @@
+package cmdhandler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+type paginatorClient struct {
+	mu      sync.Mutex
+	edits   []string
+	updates []string
+	notices int
+}
+
+func (c *paginatorClient) EditOriginalResponse(ctx context.Context, w jsonapi.InteractionWebhook, r jsonapi.MessageResponse) (entity.Message, error) {
+	b, err := r.ToMessage().MarshalToJSON()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.edits = append(c.edits, string(b))
+
+	return entity.Message{IDSnowflake: 10}, err
+}
+
+func (c *paginatorClient) SendInteractionMessage(ctx context.Context, ixID snowflake.Snowflake, token string, m jsonapi.JSONMarshaler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notices++
+
+	return nil
+}
+
+func (c *paginatorClient) SendInteractionUpdate(ctx context.Context, ixID snowflake.Snowflake, token string, m jsonapi.JSONMarshaler) error {
+	b, err := m.MarshalToJSON()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updates = append(c.updates, string(b))
+
+	return err
+}
+
+func TestPaginator_Run(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher(nil)
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	p := NewPaginator([]Response{
+		&SimpleResponse{Content: "one"},
+		&SimpleResponse{Content: "two"},
+		&SimpleResponse{Content: "three"},
+	})
+	p.OwnerOnly = true
+	p.Timeout = 200 * time.Millisecond
+
+	client := &paginatorClient{}
+	ix := &Interaction{Interaction: entity.Interaction{User: &entity.User{IDSnowflake: 2}}}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Run(context.Background(), ixd, client, ix) }()
+
+	// wait for the collector to be registered
+	for {
+		ixd.collectors.mu.Lock()
+		n := len(ixd.collectors.byMessage[10])
+		ixd.collectors.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, _, err := ixd.Dispatch(componentClick(10, 3, "paginator:next")); err != nil {
+		t.Errorf("Dispatch() error = %v", err)
+	}
+
+	if _, _, err := ixd.Dispatch(componentClick(10, 2, "paginator:last")); err != nil {
+		t.Errorf("Dispatch() error = %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if client.notices != 1 {
+		t.Errorf("expected 1 owner-only notice, got %d", client.notices)
+	}
+
+	if len(client.updates) != 1 || !strings.Contains(client.updates[0], "three") || !strings.Contains(client.updates[0], `"3 / 3"`) {
+		t.Errorf("expected an update to the last page, got %v", client.updates)
+	}
+
+	if len(client.edits) != 2 || !strings.Contains(client.edits[0], "one") {
+		t.Fatalf("expected the first page and a final edit, got %v", client.edits)
+	}
+
+	if !strings.Contains(client.edits[1], "three") || !strings.Contains(client.edits[1], `"components":[]`) {
+		t.Errorf("expected the final edit to show the last page without controls, got %s", client.edits[1])
+	}
+}
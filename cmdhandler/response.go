@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/jsonapi"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
 )
@@ -29,6 +30,8 @@ type Response interface {
 	MessageReactions() []string
 }
 
+var _ jsonapi.MessageResponse = (Response)(nil)
+
 // ReplyTo is the information required to create a message as a reply
 type ReplyTo struct {
 	MessageID snowflake.Snowflake
@@ -38,12 +41,14 @@ type ReplyTo struct {
 
 // SimpleResponse is a Response that is intended to present plain text
 type SimpleResponse struct {
-	To        string
-	Content   string
-	ToChannel snowflake.Snowflake
-	Reactions []string
-	ReplyTo   *ReplyTo
-	Ephemeral bool
+	To          string
+	Content     string
+	ToChannel   snowflake.Snowflake
+	Reactions   []string
+	ReplyTo     *ReplyTo
+	Ephemeral   bool
+	Attachments []jsonapi.File
+	Components  []entity.Component
 
 	errors []error
 }
@@ -132,6 +137,9 @@ func (r *SimpleResponse) ToMessage() JSONMarshaler {
 		resp.Flags |= (1 << 6)
 	}
 
+	resp.Files = r.Attachments
+	resp.Components = r.Components
+
 	return resp
 }
 
@@ -159,7 +167,7 @@ func (r *SimpleResponse) Split() []Response {
 		})
 	}
 
-	return resps
+	return componentsOnLast(attachToFirst(resps, r.Attachments), r.Components)
 }
 
 // MessageReactions returns the set of reactions for the response
@@ -179,6 +187,8 @@ type SimpleEmbedResponse struct {
 	Reactions   []string
 	ReplyTo     *ReplyTo
 	Ephemeral   bool
+	Attachments []jsonapi.File
+	Components  []entity.Component
 
 	errors []error
 }
@@ -306,6 +316,9 @@ func (r *SimpleEmbedResponse) ToMessage() JSONMarshaler {
 		m.Flags |= (1 << 6)
 	}
 
+	m.Files = r.Attachments
+	m.Components = r.Components
+
 	return m
 }
 
@@ -341,7 +354,7 @@ func (r *SimpleEmbedResponse) Split() []Response {
 		})
 	}
 
-	return resps
+	return componentsOnLast(attachToFirst(resps, r.Attachments), r.Components)
 }
 
 // MessageReactions returns the set of reactions for the response
@@ -369,6 +382,8 @@ type EmbedResponse struct {
 	Reactions   []string
 	ReplyTo     *ReplyTo
 	Ephemeral   bool
+	Attachments []jsonapi.File
+	Components  []entity.Component
 
 	errors []error
 }
@@ -512,6 +527,9 @@ func (r *EmbedResponse) ToMessage() JSONMarshaler {
 		m.Flags |= (1 << 6)
 	}
 
+	m.Files = r.Attachments
+	m.Components = r.Components
+
 	return m
 }
 
@@ -589,7 +607,7 @@ func (r *EmbedResponse) Split() []Response {
 		nextField++
 	}
 
-	return resps
+	return componentsOnLast(attachToFirst(resps, r.Attachments), r.Components)
 }
 
 func (r *EmbedResponse) fillResp(resp *EmbedResponse, startField, existingLen int) (int, []string) {
@@ -658,6 +676,42 @@ func (r *EmbedResponse) MessageReactions() []string {
 	return r.Reactions
 }
 
+// attachToFirst attaches files to the first of a set of split responses
+func attachToFirst(resps []Response, files []jsonapi.File) []Response {
+	if len(files) == 0 || len(resps) == 0 {
+		return resps
+	}
+
+	switch r := resps[0].(type) {
+	case *SimpleResponse:
+		r.Attachments = files
+	case *SimpleEmbedResponse:
+		r.Attachments = files
+	case *EmbedResponse:
+		r.Attachments = files
+	}
+
+	return resps
+}
+
+// componentsOnLast attaches components to the last of a set of split responses
+func componentsOnLast(resps []Response, components []entity.Component) []Response {
+	if len(components) == 0 || len(resps) == 0 {
+		return resps
+	}
+
+	switch r := resps[len(resps)-1].(type) {
+	case *SimpleResponse:
+		r.Components = components
+	case *SimpleEmbedResponse:
+		r.Components = components
+	case *EmbedResponse:
+		r.Components = components
+	}
+
+	return resps
+}
+
 // func (r *EmbedResponse) fillResp(resps []Response, resp *EmbedResponse, nextField int, nextFieldSplits []string, existingLen int) (resps []Response, nextResp *EmbedResponse, newNext int, newNextSplits []string) {
 // 	// do we need to continue an unfinished field?
 // 	for i, s := range nextFieldSplits {
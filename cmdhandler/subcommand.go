@@ -0,0 +1,254 @@
+package cmdhandler
+
+import (
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+// ErrBadSubcommandPath is the error returned when a subcommand path does not fit in its CommandTree
+var ErrBadSubcommandPath = errors.New("bad subcommand path")
+
+// Subcommand is a handler registered at a subcommand path of a CommandTree
+type Subcommand struct {
+	// Path is the full path of the subcommand, like "event create" or "admin roles add"
+	Path         string
+	Description  string
+	Options      []entity.ApplicationCommandOption
+	Handler      InteractionHandler
+	Autocomplete AutocompleteHandler
+}
+
+// CommandTree is an InteractionCommandHandler for a command made of subcommands and subcommand groups
+//
+// Each subcommand has its own handler, and the ApplicationCommand is built from the registered paths.
+// The handlers receive the interaction with only the options of their subcommand in Data.Options, and
+// Interaction.CommandPath tells which subcommand was invoked
+type CommandTree struct {
+	name        string
+	description string
+	groups      map[string]string
+	subs        map[string]Subcommand
+	order       []string
+	middleware  map[string][]Middleware // by group name, "" for the whole tree
+}
+
+// ensure that CommandTree is an InteractionCommandHandler
+var _ InteractionCommandHandler = (*CommandTree)(nil)
+
+// NewCommandTree creates an empty CommandTree for the named command
+func NewCommandTree(name, description string) *CommandTree {
+	return &CommandTree{
+		name:        name,
+		description: description,
+		groups:      map[string]string{},
+		subs:        map[string]Subcommand{},
+		middleware:  map[string][]Middleware{},
+	}
+}
+
+// relativePath checks a full subcommand or group path, returning its parts after the command name
+func (t *CommandTree) relativePath(path string, maxDepth int) ([]string, error) {
+	parts := strings.Fields(path)
+	if len(parts) < 2 || len(parts) > maxDepth+1 || parts[0] != t.name {
+		return nil, errors.Wrap(ErrBadSubcommandPath, "path does not fit the command", "command", t.name, "path", path)
+	}
+
+	return parts[1:], nil
+}
+
+// DescribeGroup sets the description of a subcommand group, like "admin roles"
+//
+// Groups that are not described use their name as their description
+func (t *CommandTree) DescribeGroup(path, description string) error {
+	rel, err := t.relativePath(path, 1)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.subs[rel[0]]; ok {
+		return errors.Wrap(ErrBadSubcommandPath, "group name is already a subcommand", "path", path)
+	}
+
+	t.groups[rel[0]] = description
+
+	return nil
+}
+
+// Use adds middleware around the handlers of every subcommand of the tree; the first is the outermost
+func (t *CommandTree) Use(mw ...Middleware) {
+	t.middleware[""] = append(t.middleware[""], mw...)
+}
+
+// UseGroup adds middleware around the handlers of the subcommands in a group, like "admin roles"
+//
+// It runs inside the middleware of the whole tree
+func (t *CommandTree) UseGroup(path string, mw ...Middleware) error {
+	rel, err := t.relativePath(path, 1)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.subs[rel[0]]; ok {
+		return errors.Wrap(ErrBadSubcommandPath, "group name is already a subcommand", "path", path)
+	}
+
+	t.middleware[rel[0]] = append(t.middleware[rel[0]], mw...)
+
+	return nil
+}
+
+// Learn registers a subcommand
+//
+// A path has the command name followed by either a subcommand name or a group name and a subcommand
+// name. A name cannot be both a subcommand and a group
+func (t *CommandTree) Learn(sc Subcommand) error {
+	rel, err := t.relativePath(sc.Path, 2)
+	if err != nil {
+		return err
+	}
+
+	key := strings.Join(rel, " ")
+
+	if len(rel) == 1 {
+		if _, ok := t.groups[rel[0]]; ok {
+			return errors.Wrap(ErrBadSubcommandPath, "subcommand name is already a group", "path", sc.Path)
+		}
+	} else {
+		if _, ok := t.subs[rel[0]]; ok {
+			return errors.Wrap(ErrBadSubcommandPath, "group name is already a subcommand", "path", sc.Path)
+		}
+
+		if _, ok := t.groups[rel[0]]; !ok {
+			t.groups[rel[0]] = rel[0]
+		}
+	}
+
+	if _, ok := t.subs[key]; !ok {
+		t.order = append(t.order, key)
+	}
+	t.subs[key] = sc
+
+	return nil
+}
+
+// Command builds the ApplicationCommand from the registered subcommands, in the order they were learned
+func (t *CommandTree) Command() entity.ApplicationCommand {
+	cmd := entity.ApplicationCommand{
+		Type:              entity.CmdTypeChatInput,
+		Name:              t.name,
+		Description:       t.description,
+		DefaultPermission: true,
+	}
+
+	groupIndex := map[string]int{}
+
+	for _, key := range t.order {
+		sc := t.subs[key]
+		rel := strings.Fields(key)
+
+		opt := entity.ApplicationCommandOption{
+			Type:        entity.OptTypeSubCommand,
+			Name:        rel[len(rel)-1],
+			Description: sc.Description,
+			Options:     sc.Options,
+		}
+
+		if len(rel) == 1 {
+			cmd.Options = append(cmd.Options, opt)
+			continue
+		}
+
+		gi, ok := groupIndex[rel[0]]
+		if !ok {
+			gi = len(cmd.Options)
+			groupIndex[rel[0]] = gi
+			cmd.Options = append(cmd.Options, entity.ApplicationCommandOption{
+				Type:        entity.OptTypeSubCommandGroup,
+				Name:        rel[0],
+				Description: t.groups[rel[0]],
+			})
+		}
+
+		cmd.Options[gi].Options = append(cmd.Options[gi].Options, opt)
+	}
+
+	return cmd
+}
+
+// Handler returns the CommandTree, which routes interactions to the subcommand handlers
+func (t *CommandTree) Handler() InteractionHandler { return t }
+
+// AutocompleteHandler returns the CommandTree, which routes autocomplete requests to the subcommand handlers
+func (t *CommandTree) AutocompleteHandler() AutocompleteHandler { return t }
+
+// route finds the subcommand invoked by the interaction, and narrows the interaction down to it
+func (t *CommandTree) route(ix *Interaction) (Subcommand, *Interaction, error) {
+	if ix.Data == nil {
+		return Subcommand{}, nil, errors.WithDetails(ErrMalformedInteraction, "reason", "nil Data")
+	}
+
+	names, leaf := splitCommandPath(ix.Data.Options)
+
+	sc, ok := t.subs[strings.Join(names, " ")]
+	if !ok {
+		return sc, nil, errors.Wrap(ErrMissingHandler, "no subcommand route", "command", t.name, "path", strings.Join(names, " "))
+	}
+
+	data := *ix.Data
+	data.Options = leaf
+
+	sub := *ix
+	sub.Data = &data
+	sub.path = strings.Join(append([]string{ix.Data.Name}, names...), " ")
+
+	return sc, &sub, nil
+}
+
+// HandleInteraction sends the interaction to the handler of the subcommand that was invoked
+func (t *CommandTree) HandleInteraction(ix *Interaction) (Response, []Response, error) {
+	sc, sub, err := t.route(ix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if sc.Handler == nil {
+		return nil, nil, errors.Wrap(ErrMissingHandler, "subcommand has no handler", "path", sc.Path)
+	}
+
+	h := sc.Handler
+	if names := strings.Fields(sub.path); len(names) == 3 {
+		h = WithMiddleware(h, t.middleware[names[1]]...)
+	}
+
+	return WithMiddleware(h, t.middleware[""]...).HandleInteraction(sub)
+}
+
+// Autocomplete sends the autocomplete request to the autocomplete handler of the subcommand being typed
+func (t *CommandTree) Autocomplete(ix *Interaction) ([]entity.ApplicationCommandOptionChoice, error) {
+	sc, sub, err := t.route(ix)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.Autocomplete == nil {
+		return nil, errors.Wrap(ErrMissingHandler, "subcommand has no autocomplete handler", "path", sc.Path)
+	}
+
+	return sc.Autocomplete.Autocomplete(sub)
+}
+
+// splitCommandPath descends through a subcommand group and subcommand, returning their names and the
+// options given to the subcommand
+func splitCommandPath(opts []entity.ApplicationCommandInteractionOption) ([]string, []entity.ApplicationCommandInteractionOption) {
+	var names []string
+
+	for len(opts) == 1 && (opts[0].Type == entity.OptTypeSubCommand || opts[0].Type == entity.OptTypeSubCommandGroup) {
+		names = append(names, opts[0].Name)
+		opts = opts[0].Options
+	}
+
+	return names, opts
+}
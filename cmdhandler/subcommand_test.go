@@ -0,0 +1,188 @@
+package cmdhandler
+
+import (
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+func pathHandler() InteractionHandler {
+	return NewInteractionHandler(func(ix *Interaction) (Response, []Response, error) {
+		names := ""
+		for _, o := range ix.Data.Options {
+			names += o.Name
+		}
+
+		return &SimpleResponse{Content: ix.CommandPath() + ":" + names}, nil, nil
+	})
+}
+
+func newAdminTree(t *testing.T) *CommandTree {
+	t.Helper()
+
+	tree := NewCommandTree("admin", "Administration")
+
+	if err := tree.DescribeGroup("admin roles", "Manage roles"); err != nil {
+		t.Fatalf("DescribeGroup() error = %v", err)
+	}
+
+	subs := []Subcommand{
+		{Path: "admin status", Description: "Show the status", Handler: pathHandler()},
+		{Path: "admin roles add", Description: "Add a role", Handler: pathHandler(), Autocomplete: NewAutocompleteHandler(func(ix *Interaction) ([]entity.ApplicationCommandOptionChoice, error) {
+			return []entity.ApplicationCommandOptionChoice{{Name: ix.CommandPath()}}, nil
+		})},
+		{Path: "admin roles remove", Description: "Remove a role", Handler: pathHandler()},
+	}
+	for _, sc := range subs {
+		if err := tree.Learn(sc); err != nil {
+			t.Fatalf("Learn(%q) error = %v", sc.Path, err)
+		}
+	}
+
+	return tree
+}
+
+func TestCommandTree_Learn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "wrong command", path: "event create"},
+		{name: "too short", path: "admin"},
+		{name: "too deep", path: "admin roles add now"},
+		{name: "subcommand is a group", path: "admin roles"},
+		{name: "group is a subcommand", path: "admin status now"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := newAdminTree(t).Learn(Subcommand{Path: tt.path, Description: "x", Handler: pathHandler()})
+			if !errors.Is(err, ErrBadSubcommandPath) {
+				t.Errorf("CommandTree.Learn() error = %v, want ErrBadSubcommandPath", err)
+			}
+		})
+	}
+}
+
+func TestCommandTree_Command(t *testing.T) {
+	t.Parallel()
+
+	cmd := newAdminTree(t).Command()
+
+	if cmd.Name != "admin" || len(cmd.Options) != 2 {
+		t.Fatalf("CommandTree.Command() = %+v", cmd)
+	}
+
+	if o := cmd.Options[0]; o.Type != entity.OptTypeSubCommand || o.Name != "status" {
+		t.Errorf("CommandTree.Command() first option = %+v", o)
+	}
+
+	g := cmd.Options[1]
+	if g.Type != entity.OptTypeSubCommandGroup || g.Name != "roles" || g.Description != "Manage roles" || len(g.Options) != 2 {
+		t.Fatalf("CommandTree.Command() group = %+v", g)
+	}
+
+	if g.Options[0].Name != "add" || g.Options[1].Name != "remove" || g.Options[1].Type != entity.OptTypeSubCommand {
+		t.Errorf("CommandTree.Command() group options = %+v", g.Options)
+	}
+}
+
+func TestCommandTree_dispatch(t *testing.T) {
+	t.Parallel()
+
+	ixd, err := NewInteractionDispatcher([]InteractionCommandHandler{newAdminTree(t)})
+	if err != nil {
+		t.Fatalf("NewInteractionDispatcher() error = %v", err)
+	}
+
+	role := entity.ApplicationCommandInteractionOption{Name: "role", Type: entity.OptTypeRole, ValueRole: 5}
+
+	tests := []struct {
+		name    string
+		opts    []entity.ApplicationCommandInteractionOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "subcommand",
+			opts: []entity.ApplicationCommandInteractionOption{{Name: "status", Type: entity.OptTypeSubCommand}},
+			want: "admin status:",
+		},
+		{
+			name: "group subcommand",
+			opts: []entity.ApplicationCommandInteractionOption{{Name: "roles", Type: entity.OptTypeSubCommandGroup, Options: []entity.ApplicationCommandInteractionOption{
+				{Name: "remove", Type: entity.OptTypeSubCommand, Options: []entity.ApplicationCommandInteractionOption{role}},
+			}}},
+			want: "admin roles remove:role",
+		},
+		{
+			name:    "unknown subcommand",
+			opts:    []entity.ApplicationCommandInteractionOption{{Name: "reboot", Type: entity.OptTypeSubCommand}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ix := &Interaction{
+				Interaction: entity.Interaction{
+					Type: entity.InteractionApplicationCommand,
+					Data: &entity.InteractionData{Name: "admin", Options: tt.opts},
+				},
+			}
+
+			got, _, err := ixd.Dispatch(ix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InteractionDispatcher.Dispatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissingHandler) {
+					t.Errorf("InteractionDispatcher.Dispatch() error = %v, want ErrMissingHandler", err)
+				}
+				return
+			}
+
+			if sr, ok := got.(*SimpleResponse); !ok || sr.Content != tt.want {
+				t.Errorf("InteractionDispatcher.Dispatch() = %v, want %v", got, tt.want)
+			}
+
+			if len(ix.Data.Options) != len(tt.opts) {
+				t.Errorf("InteractionDispatcher.Dispatch() modified the original options")
+			}
+		})
+	}
+
+	t.Run("autocomplete", func(t *testing.T) {
+		t.Parallel()
+
+		ix := &Interaction{
+			Interaction: entity.Interaction{
+				Type: entity.InteractionAutocomplete,
+				Data: &entity.InteractionData{Name: "admin", Options: []entity.ApplicationCommandInteractionOption{
+					{Name: "roles", Type: entity.OptTypeSubCommandGroup, Options: []entity.ApplicationCommandInteractionOption{
+						{Name: "add", Type: entity.OptTypeSubCommand},
+					}},
+				}},
+			},
+		}
+
+		choices, err := ixd.Autocomplete(ix)
+		if err != nil || len(choices) != 1 || choices[0].Name != "admin roles add" {
+			t.Errorf("InteractionDispatcher.Autocomplete() = %v, %v", choices, err)
+		}
+
+		ix.Data.Options[0].Options[0].Name = "remove"
+		if _, err := ixd.Autocomplete(ix); !errors.Is(err, ErrMissingHandler) {
+			t.Errorf("InteractionDispatcher.Autocomplete() error = %v, want ErrMissingHandler", err)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package commandsync
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+)
+
+// HashCache remembers the hash of the command definitions last applied to each scope
+type HashCache interface {
+	Get(key string) (string, bool)
+	Set(key, hash string) error
+}
+
+// MemoryCache is a HashCache that lasts as long as the process
+type MemoryCache struct {
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+var _ HashCache = (*MemoryCache)(nil)
+
+// NewMemoryCache creates an empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{hashes: map[string]string{}}
+}
+
+// Get returns the hash stored for the key
+func (c *MemoryCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h, ok := c.hashes[key]
+	return h, ok
+}
+
+// Set stores the hash for the key
+func (c *MemoryCache) Set(key, hash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hashes[key] = hash
+	return nil
+}
+
+// FileCache is a HashCache stored in a json file, so that it survives restarts
+type FileCache struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ HashCache = (*FileCache)(nil)
+
+// NewFileCache creates a FileCache stored at path (the file is created on the first Set)
+func NewFileCache(path string) *FileCache {
+	return &FileCache{path: path}
+}
+
+func (c *FileCache) load() (map[string]string, error) {
+	hashes := map[string]string{}
+
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return hashes, nil
+	}
+	if err != nil {
+		return hashes, errors.Wrap(err, "could not read command hash cache", "path", c.path)
+	}
+
+	err = json.Unmarshal(b, &hashes)
+	return hashes, errors.Wrap(err, "could not unmarshal command hash cache", "path", c.path)
+}
+
+// Get returns the hash stored for the key; an unreadable cache file counts as empty
+func (c *FileCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hashes, err := c.load()
+	if err != nil {
+		return "", false
+	}
+
+	h, ok := hashes[key]
+	return h, ok
+}
+
+// Set stores the hash for the key, replacing the cache file atomically
+func (c *FileCache) Set(key, hash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hashes, err := c.load()
+	if err != nil {
+		hashes = map[string]string{}
+	}
+	hashes[key] = hash
+
+	b, err := json.Marshal(hashes)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal command hash cache")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "could not create temporary cache file", "path", c.path)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // it is gone after a successful rename
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "could not write temporary cache file", "path", tmp.Name())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not close temporary cache file", "path", tmp.Name())
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), c.path), "could not replace cache file", "path", c.path)
+}
@@ -0,0 +1,282 @@
+package commandsync
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+// canonicalCommand is the part of a command definition that is compared, without the server-assigned
+// fields (id, application_id, guild_id and version) and with unordered lists sorted
+type canonicalCommand struct {
+	Type              entity.ApplicationCommandType `json:"type"`
+	Name              string                        `json:"name"`
+	Description       string                        `json:"description"`
+	DefaultPermission bool                          `json:"default_permission"`
+	Options           []canonicalOption             `json:"options"`
+
+	NameLocalizations        []localization `json:"name_localizations"`
+	DescriptionLocalizations []localization `json:"description_localizations"`
+}
+
+type canonicalOption struct {
+	Type         entity.ApplicationCommandOptionType `json:"type"`
+	Name         string                              `json:"name"`
+	Description  string                              `json:"description"`
+	Required     bool                                `json:"required"`
+	Choices      []canonicalChoice                   `json:"choices"`
+	Options      []canonicalOption                   `json:"options"`
+	ChannelTypes []entity.ChannelType                `json:"channel_types"`
+	Autocomplete bool                                `json:"autocomplete"`
+	MinValue     *float64                            `json:"min_value"`
+	MaxValue     *float64                            `json:"max_value"`
+	MinLength    *int                                `json:"min_length"`
+	MaxLength    *int                                `json:"max_length"`
+
+	NameLocalizations        []localization `json:"name_localizations"`
+	DescriptionLocalizations []localization `json:"description_localizations"`
+}
+
+type canonicalChoice struct {
+	Name              string         `json:"name"`
+	NameLocalizations []localization `json:"name_localizations"`
+	Value             interface{}    `json:"value"`
+}
+
+// localization is one entry of a localization map; the maps are kept as sorted lists, so that they marshal
+// (and hash) the same way every time
+type localization struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+func canonicalLocalizations(l entity.Localizations) []localization {
+	if len(l) == 0 {
+		return nil
+	}
+
+	out := make([]localization, 0, len(l))
+	for locale, text := range l {
+		out = append(out, localization{Locale: locale, Text: text})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Locale < out[j].Locale })
+
+	return out
+}
+
+// commandKey identifies a command within a scope (commands of different types may share a name)
+func commandKey(c entity.ApplicationCommand) string {
+	t := c.Type
+	if t == 0 {
+		t = entity.CmdTypeChatInput
+	}
+
+	return fmt.Sprintf("%d:%s", t, c.Name)
+}
+
+func canonicalize(c entity.ApplicationCommand) (canonicalCommand, error) {
+	cc := canonicalCommand{
+		Type:              c.Type,
+		Name:              c.Name,
+		Description:       c.Description,
+		DefaultPermission: c.DefaultPermission,
+
+		NameLocalizations:        canonicalLocalizations(c.NameLocalizations),
+		DescriptionLocalizations: canonicalLocalizations(c.DescriptionLocalizations),
+	}
+
+	if cc.Type == 0 {
+		cc.Type = entity.CmdTypeChatInput
+	}
+
+	var err error
+	cc.Options, err = canonicalOptions(c.Options)
+
+	return cc, errors.Wrap(err, "could not canonicalize options", "command", c.Name)
+}
+
+// canonicalOptions keeps the order of regular options (it is the order users see), but sorts subcommands
+// and subcommand groups by name
+func canonicalOptions(opts []entity.ApplicationCommandOption) ([]canonicalOption, error) {
+	if len(opts) == 0 {
+		return nil, nil
+	}
+
+	out := make([]canonicalOption, 0, len(opts))
+	nested := false
+
+	for _, o := range opts {
+		co := canonicalOption{
+			Type:         o.Type,
+			Name:         o.Name,
+			Description:  o.Description,
+			Required:     o.Required,
+			Autocomplete: o.Autocomplete,
+			MinValue:     o.MinValue,
+			MaxValue:     o.MaxValue,
+			MinLength:    o.MinLength,
+			MaxLength:    o.MaxLength,
+
+			NameLocalizations:        canonicalLocalizations(o.NameLocalizations),
+			DescriptionLocalizations: canonicalLocalizations(o.DescriptionLocalizations),
+		}
+
+		if o.Type == entity.OptTypeSubCommand || o.Type == entity.OptTypeSubCommandGroup {
+			nested = true
+		}
+
+		if len(o.ChannelTypes) > 0 {
+			co.ChannelTypes = append([]entity.ChannelType(nil), o.ChannelTypes...)
+			sort.Slice(co.ChannelTypes, func(i, j int) bool { return co.ChannelTypes[i] < co.ChannelTypes[j] })
+		}
+
+		for _, c := range o.Choices {
+			cc, err := canonicalizeChoice(o.Type, c)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not canonicalize choice", "option", o.Name)
+			}
+			co.Choices = append(co.Choices, cc)
+		}
+
+		var err error
+		if co.Options, err = canonicalOptions(o.Options); err != nil {
+			return nil, errors.Wrap(err, "could not canonicalize sub-options", "option", o.Name)
+		}
+
+		out = append(out, co)
+	}
+
+	if nested {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	}
+
+	return out, nil
+}
+
+// canonicalizeChoice reads the value of a choice, whether it was built in code (with a typed value)
+// or fetched from discord (with only the raw json value)
+func canonicalizeChoice(t entity.ApplicationCommandOptionType, c entity.ApplicationCommandOptionChoice) (canonicalChoice, error) {
+	cc := canonicalChoice{Name: c.Name, NameLocalizations: canonicalLocalizations(c.NameLocalizations)}
+
+	if c.Type != 0 {
+		if err := c.FillValue(); err != nil {
+			return cc, err
+		}
+	} else if len(c.Value) == 0 {
+		c.Type = t
+		if err := c.FillValue(); err != nil {
+			return cc, err
+		}
+	}
+
+	if len(c.Value) == 0 {
+		return cc, nil
+	}
+
+	err := json.Unmarshal(c.Value, &cc.Value)
+	return cc, errors.Wrap(err, "could not unmarshal choice value", "choice", c.Name)
+}
+
+// changes lists the parts of a command that differ between the current and desired definitions
+func changes(current, desired canonicalCommand) []string {
+	var out []string
+
+	if current.Type != desired.Type {
+		out = append(out, "type")
+	}
+
+	if current.Description != desired.Description {
+		out = append(out, "description")
+	}
+
+	if current.DefaultPermission != desired.DefaultPermission {
+		out = append(out, "default_permission")
+	}
+
+	if !reflect.DeepEqual(current.NameLocalizations, desired.NameLocalizations) {
+		out = append(out, "name_localizations")
+	}
+
+	if !reflect.DeepEqual(current.DescriptionLocalizations, desired.DescriptionLocalizations) {
+		out = append(out, "description_localizations")
+	}
+
+	return append(out, optionChanges("", current.Options, desired.Options)...)
+}
+
+func optionChanges(prefix string, current, desired []canonicalOption) []string {
+	var out []string
+
+	byName := make(map[string]canonicalOption, len(current))
+	for _, o := range current {
+		byName[o.Name] = o
+	}
+
+	seen := make(map[string]bool, len(desired))
+	for i, o := range desired {
+		seen[o.Name] = true
+
+		co, ok := byName[o.Name]
+		switch {
+		case !ok:
+			out = append(out, fmt.Sprintf("+option %s%s", prefix, o.Name))
+		case !reflect.DeepEqual(co, o):
+			if (o.Type == entity.OptTypeSubCommand || o.Type == entity.OptTypeSubCommandGroup) && co.Type == o.Type &&
+				co.Description == o.Description && sameLocalizations(co, o) {
+				out = append(out, optionChanges(prefix+o.Name+" ", co.Options, o.Options)...)
+			} else {
+				out = append(out, fmt.Sprintf("~option %s%s", prefix, o.Name))
+			}
+		case len(current) == len(desired) && current[i].Name != o.Name:
+			out = append(out, fmt.Sprintf("~order %s%s", prefix, o.Name))
+		}
+	}
+
+	for _, o := range current {
+		if !seen[o.Name] {
+			out = append(out, fmt.Sprintf("-option %s%s", prefix, o.Name))
+		}
+	}
+
+	return out
+}
+
+func sameLocalizations(a, b canonicalOption) bool {
+	return reflect.DeepEqual(a.NameLocalizations, b.NameLocalizations) &&
+		reflect.DeepEqual(a.DescriptionLocalizations, b.DescriptionLocalizations)
+}
+
+// Hash computes a digest of the semantic content of command definitions, independent of their order
+func Hash(cmds []entity.ApplicationCommand) (string, error) {
+	ccs := make([]canonicalCommand, 0, len(cmds))
+	for _, c := range cmds {
+		cc, err := canonicalize(c)
+		if err != nil {
+			return "", err
+		}
+		ccs = append(ccs, cc)
+	}
+
+	sort.Slice(ccs, func(i, j int) bool {
+		if ccs[i].Type != ccs[j].Type {
+			return ccs[i].Type < ccs[j].Type
+		}
+		return ccs[i].Name < ccs[j].Name
+	})
+
+	b, err := json.Marshal(ccs)
+	if err != nil {
+		return "", errors.Wrap(err, "could not marshal commands")
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
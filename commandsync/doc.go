@@ -0,0 +1,2 @@
+// Package commandsync synchronizes application command definitions with discord by applying only what changed
+package commandsync
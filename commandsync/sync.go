@@ -0,0 +1,422 @@
+package commandsync
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrDuplicateCommand is the error returned when the desired commands include two of the same type and name
+var ErrDuplicateCommand = errors.New("duplicate command")
+
+// ErrUnknownAction is the error returned when applying an Action of an unknown kind
+var ErrUnknownAction = errors.New("unknown action kind")
+
+type dependencies interface {
+	Logger() Logger
+	Telemetry() *telemetry.Telemeter
+}
+
+// Logger is the interface expected for logging
+type Logger = interface {
+	Log(keyvals ...interface{}) error
+	Message(string, ...interface{})
+	Err(string, error, ...interface{})
+	Printf(string, ...interface{})
+}
+
+// Client is the part of jsonapi.DiscordJSONClient that a Syncer uses
+type Client interface {
+	GetGlobalCommands(context.Context, string) ([]entity.ApplicationCommand, error)
+	CreateGlobalCommand(context.Context, string, entity.ApplicationCommand) (entity.ApplicationCommand, error)
+	EditGlobalCommand(context.Context, string, snowflake.Snowflake, entity.ApplicationCommand) (entity.ApplicationCommand, error)
+	DeleteGlobalCommand(context.Context, string, snowflake.Snowflake) error
+
+	GetGuildCommands(context.Context, string, snowflake.Snowflake) ([]entity.ApplicationCommand, error)
+	CreateGuildCommand(context.Context, string, snowflake.Snowflake, entity.ApplicationCommand) (entity.ApplicationCommand, error)
+	EditGuildCommand(context.Context, string, snowflake.Snowflake, snowflake.Snowflake, entity.ApplicationCommand) (entity.ApplicationCommand, error)
+	DeleteGuildCommand(context.Context, string, snowflake.Snowflake, snowflake.Snowflake) error
+}
+
+// ActionKind is the kind of change an Action makes
+type ActionKind int
+
+// These are the known ActionKind values
+const (
+	ActionCreate ActionKind = iota + 1
+	ActionEdit
+	ActionDelete
+)
+
+func (k ActionKind) String() string {
+	switch k {
+	case ActionCreate:
+		return "create"
+	case ActionEdit:
+		return "edit"
+	case ActionDelete:
+		return "delete"
+	default:
+		return fmt.Sprintf("ActionKind(%d)", int(k))
+	}
+}
+
+// Action is a single change to the registered commands
+type Action struct {
+	Kind ActionKind
+	Name string
+	// ID is the registered command, for edits and deletes
+	ID snowflake.Snowflake
+	// Command is the desired definition, for creates and edits
+	Command entity.ApplicationCommand
+	// Changes lists what differs, for edits
+	Changes []string
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case ActionCreate:
+		return fmt.Sprintf("+ create %s", a.Name)
+	case ActionEdit:
+		return fmt.Sprintf("~ edit %s (%s)", a.Name, strings.Join(a.Changes, ", "))
+	case ActionDelete:
+		return fmt.Sprintf("- delete %s", a.Name)
+	default:
+		return fmt.Sprintf("? %s %s", a.Kind, a.Name)
+	}
+}
+
+// Plan is the set of changes that brings the registered commands of a scope (global, or one guild)
+// in line with the desired definitions
+type Plan struct {
+	// GuildID is the guild whose commands are planned, or 0 for the global commands
+	GuildID snowflake.Snowflake
+	Actions []Action
+	// Unchanged lists the commands that already match
+	Unchanged []string
+	// Hash is the hash of the desired definitions
+	Hash string
+	// Cached reports that the desired definitions were last applied as they are, so no changes were planned
+	Cached bool
+
+	kept []entity.ApplicationCommand
+}
+
+// Empty reports whether the plan makes no changes
+func (p Plan) Empty() bool {
+	return len(p.Actions) == 0
+}
+
+func (p Plan) scope() string {
+	if p.GuildID == 0 {
+		return "global commands"
+	}
+
+	return fmt.Sprintf("guild %s commands", p.GuildID.ToString())
+}
+
+// String describes the plan, one line per action
+func (p Plan) String() string {
+	if p.Cached {
+		return fmt.Sprintf("%s: unchanged since the last sync\n", p.scope())
+	}
+
+	counts := map[ActionKind]int{}
+	for _, a := range p.Actions {
+		counts[a.Kind]++
+	}
+
+	b := strings.Builder{}
+	_, _ = b.WriteString(fmt.Sprintf("%s: %d to create, %d to edit, %d to delete, %d unchanged\n",
+		p.scope(), counts[ActionCreate], counts[ActionEdit], counts[ActionDelete], len(p.Unchanged)))
+
+	for _, a := range p.Actions {
+		_, _ = b.WriteString(fmt.Sprintf("  %s\n", a.String()))
+	}
+
+	return b.String()
+}
+
+// Syncer brings registered application commands in line with their desired definitions, creating, editing
+// and deleting only the commands that changed
+type Syncer struct {
+	deps   dependencies
+	client Client
+	appID  string
+	cache  HashCache
+
+	mu         sync.Mutex
+	registered map[string]registeredCommands
+}
+
+// registeredCommands are the commands registered in a scope by the last sync, with the hash of their definitions
+type registeredCommands struct {
+	hash string
+	cmds []entity.ApplicationCommand
+}
+
+// NewSyncer creates a Syncer for the application, with an in-memory hash cache
+func NewSyncer(deps dependencies, client Client, appID string) *Syncer {
+	return &Syncer{
+		deps:   deps,
+		client: client,
+		appID:  appID,
+		cache:  NewMemoryCache(),
+
+		registered: map[string]registeredCommands{},
+	}
+}
+
+// SetCache replaces the hash cache (for example with a FileCache, so that restarts make no changes)
+func (s *Syncer) SetCache(c HashCache) {
+	s.cache = c
+}
+
+func (s *Syncer) cacheKey(gid snowflake.Snowflake) string {
+	if gid == 0 {
+		return fmt.Sprintf("%s/global", s.appID)
+	}
+
+	return fmt.Sprintf("%s/guild/%s", s.appID, gid.ToString())
+}
+
+// PlanGlobal fetches the registered global commands and plans the changes to reach the desired ones
+func (s *Syncer) PlanGlobal(ctx context.Context, desired []entity.ApplicationCommand) (Plan, error) {
+	ctx, span := s.deps.Telemetry().StartSpan(ctx, "commandsync", "PlanGlobal")
+	defer span.End()
+
+	current, err := s.client.GetGlobalCommands(ctx, s.appID)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "could not get the global commands")
+	}
+
+	return Diff(0, current, desired)
+}
+
+// PlanGuild fetches the registered commands of a guild and plans the changes to reach the desired ones
+func (s *Syncer) PlanGuild(ctx context.Context, gid snowflake.Snowflake, desired []entity.ApplicationCommand) (Plan, error) {
+	ctx, span := s.deps.Telemetry().StartSpan(ctx, "commandsync", "PlanGuild", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	current, err := s.client.GetGuildCommands(ctx, s.appID, gid)
+	if err != nil {
+		return Plan{}, errors.Wrap(err, "could not get the guild commands", "gid", gid.ToString())
+	}
+
+	return Diff(gid, current, desired)
+}
+
+// Diff plans the changes that turn the current commands of a scope into the desired ones
+//
+// Commands are matched by type and name. Server-assigned fields are ignored, as are the order of
+// subcommands and of channel types
+func Diff(gid snowflake.Snowflake, current, desired []entity.ApplicationCommand) (Plan, error) {
+	p := Plan{GuildID: gid}
+
+	var err error
+	if p.Hash, err = Hash(desired); err != nil {
+		return p, err
+	}
+
+	byKey := make(map[string]entity.ApplicationCommand, len(current))
+	for _, c := range current {
+		byKey[commandKey(c)] = c
+	}
+
+	seen := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		key := commandKey(d)
+		if seen[key] {
+			return p, errors.Wrap(ErrDuplicateCommand, "could not plan", "name", d.Name)
+		}
+		seen[key] = true
+
+		c, ok := byKey[key]
+		if !ok {
+			p.Actions = append(p.Actions, Action{Kind: ActionCreate, Name: d.Name, Command: d})
+			continue
+		}
+
+		cc, err := canonicalize(c)
+		if err != nil {
+			return p, errors.Wrap(err, "could not canonicalize the current command")
+		}
+
+		dc, err := canonicalize(d)
+		if err != nil {
+			return p, errors.Wrap(err, "could not canonicalize the desired command")
+		}
+
+		if reflect.DeepEqual(cc, dc) {
+			p.Unchanged = append(p.Unchanged, d.Name)
+			p.kept = append(p.kept, c)
+			continue
+		}
+
+		p.Actions = append(p.Actions, Action{Kind: ActionEdit, Name: d.Name, ID: c.IDSnowflake, Command: d, Changes: changes(cc, dc)})
+	}
+
+	deletes := make([]Action, 0, len(current))
+	for _, c := range current {
+		if !seen[commandKey(c)] {
+			deletes = append(deletes, Action{Kind: ActionDelete, Name: c.Name, ID: c.IDSnowflake})
+		}
+	}
+	sort.SliceStable(deletes, func(i, j int) bool { return deletes[i].Name < deletes[j].Name })
+
+	// deleting first frees the names and the command slots for the creates
+	p.Actions = append(deletes, p.Actions...)
+
+	return p, nil
+}
+
+// Apply makes the changes in the plan, returning the registered commands of its scope afterwards
+//
+// It stops at the first failure. Once every change succeeds, the plan's hash is cached, so that syncing
+// the same definitions again makes no changes
+func (s *Syncer) Apply(ctx context.Context, p Plan) ([]entity.ApplicationCommand, error) {
+	ctx, span := s.deps.Telemetry().StartSpan(ctx, "commandsync", "Apply", telemetry.WithAttributes(telemetry.KVString("gid", p.GuildID.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, s.deps.Logger())
+
+	registered := append([]entity.ApplicationCommand(nil), p.kept...)
+
+	for _, a := range p.Actions {
+		level.Info(logger).Message("applying command change", "gid", p.GuildID.ToString(), "action", a.Kind.String(), "name", a.Name)
+
+		cmd, err := s.apply(ctx, p.GuildID, a)
+		if err != nil {
+			return registered, errors.Wrap(err, "could not apply command change", "action", a.Kind.String(), "name", a.Name)
+		}
+
+		if a.Kind != ActionDelete {
+			registered = append(registered, cmd)
+		}
+	}
+
+	if p.Cached {
+		return registered, nil
+	}
+
+	if err := s.cache.Set(s.cacheKey(p.GuildID), p.Hash); err != nil {
+		level.Error(logger).Err("could not cache command hash", err, "gid", p.GuildID.ToString())
+	}
+	s.remember(s.cacheKey(p.GuildID), p.Hash, registered)
+
+	return registered, nil
+}
+
+func (s *Syncer) apply(ctx context.Context, gid snowflake.Snowflake, a Action) (entity.ApplicationCommand, error) {
+	switch {
+	case a.Kind == ActionCreate && gid == 0:
+		return s.client.CreateGlobalCommand(ctx, s.appID, a.Command)
+	case a.Kind == ActionCreate:
+		return s.client.CreateGuildCommand(ctx, s.appID, gid, a.Command)
+	case a.Kind == ActionEdit && gid == 0:
+		return s.client.EditGlobalCommand(ctx, s.appID, a.ID, a.Command)
+	case a.Kind == ActionEdit:
+		return s.client.EditGuildCommand(ctx, s.appID, gid, a.ID, a.Command)
+	case a.Kind == ActionDelete && gid == 0:
+		return entity.ApplicationCommand{}, s.client.DeleteGlobalCommand(ctx, s.appID, a.ID)
+	case a.Kind == ActionDelete:
+		return entity.ApplicationCommand{}, s.client.DeleteGuildCommand(ctx, s.appID, gid, a.ID)
+	default:
+		return entity.ApplicationCommand{}, errors.Wrap(ErrUnknownAction, "could not apply", "kind", a.Kind.String())
+	}
+}
+
+// SyncGlobal brings the global commands in line with the desired ones
+//
+// The registered commands are returned. If the desired definitions hash the same as the last ones applied,
+// nothing is changed, and the registered commands are only fetched if this Syncer did not apply them itself
+// (for example, after a restart with a FileCache)
+func (s *Syncer) SyncGlobal(ctx context.Context, desired []entity.ApplicationCommand) (Plan, []entity.ApplicationCommand, error) {
+	return s.sync(ctx, 0, desired)
+}
+
+// SyncGuild brings the commands of a guild in line with the desired ones (see SyncGlobal)
+func (s *Syncer) SyncGuild(ctx context.Context, gid snowflake.Snowflake, desired []entity.ApplicationCommand) (Plan, []entity.ApplicationCommand, error) {
+	return s.sync(ctx, gid, desired)
+}
+
+func (s *Syncer) sync(ctx context.Context, gid snowflake.Snowflake, desired []entity.ApplicationCommand) (Plan, []entity.ApplicationCommand, error) {
+	ctx, span := s.deps.Telemetry().StartSpan(ctx, "commandsync", "Sync", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, s.deps.Logger())
+
+	hash, err := Hash(desired)
+	if err != nil {
+		return Plan{}, nil, err
+	}
+
+	if cached, ok := s.cache.Get(s.cacheKey(gid)); ok && cached == hash {
+		level.Debug(logger).Message("commands unchanged since the last sync", "gid", gid.ToString())
+
+		registered, err := s.registeredCommands(ctx, gid, hash)
+		return Plan{GuildID: gid, Hash: hash, Cached: true}, registered, err
+	}
+
+	var p Plan
+	if gid == 0 {
+		p, err = s.PlanGlobal(ctx, desired)
+	} else {
+		p, err = s.PlanGuild(ctx, gid, desired)
+	}
+	if err != nil {
+		return p, nil, err
+	}
+
+	level.Info(logger).Message("syncing commands", "gid", gid.ToString(), "changes", len(p.Actions), "unchanged", len(p.Unchanged))
+
+	registered, err := s.Apply(ctx, p)
+	return p, registered, err
+}
+
+func (s *Syncer) remember(key, hash string, cmds []entity.ApplicationCommand) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.registered[key] = registeredCommands{hash: hash, cmds: append([]entity.ApplicationCommand(nil), cmds...)}
+}
+
+// registeredCommands returns the commands registered by the last sync of the scope, if their definitions
+// had the given hash, fetching them if this Syncer did not register them itself
+func (s *Syncer) registeredCommands(ctx context.Context, gid snowflake.Snowflake, hash string) ([]entity.ApplicationCommand, error) {
+	key := s.cacheKey(gid)
+
+	s.mu.Lock()
+	r, ok := s.registered[key]
+	s.mu.Unlock()
+
+	if ok && r.hash == hash {
+		return append([]entity.ApplicationCommand(nil), r.cmds...), nil
+	}
+
+	var cmds []entity.ApplicationCommand
+	var err error
+	if gid == 0 {
+		cmds, err = s.client.GetGlobalCommands(ctx, s.appID)
+	} else {
+		cmds, err = s.client.GetGuildCommands(ctx, s.appID, gid)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get the registered commands", "gid", gid.ToString())
+	}
+
+	s.remember(key, hash, cmds)
+
+	return cmds, nil
+}
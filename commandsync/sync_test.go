@@ -0,0 +1,346 @@
+package commandsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+type nopLogger struct{}
+
+func (l nopLogger) Log(kv ...interface{}) error              { return nil }
+func (l nopLogger) Err(m string, e error, kv ...interface{}) {}
+func (l nopLogger) Message(m string, kv ...interface{})      {}
+func (l nopLogger) Printf(f string, a ...interface{})        {}
+
+type nopExporter struct{}
+
+func (nopExporter) ExportSpans(context.Context, []telemetry.ReadOnlySpan) error { return nil }
+func (nopExporter) Shutdown(context.Context) error                              { return nil }
+
+type testDeps struct {
+	telemeter *telemetry.Telemeter
+}
+
+func (d testDeps) Logger() Logger                  { return nopLogger{} }
+func (d testDeps) Telemetry() *telemetry.Telemeter { return d.telemeter }
+
+func newTestDeps() testDeps {
+	return testDeps{telemeter: telemetry.NewTelemeter("test", "test", "test", nopExporter{}, nonrecording.NewNoopMeterProvider(), 1.0)}
+}
+
+// fakeClient keeps registered global commands in memory and records the calls made
+type fakeClient struct {
+	cmds   []entity.ApplicationCommand
+	nextID snowflake.Snowflake
+	calls  []string
+}
+
+var _ Client = (*fakeClient)(nil)
+
+func (c *fakeClient) GetGlobalCommands(context.Context, string) ([]entity.ApplicationCommand, error) {
+	c.calls = append(c.calls, "get")
+
+	// round-trip through json, as the real client does
+	b, err := json.Marshal(c.cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []entity.ApplicationCommand
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+
+	for i := range out {
+		if err := out[i].Snowflakify(); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (c *fakeClient) CreateGlobalCommand(_ context.Context, _ string, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	c.calls = append(c.calls, "create "+cmd.Name)
+
+	c.nextID++
+	cmd.ID, cmd.IDSnowflake = c.nextID.ToString(), c.nextID
+	cmd.ApplicationID, cmd.ApplicationIDSnowflake = "99", 99
+	cmd.Version, cmd.VersionSnowflake = c.nextID.ToString(), c.nextID
+	c.cmds = append(c.cmds, cmd)
+
+	return cmd, nil
+}
+
+func (c *fakeClient) EditGlobalCommand(_ context.Context, _ string, id snowflake.Snowflake, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	c.calls = append(c.calls, "edit "+cmd.Name)
+
+	for i := range c.cmds {
+		if c.cmds[i].IDSnowflake == id {
+			cmd.ID, cmd.IDSnowflake = c.cmds[i].ID, id
+			c.cmds[i] = cmd
+			return cmd, nil
+		}
+	}
+
+	return cmd, fmt.Errorf("no command %v", id)
+}
+
+func (c *fakeClient) DeleteGlobalCommand(_ context.Context, _ string, id snowflake.Snowflake) error {
+	c.calls = append(c.calls, fmt.Sprintf("delete %v", id))
+
+	for i := range c.cmds {
+		if c.cmds[i].IDSnowflake == id {
+			c.cmds = append(c.cmds[:i], c.cmds[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no command %v", id)
+}
+
+func (c *fakeClient) GetGuildCommands(context.Context, string, snowflake.Snowflake) ([]entity.ApplicationCommand, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) CreateGuildCommand(context.Context, string, snowflake.Snowflake, entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	return entity.ApplicationCommand{}, nil
+}
+
+func (c *fakeClient) EditGuildCommand(context.Context, string, snowflake.Snowflake, snowflake.Snowflake, entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	return entity.ApplicationCommand{}, nil
+}
+
+func (c *fakeClient) DeleteGuildCommand(context.Context, string, snowflake.Snowflake, snowflake.Snowflake) error {
+	return nil
+}
+
+func pingCommand() entity.ApplicationCommand {
+	return entity.ApplicationCommand{Type: entity.CmdTypeChatInput, Name: "ping", Description: "Ping the bot", DefaultPermission: true}
+}
+
+func eventCommand() entity.ApplicationCommand {
+	return entity.ApplicationCommand{
+		Type:              entity.CmdTypeChatInput,
+		Name:              "event",
+		Description:       "Manage events",
+		DefaultPermission: true,
+		Options: []entity.ApplicationCommandOption{
+			{Type: entity.OptTypeSubCommand, Name: "create", Description: "Create an event", Options: []entity.ApplicationCommandOption{
+				{Type: entity.OptTypeString, Name: "kind", Description: "The kind", Choices: []entity.ApplicationCommandOptionChoice{
+					{Name: "Raid", Type: entity.OptTypeString, ValueString: "raid"},
+				}},
+				{Type: entity.OptTypeChannel, Name: "where", Description: "Where", ChannelTypes: []entity.ChannelType{5, 0}},
+			}},
+			{Type: entity.OptTypeSubCommand, Name: "cancel", Description: "Cancel an event"},
+		},
+	}
+}
+
+func actionStrings(p Plan) []string {
+	out := make([]string, 0, len(p.Actions))
+	for _, a := range p.Actions {
+		out = append(out, a.String())
+	}
+	return out
+}
+
+func TestSyncer_SyncGlobal(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeClient{}
+	s := NewSyncer(newTestDeps(), client, "app")
+	ctx := context.Background()
+
+	cachePath := filepath.Join(t.TempDir(), "commands.json")
+	s.SetCache(NewFileCache(cachePath))
+
+	// first deployment creates everything
+	p, registered, err := s.SyncGlobal(ctx, []entity.ApplicationCommand{pingCommand(), eventCommand()})
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if want := []string{"+ create ping", "+ create event"}; !reflect.DeepEqual(actionStrings(p), want) {
+		t.Errorf("SyncGlobal() actions = %v, want %v", actionStrings(p), want)
+	}
+
+	if len(registered) != 2 || registered[0].IDSnowflake == 0 {
+		t.Errorf("SyncGlobal() registered = %+v", registered)
+	}
+
+	// the same definitions in another order make no calls, and still return the registered commands
+	client.calls = nil
+	p, cached, err := s.SyncGlobal(ctx, []entity.ApplicationCommand{eventCommand(), pingCommand()})
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if !p.Cached || len(client.calls) != 0 {
+		t.Errorf("SyncGlobal() cached = %v, calls = %v", p.Cached, client.calls)
+	}
+
+	if !reflect.DeepEqual(cached, registered) {
+		t.Errorf("SyncGlobal() cached registered = %+v, want %+v", cached, registered)
+	}
+
+	// after a restart, the cached hash still skips the changes, but the registered commands are fetched once
+	restarted := NewSyncer(newTestDeps(), client, "app")
+	restarted.SetCache(NewFileCache(cachePath))
+
+	client.calls = nil
+	p, cached, err = restarted.SyncGlobal(ctx, []entity.ApplicationCommand{pingCommand(), eventCommand()})
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if !p.Cached || !reflect.DeepEqual(client.calls, []string{"get"}) {
+		t.Errorf("SyncGlobal() after restart cached = %v, calls = %v", p.Cached, client.calls)
+	}
+
+	if len(cached) != 2 || cached[0].IDSnowflake == 0 || cached[0].ApplicationIDSnowflake == 0 || cached[0].Version == "" {
+		t.Errorf("SyncGlobal() after restart registered = %+v", cached)
+	}
+
+	client.calls = nil
+	if _, _, err = restarted.SyncGlobal(ctx, []entity.ApplicationCommand{pingCommand(), eventCommand()}); err != nil || len(client.calls) != 0 {
+		t.Errorf("SyncGlobal() after the fetch error = %v, calls = %v", err, client.calls)
+	}
+
+	// a fresh syncer (no cache) finds nothing to change, despite the reordered subcommands and channel types
+	fresh := NewSyncer(newTestDeps(), client, "app")
+	reordered := eventCommand()
+	reordered.Options[0], reordered.Options[1] = reordered.Options[1], reordered.Options[0]
+	reordered.Options[1].Options[1].ChannelTypes = []entity.ChannelType{0, 5}
+
+	client.calls = nil
+	p, _, err = fresh.SyncGlobal(ctx, []entity.ApplicationCommand{pingCommand(), reordered})
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if !p.Empty() || len(p.Unchanged) != 2 || !reflect.DeepEqual(client.calls, []string{"get"}) {
+		t.Errorf("SyncGlobal() plan = %v, calls = %v", p, client.calls)
+	}
+
+	// changes edit and delete only what changed
+	changed := eventCommand()
+	changed.Description = "Manage raids"
+	changed.Options[0].Options[0].Choices = append(changed.Options[0].Options[0].Choices,
+		entity.ApplicationCommandOptionChoice{Name: "Dungeon", Type: entity.OptTypeString, ValueString: "dungeon"})
+
+	client.calls = nil
+	p, _, err = s.SyncGlobal(ctx, []entity.ApplicationCommand{changed})
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if want := []string{"- delete ping", "~ edit event (description, ~option create kind)"}; !reflect.DeepEqual(actionStrings(p), want) {
+		t.Errorf("SyncGlobal() actions = %v, want %v", actionStrings(p), want)
+	}
+
+	if want := []string{"get", "delete 1", "edit event"}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("SyncGlobal() calls = %v, want %v", client.calls, want)
+	}
+
+	if !strings.Contains(p.String(), "0 to create, 1 to edit, 1 to delete, 0 unchanged") {
+		t.Errorf("Plan.String() = %q", p.String())
+	}
+}
+
+func TestSyncer_SyncGlobal_localizations(t *testing.T) {
+	t.Parallel()
+
+	localized := func() []entity.ApplicationCommand {
+		ping := pingCommand()
+		ping.NameLocalizations = entity.Localizations{"de": "pingen", "fr": "pinger"}
+		ping.DescriptionLocalizations = entity.Localizations{"de": "Pingt den Bot"}
+
+		event := eventCommand()
+		event.Options[0].DescriptionLocalizations = entity.Localizations{"de": "Ein Event erstellen"}
+		event.Options[0].Options[0].Choices[0].NameLocalizations = entity.Localizations{"de": "Schlachtzug"}
+
+		return []entity.ApplicationCommand{ping, event}
+	}
+
+	client := &fakeClient{}
+	ctx := context.Background()
+
+	if _, _, err := NewSyncer(newTestDeps(), client, "app").SyncGlobal(ctx, localized()); err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	// the fetched commands carry their localizations, so a fresh syncer finds nothing to change
+	client.calls = nil
+	p, _, err := NewSyncer(newTestDeps(), client, "app").SyncGlobal(ctx, localized())
+	if err != nil {
+		t.Fatalf("SyncGlobal() error = %v", err)
+	}
+
+	if !p.Empty() || len(p.Unchanged) != 2 || !reflect.DeepEqual(client.calls, []string{"get"}) {
+		t.Errorf("SyncGlobal() plan = %v, calls = %v", p, client.calls)
+	}
+}
+
+func TestDiff_duplicate(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Diff(0, nil, []entity.ApplicationCommand{pingCommand(), pingCommand()}); err == nil {
+		t.Errorf("Diff() expected an error for duplicate commands")
+	}
+
+	user := pingCommand()
+	user.Type = entity.CmdTypeUser
+	user.Description = ""
+
+	if _, err := Diff(0, nil, []entity.ApplicationCommand{pingCommand(), user}); err != nil {
+		t.Errorf("Diff() error = %v for commands of different types", err)
+	}
+}
+
+func TestDiff_localizations(t *testing.T) {
+	t.Parallel()
+
+	current := pingCommand()
+	current.IDSnowflake = 1
+	current.NameLocalizations = entity.Localizations{"de": "pingen", "fr": "pinger"}
+
+	desired := pingCommand()
+	desired.NameLocalizations = entity.Localizations{"fr": "pinger", "de": "pingen"}
+
+	h1, err := Hash([]entity.ApplicationCommand{current})
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	h2, err := Hash([]entity.ApplicationCommand{desired})
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if h1 != h2 {
+		t.Errorf("Hash() differs for equal localizations: %s != %s", h1, h2)
+	}
+
+	desired.DescriptionLocalizations = entity.Localizations{"de": "Pingt den Bot"}
+
+	p, err := Diff(0, []entity.ApplicationCommand{current}, []entity.ApplicationCommand{desired})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	if want := []string{"~ edit ping (description_localizations)"}; !reflect.DeepEqual(actionStrings(p), want) {
+		t.Errorf("Diff() actions = %v, want %v", actionStrings(p), want)
+	}
+}
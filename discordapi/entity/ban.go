@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+)
+
+// Ban is the data about a guild ban received from the json api
+type Ban struct {
+	Reason string `json:"reason"`
+	User   User   `json:"user"`
+}
+
+// Snowflakify converts snowflake strings into real sowflakes
+func (b *Ban) Snowflakify() error {
+	if err := b.User.Snowflakify(); err != nil {
+		return errors.Wrap(err, "could not snowflakify User")
+	}
+
+	return nil
+}
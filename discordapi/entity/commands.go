@@ -53,6 +53,9 @@ func ApplicationCommandTypeFromElement(e etfapi.Element) (ApplicationCommandType
 	return t, errors.Wrap(err, "could not unmarshal ApplicationCommandType")
 }
 
+// Localizations maps discord locales (like "de" or "es-ES") to the text to show users of that locale
+type Localizations map[string]string
+
 // ErrBadOptType represents an error understanding an ApplicationCommandOptionType
 var ErrBadOptType = errors.New("bad option type value")
 
@@ -68,6 +71,9 @@ type ApplicationCommand struct {
 	DefaultPermission bool                       `json:"default_permission"`
 	Version           string                     `json:"version,omitempty"`
 
+	NameLocalizations        Localizations `json:"name_localizations,omitempty"`
+	DescriptionLocalizations Localizations `json:"description_localizations,omitempty"`
+
 	IDSnowflake            snowflake.Snowflake `json:"-"`
 	ApplicationIDSnowflake snowflake.Snowflake `json:"-"`
 	GuildIDSnowflake       snowflake.Snowflake `json:"-"`
@@ -122,6 +128,13 @@ type ApplicationCommandOption struct {
 	Options      []ApplicationCommandOption       `json:"options,omitempty"`
 	ChannelTypes []ChannelType                    `json:"channel_types,omitempty"`
 	Autocomplete bool                             `json:"autocomplete,omitempty"`
+	MinValue     *float64                         `json:"min_value,omitempty"`  // integer and number options
+	MaxValue     *float64                         `json:"max_value,omitempty"`  // integer and number options
+	MinLength    *int                             `json:"min_length,omitempty"` // string options
+	MaxLength    *int                             `json:"max_length,omitempty"` // string options
+
+	NameLocalizations        Localizations `json:"name_localizations,omitempty"`
+	DescriptionLocalizations Localizations `json:"description_localizations,omitempty"`
 }
 
 // Snowflakify converts snowflake strings into real sowflakes
@@ -140,8 +153,9 @@ func (o *ApplicationCommandOption) Snowflakify() error {
 
 // ApplicationCommandOptionChoice represents an interaction command select choice
 type ApplicationCommandOptionChoice struct {
-	Name  string          `json:"name"`
-	Value json.RawMessage `json:"value"`
+	Name              string          `json:"name"`
+	NameLocalizations Localizations   `json:"name_localizations,omitempty"`
+	Value             json.RawMessage `json:"value"`
 
 	Type        ApplicationCommandOptionType `json:"-"`
 	ValueString string                       `json:"-"`
@@ -154,8 +168,11 @@ func (c *ApplicationCommandOptionChoice) MarshalJSON() ([]byte, error) {
 	var b2 []byte
 	var err error
 
-	if err = c.FillValue(); err != nil {
-		return nil, errors.Wrap(err, "could not FillValue")
+	// choices unmarshaled from json only have the raw value
+	if c.Type != 0 || len(c.Value) == 0 {
+		if err = c.FillValue(); err != nil {
+			return nil, errors.Wrap(err, "could not FillValue")
+		}
 	}
 
 	b := &bytes.Buffer{}
@@ -172,6 +189,21 @@ func (c *ApplicationCommandOptionChoice) MarshalJSON() ([]byte, error) {
 		return nil, errors.Wrap(err, "could not write to buffer")
 	}
 
+	if len(c.NameLocalizations) > 0 {
+		if _, err = b.WriteString(`,"name_localizations":`); err != nil {
+			return nil, errors.Wrap(err, "could not write to buffer")
+		}
+
+		b2, err = json.Marshal(c.NameLocalizations)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not marshal name_localizations")
+		}
+
+		if _, err = b.Write(b2); err != nil {
+			return nil, errors.Wrap(err, "could not write to buffer")
+		}
+	}
+
 	if _, err = b.WriteString(`,"value":`); err != nil {
 		return nil, errors.Wrap(err, "could not write to buffer")
 	}
@@ -14,6 +14,7 @@ func TestApplicationCommandOptionChoice_MarshalJSON(t *testing.T) {
 		ValueString string
 		ValueInt    int
 		ValueNumber float64
+		NameLocs    Localizations
 	}
 	tests := []struct {
 		name    string
@@ -51,6 +52,17 @@ func TestApplicationCommandOptionChoice_MarshalJSON(t *testing.T) {
 			want:    []byte(`{"name":"n","value":3.14}`),
 			wantErr: false,
 		},
+		{
+			name: "localized",
+			fields: fields{
+				Name:        "s",
+				Type:        OptTypeString,
+				ValueString: "sv",
+				NameLocs:    Localizations{"fr": "s-fr"},
+			},
+			want:    []byte(`{"name":"s","name_localizations":{"fr":"s-fr"},"value":"sv"}`),
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -62,6 +74,8 @@ func TestApplicationCommandOptionChoice_MarshalJSON(t *testing.T) {
 				ValueString: tt.fields.ValueString,
 				ValueInt:    tt.fields.ValueInt,
 				ValueNumber: tt.fields.ValueNumber,
+
+				NameLocalizations: tt.fields.NameLocs,
 			}
 			got, err := c.MarshalJSON()
 			if (err != nil) != tt.wantErr {
@@ -0,0 +1,497 @@
+package entity
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+)
+
+// ComponentType represents the type of a message component
+type ComponentType int
+
+// These are the known ComponentType values
+const (
+	ComponentActionRow         ComponentType = 1
+	ComponentButton            ComponentType = 2
+	ComponentStringSelect      ComponentType = 3
+	ComponentTextInput         ComponentType = 4
+	ComponentUserSelect        ComponentType = 5
+	ComponentRoleSelect        ComponentType = 6
+	ComponentMentionableSelect ComponentType = 7
+	ComponentChannelSelect     ComponentType = 8
+)
+
+// ComponentTypeFromElement generates a ComponentType representation from the given
+// component-type Element
+func ComponentTypeFromElement(e etfapi.Element) (ComponentType, error) {
+	temp, err := e.ToInt()
+	t := ComponentType(temp)
+	return t, errors.Wrap(err, "could not unmarshal ComponentType", "raw", e.Val)
+}
+
+// IsSelect determines if the component type is one of the select menus
+func (t ComponentType) IsSelect() bool {
+	switch t {
+	case ComponentStringSelect, ComponentUserSelect, ComponentRoleSelect, ComponentMentionableSelect, ComponentChannelSelect:
+		return true
+	default:
+		return false
+	}
+}
+
+// ButtonStyle represents the style of a button component
+type ButtonStyle int
+
+// These are the known ButtonStyle values
+const (
+	ButtonPrimary   ButtonStyle = 1
+	ButtonSecondary ButtonStyle = 2
+	ButtonSuccess   ButtonStyle = 3
+	ButtonDanger    ButtonStyle = 4
+	ButtonLink      ButtonStyle = 5
+)
+
+// TextInputStyle represents the style of a text input component
+type TextInputStyle int
+
+// These are the known TextInputStyle values
+const (
+	TextInputShort     TextInputStyle = 1
+	TextInputParagraph TextInputStyle = 2
+)
+
+// These are the limits discord imposes on components
+const (
+	MaxActionRows           = 5
+	MaxActionRowButtons     = 5
+	MaxSelectOptions        = 25
+	MaxComponentCustomIDLen = 100
+	MaxModalTextInputs      = 5
+)
+
+// ComponentEmoji is the emoji shown on a button or select option
+//
+// Use Name alone for a unicode emoji, or ID and Name for a custom emoji
+type ComponentEmoji struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Animated bool   `json:"animated,omitempty"`
+}
+
+// SelectOption is a choice in a string select menu
+type SelectOption struct {
+	Label       string          `json:"label"`
+	Value       string          `json:"value"`
+	Description string          `json:"description,omitempty"`
+	Emoji       *ComponentEmoji `json:"emoji,omitempty"`
+	Default     bool            `json:"default,omitempty"`
+}
+
+// Component is a message component (action row, button, select menu or text input)
+//
+// Only the fields relevant to the Type are sent; an action row holds the other components
+// in Components. The constructor functions below fill in the required fields for each type.
+// Style holds the style of a button; a text input keeps its TextInputStyle there too (see InputStyle).
+// Value holds the text submitted in a text input of a modal submit interaction
+type Component struct {
+	Type         ComponentType   `json:"type"`
+	CustomID     string          `json:"custom_id,omitempty"`
+	Disabled     bool            `json:"disabled,omitempty"`
+	Style        ButtonStyle     `json:"style,omitempty"`
+	Label        string          `json:"label,omitempty"`
+	Emoji        *ComponentEmoji `json:"emoji,omitempty"`
+	URL          string          `json:"url,omitempty"`
+	Options      []SelectOption  `json:"options,omitempty"`
+	ChannelTypes []ChannelType   `json:"channel_types,omitempty"`
+	Placeholder  string          `json:"placeholder,omitempty"`
+	MinValues    *int            `json:"min_values,omitempty"`
+	MaxValues    int             `json:"max_values,omitempty"`
+	MinLength    int             `json:"min_length,omitempty"`
+	MaxLength    int             `json:"max_length,omitempty"`
+	Required     *bool           `json:"required,omitempty"`
+	Value        string          `json:"value,omitempty"`
+	Components   []Component     `json:"components,omitempty"`
+}
+
+// ActionRow creates an action row holding the given components
+func ActionRow(components ...Component) Component {
+	return Component{
+		Type:       ComponentActionRow,
+		Components: components,
+	}
+}
+
+// Button creates a button that sends an interaction with the given custom id when clicked
+func Button(style ButtonStyle, label, customID string) Component {
+	return Component{
+		Type:     ComponentButton,
+		Style:    style,
+		Label:    label,
+		CustomID: customID,
+	}
+}
+
+// LinkButton creates a button that opens the given url when clicked (it sends no interaction)
+func LinkButton(label, url string) Component {
+	return Component{
+		Type:  ComponentButton,
+		Style: ButtonLink,
+		Label: label,
+		URL:   url,
+	}
+}
+
+// StringSelect creates a select menu offering the given options
+func StringSelect(customID string, options ...SelectOption) Component {
+	return Component{
+		Type:     ComponentStringSelect,
+		CustomID: customID,
+		Options:  options,
+	}
+}
+
+// UserSelect creates a select menu offering the users of the guild
+func UserSelect(customID string) Component {
+	return Component{
+		Type:     ComponentUserSelect,
+		CustomID: customID,
+	}
+}
+
+// RoleSelect creates a select menu offering the roles of the guild
+func RoleSelect(customID string) Component {
+	return Component{
+		Type:     ComponentRoleSelect,
+		CustomID: customID,
+	}
+}
+
+// MentionableSelect creates a select menu offering the users and roles of the guild
+func MentionableSelect(customID string) Component {
+	return Component{
+		Type:     ComponentMentionableSelect,
+		CustomID: customID,
+	}
+}
+
+// ChannelSelect creates a select menu offering the channels of the guild, optionally restricted to the given types
+func ChannelSelect(customID string, types ...ChannelType) Component {
+	return Component{
+		Type:         ComponentChannelSelect,
+		CustomID:     customID,
+		ChannelTypes: types,
+	}
+}
+
+// TextInput creates a text input for a modal
+func TextInput(customID, label string, style TextInputStyle) Component {
+	return Component{
+		Type:     ComponentTextInput,
+		CustomID: customID,
+		Label:    label,
+		Style:    ButtonStyle(style),
+	}
+}
+
+// InputStyle returns the style of a text input
+func (c Component) InputStyle() TextInputStyle {
+	return TextInputStyle(c.Style)
+}
+
+// WithLength sets the minimum and maximum length of the text in a text input
+func (c Component) WithLength(minLength, maxLength int) Component {
+	c.MinLength = minLength
+	c.MaxLength = maxLength
+	return c
+}
+
+// WithRequired sets whether a text input must be filled in (discord's default is true)
+func (c Component) WithRequired(required bool) Component {
+	c.Required = &required
+	return c
+}
+
+// WithValues sets the minimum and maximum number of values that may be chosen in a select menu
+func (c Component) WithValues(minValues, maxValues int) Component {
+	c.MinValues = &minValues
+	c.MaxValues = maxValues
+	return c
+}
+
+// WithEmoji sets the emoji of a button
+func (c Component) WithEmoji(e ComponentEmoji) Component {
+	c.Emoji = &e
+	return c
+}
+
+// ComponentEmojiFromElement generates a new ComponentEmoji object from the given Element
+func ComponentEmojiFromElement(e etfapi.Element) (ComponentEmoji, error) {
+	var ce ComponentEmoji
+
+	eMap, err := e.ToMap()
+	if err != nil {
+		return ce, errors.Wrap(err, "could not inflate ComponentEmoji from non-map")
+	}
+
+	e2, ok := eMap["id"]
+	if ok && !e2.IsNil() {
+		id, err := etfapi.SnowflakeFromUnknownElement(e2)
+		if err != nil {
+			return ce, errors.Wrap(err, "could not get id snowflake.Snowflake")
+		}
+		ce.ID = id.ToString()
+	}
+
+	e2, ok = eMap["name"]
+	if ok && !e2.IsNil() {
+		ce.Name, err = e2.ToString()
+		if err != nil {
+			return ce, errors.Wrap(err, "could not get name")
+		}
+	}
+
+	e2, ok = eMap["animated"]
+	if ok {
+		ce.Animated, err = e2.ToBool()
+		if err != nil {
+			return ce, errors.Wrap(err, "could not get animated")
+		}
+	}
+
+	return ce, nil
+}
+
+// SelectOptionFromElement generates a new SelectOption object from the given Element
+func SelectOptionFromElement(e etfapi.Element) (SelectOption, error) {
+	var o SelectOption
+
+	eMap, err := e.ToMap()
+	if err != nil {
+		return o, errors.Wrap(err, "could not inflate SelectOption from non-map")
+	}
+
+	e2 := eMap["label"]
+	o.Label, err = e2.ToString()
+	if err != nil {
+		return o, errors.Wrap(err, "could not get label")
+	}
+
+	e2 = eMap["value"]
+	o.Value, err = e2.ToString()
+	if err != nil {
+		return o, errors.Wrap(err, "could not get value")
+	}
+
+	e2, ok := eMap["description"]
+	if ok && !e2.IsNil() {
+		o.Description, err = e2.ToString()
+		if err != nil {
+			return o, errors.Wrap(err, "could not get description")
+		}
+	}
+
+	e2, ok = eMap["emoji"]
+	if ok && !e2.IsNil() {
+		ce, err := ComponentEmojiFromElement(e2)
+		if err != nil {
+			return o, errors.Wrap(err, "could not inflate emoji")
+		}
+		o.Emoji = &ce
+	}
+
+	e2, ok = eMap["default"]
+	if ok {
+		o.Default, err = e2.ToBool()
+		if err != nil {
+			return o, errors.Wrap(err, "could not get default")
+		}
+	}
+
+	return o, nil
+}
+
+// ComponentFromElement generates a new Component object from the given Element
+func ComponentFromElement(e etfapi.Element) (Component, error) {
+	var c Component
+
+	eMap, err := e.ToMap()
+	if err != nil {
+		return c, errors.Wrap(err, "could not inflate Component from non-map")
+	}
+
+	c.Type, err = ComponentTypeFromElement(eMap["type"])
+	if err != nil {
+		return c, errors.Wrap(err, "could not get type")
+	}
+
+	e2, ok := eMap["custom_id"]
+	if ok && !e2.IsNil() {
+		c.CustomID, err = e2.ToString()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get custom_id")
+		}
+	}
+
+	e2, ok = eMap["label"]
+	if ok && !e2.IsNil() {
+		c.Label, err = e2.ToString()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get label")
+		}
+	}
+
+	e2, ok = eMap["url"]
+	if ok && !e2.IsNil() {
+		c.URL, err = e2.ToString()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get url")
+		}
+	}
+
+	e2, ok = eMap["value"]
+	if ok && !e2.IsNil() {
+		c.Value, err = e2.ToString()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get value")
+		}
+	}
+
+	e2, ok = eMap["placeholder"]
+	if ok && !e2.IsNil() {
+		c.Placeholder, err = e2.ToString()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get placeholder")
+		}
+	}
+
+	e2, ok = eMap["disabled"]
+	if ok {
+		c.Disabled, err = e2.ToBool()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get disabled")
+		}
+	}
+
+	e2, ok = eMap["style"]
+	if ok && !e2.IsNil() {
+		style, err := e2.ToInt()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get style")
+		}
+		c.Style = ButtonStyle(style)
+	}
+
+	e2, ok = eMap["emoji"]
+	if ok && !e2.IsNil() {
+		ce, err := ComponentEmojiFromElement(e2)
+		if err != nil {
+			return c, errors.Wrap(err, "could not inflate emoji")
+		}
+		c.Emoji = &ce
+	}
+
+	e2, ok = eMap["min_values"]
+	if ok && !e2.IsNil() {
+		v, err := e2.ToInt()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get min_values")
+		}
+		c.MinValues = &v
+	}
+
+	e2, ok = eMap["max_values"]
+	if ok && !e2.IsNil() {
+		c.MaxValues, err = e2.ToInt()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get max_values")
+		}
+	}
+
+	e2, ok = eMap["min_length"]
+	if ok && !e2.IsNil() {
+		c.MinLength, err = e2.ToInt()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get min_length")
+		}
+	}
+
+	e2, ok = eMap["max_length"]
+	if ok && !e2.IsNil() {
+		c.MaxLength, err = e2.ToInt()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get max_length")
+		}
+	}
+
+	e2, ok = eMap["required"]
+	if ok && !e2.IsNil() {
+		v, err := e2.ToBool()
+		if err != nil {
+			return c, errors.Wrap(err, "could not get required")
+		}
+		c.Required = &v
+	}
+
+	e2, ok = eMap["channel_types"]
+	if ok && !e2.IsNil() {
+		el, err := e2.ToList()
+		if err != nil {
+			return c, errors.Wrap(err, "channel_types was not a list")
+		}
+
+		c.ChannelTypes = make([]ChannelType, 0, len(el))
+		for _, e3 := range el {
+			ct, err := ChannelTypeFromElement(e3)
+			if err != nil {
+				return c, errors.Wrap(err, "could not inflate channel type")
+			}
+			c.ChannelTypes = append(c.ChannelTypes, ct)
+		}
+	}
+
+	e2, ok = eMap["options"]
+	if ok && !e2.IsNil() {
+		el, err := e2.ToList()
+		if err != nil {
+			return c, errors.Wrap(err, "options was not a list")
+		}
+
+		c.Options = make([]SelectOption, 0, len(el))
+		for _, e3 := range el {
+			o, err := SelectOptionFromElement(e3)
+			if err != nil {
+				return c, errors.Wrap(err, "could not inflate select option")
+			}
+			c.Options = append(c.Options, o)
+		}
+	}
+
+	e2, ok = eMap["components"]
+	if ok && !e2.IsNil() {
+		c.Components, err = ComponentsFromElement(e2)
+		if err != nil {
+			return c, errors.Wrap(err, "could not inflate child components")
+		}
+	}
+
+	return c, nil
+}
+
+// ComponentsFromElement generates a list of Component objects from the given list Element
+func ComponentsFromElement(e etfapi.Element) ([]Component, error) {
+	el, err := e.ToList()
+	if err != nil {
+		return nil, errors.Wrap(err, "components was not a list")
+	}
+
+	cs := make([]Component, 0, len(el))
+	for _, e2 := range el {
+		c, err := ComponentFromElement(e2)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not inflate component")
+		}
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
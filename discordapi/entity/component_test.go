@@ -0,0 +1,230 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+)
+
+func mustElement(e etfapi.Element, err error) etfapi.Element {
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func etfString(s string) etfapi.Element { return mustElement(etfapi.NewStringElement(s)) }
+func etfInt(i int) etfapi.Element       { return mustElement(etfapi.NewInt8Element(i)) }
+func etfBool(b bool) etfapi.Element     { return mustElement(etfapi.NewBoolElement(b)) }
+func etfID(i int64) etfapi.Element      { return mustElement(etfapi.NewSmallBigElement(i)) }
+
+func etfMap(m map[string]etfapi.Element) etfapi.Element {
+	return mustElement(etfapi.NewMapElement(m))
+}
+
+func etfList(l ...etfapi.Element) etfapi.Element {
+	return mustElement(etfapi.NewListElement(l))
+}
+
+func TestComponentFromElement(t *testing.T) {
+	t.Parallel()
+
+	one := 1
+
+	tests := []struct {
+		name string
+		etf  etfapi.Element
+		json string
+		want Component
+	}{
+		{
+			name: "action row of buttons",
+			etf: etfMap(map[string]etfapi.Element{
+				"type": etfInt(1),
+				"components": etfList(
+					etfMap(map[string]etfapi.Element{
+						"type":      etfInt(2),
+						"custom_id": etfString("poll:yes"),
+						"style":     etfInt(3),
+						"label":     etfString("Yes"),
+						"emoji":     etfMap(map[string]etfapi.Element{"id": etfID(123), "name": etfString("blob"), "animated": etfBool(true)}),
+					}),
+					etfMap(map[string]etfapi.Element{
+						"type":     etfInt(2),
+						"style":    etfInt(5),
+						"label":    etfString("Docs"),
+						"url":      etfString("https://example.com"),
+						"disabled": etfBool(true),
+					}),
+				),
+			}),
+			json: `{"type": 1, "components": [
+				{"type": 2, "custom_id": "poll:yes", "style": 3, "label": "Yes", "emoji": {"id": "123", "name": "blob", "animated": true}},
+				{"type": 2, "style": 5, "label": "Docs", "url": "https://example.com", "disabled": true}
+			]}`,
+			want: ActionRow(
+				Button(ButtonSuccess, "Yes", "poll:yes").WithEmoji(ComponentEmoji{ID: "123", Name: "blob", Animated: true}),
+				Component{Type: ComponentButton, Style: ButtonLink, Label: "Docs", URL: "https://example.com", Disabled: true},
+			),
+		},
+		{
+			name: "string select",
+			etf: etfMap(map[string]etfapi.Element{
+				"type":        etfInt(3),
+				"custom_id":   etfString("color"),
+				"placeholder": etfString("Pick one"),
+				"min_values":  etfInt(1),
+				"max_values":  etfInt(2),
+				"options": etfList(
+					etfMap(map[string]etfapi.Element{"label": etfString("Red"), "value": etfString("r"), "default": etfBool(true)}),
+					etfMap(map[string]etfapi.Element{"label": etfString("Blue"), "value": etfString("b"), "description": etfString("cool")}),
+				),
+			}),
+			json: `{"type": 3, "custom_id": "color", "placeholder": "Pick one", "min_values": 1, "max_values": 2, "options": [
+				{"label": "Red", "value": "r", "default": true},
+				{"label": "Blue", "value": "b", "description": "cool"}
+			]}`,
+			want: Component{
+				Type:        ComponentStringSelect,
+				CustomID:    "color",
+				Placeholder: "Pick one",
+				MinValues:   &one,
+				MaxValues:   2,
+				Options: []SelectOption{
+					{Label: "Red", Value: "r", Default: true},
+					{Label: "Blue", Value: "b", Description: "cool"},
+				},
+			},
+		},
+		{
+			name: "channel select",
+			etf: etfMap(map[string]etfapi.Element{
+				"type":          etfInt(8),
+				"custom_id":     etfString("where"),
+				"channel_types": etfList(etfInt(0), etfInt(5)),
+			}),
+			json: `{"type": 8, "custom_id": "where", "channel_types": [0, 5]}`,
+			want: ChannelSelect("where", ChannelType(0), ChannelType(5)),
+		},
+		{
+			name: "submitted text input",
+			etf: etfMap(map[string]etfapi.Element{
+				"type":      etfInt(4),
+				"custom_id": etfString("reason"),
+				"value":     etfString("spam"),
+			}),
+			json: `{"type": 4, "custom_id": "reason", "value": "spam"}`,
+			want: Component{Type: ComponentTextInput, CustomID: "reason", Value: "spam"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ComponentFromElement(tt.etf)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got, "etf")
+
+			var gotJSON Component
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &gotJSON))
+			assert.Equal(t, tt.want, gotJSON, "json")
+		})
+	}
+}
+
+func TestInteractionDataFromElement_components(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		etf        etfapi.Element
+		json       string
+		want       InteractionData
+		wantValues map[string]string
+	}{
+		{
+			name: "button click",
+			etf: etfMap(map[string]etfapi.Element{
+				"custom_id":      etfString("poll:yes"),
+				"component_type": etfInt(2),
+			}),
+			json:       `{"custom_id": "poll:yes", "component_type": 2}`,
+			want:       InteractionData{CustomID: "poll:yes", ComponentType: ComponentButton},
+			wantValues: map[string]string{},
+		},
+		{
+			name: "select values",
+			etf: etfMap(map[string]etfapi.Element{
+				"custom_id":      etfString("color"),
+				"component_type": etfInt(3),
+				"values":         etfList(etfString("r"), etfString("b")),
+			}),
+			json:       `{"custom_id": "color", "component_type": 3, "values": ["r", "b"]}`,
+			want:       InteractionData{CustomID: "color", ComponentType: ComponentStringSelect, Values: []string{"r", "b"}},
+			wantValues: map[string]string{},
+		},
+		{
+			name: "modal submit",
+			etf: etfMap(map[string]etfapi.Element{
+				"custom_id": etfString("report"),
+				"components": etfList(
+					etfMap(map[string]etfapi.Element{
+						"type": etfInt(1),
+						"components": etfList(etfMap(map[string]etfapi.Element{
+							"type": etfInt(4), "custom_id": etfString("reason"), "value": etfString("spam"),
+						})),
+					}),
+					etfMap(map[string]etfapi.Element{
+						"type": etfInt(1),
+						"components": etfList(etfMap(map[string]etfapi.Element{
+							"type": etfInt(4), "custom_id": etfString("details"), "value": etfString(""),
+						})),
+					}),
+				),
+			}),
+			json: `{"custom_id": "report", "components": [
+				{"type": 1, "components": [{"type": 4, "custom_id": "reason", "value": "spam"}]},
+				{"type": 1, "components": [{"type": 4, "custom_id": "details", "value": ""}]}
+			]}`,
+			want: InteractionData{CustomID: "report", Components: []Component{
+				ActionRow(Component{Type: ComponentTextInput, CustomID: "reason", Value: "spam"}),
+				ActionRow(Component{Type: ComponentTextInput, CustomID: "details"}),
+			}},
+			wantValues: map[string]string{"reason": "spam", "details": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := InteractionDataFromElement(tt.etf)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got, "etf")
+			assert.Equal(t, tt.wantValues, got.SubmittedValues(), "etf")
+
+			var gotJSON InteractionData
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &gotJSON))
+			assert.Equal(t, tt.want, gotJSON, "json")
+			assert.Equal(t, tt.wantValues, gotJSON.SubmittedValues(), "json")
+		})
+	}
+}
+
+func TestTextInput_style(t *testing.T) {
+	t.Parallel()
+
+	c := TextInput("reason", "Reason", TextInputParagraph).WithLength(1, 200)
+	assert.Equal(t, TextInputParagraph, c.InputStyle())
+
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":4,"custom_id":"reason","style":2,"label":"Reason","min_length":1,"max_length":200}`, string(b))
+}
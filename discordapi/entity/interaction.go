@@ -17,6 +17,7 @@ const (
 	InteractionApplicationCommand InteractionType = 2
 	InteractionMessageComponent   InteractionType = 3
 	InteractionAutocomplete       InteractionType = 4
+	InteractionModalSubmit        InteractionType = 5
 )
 
 // InteractionTypeFromElement generates a InteractionType representation from the given
@@ -37,6 +38,9 @@ type Interaction struct {
 	Version int              `json:"version"`
 	Message *Message         `json:"message"`
 
+	Locale      string `json:"locale"`       // the locale of the invoking user
+	GuildLocale string `json:"guild_locale"` // the preferred locale of the guild, if invoked in one
+
 	IDString            string `json:"id"`
 	ApplicationIDString string `json:"application_id"`
 	GuildIDString       string `json:"guild_id"`
@@ -108,6 +112,22 @@ func InteractionFromElementMap(eMap map[string]etfapi.Element) (Interaction, err
 		return i, errors.Wrap(err, "could not get version")
 	}
 
+	e2, ok = eMap["locale"]
+	if ok && !e2.IsNil() {
+		i.Locale, err = e2.ToString()
+		if err != nil {
+			return i, errors.Wrap(err, "could not get locale")
+		}
+	}
+
+	e2, ok = eMap["guild_locale"]
+	if ok && !e2.IsNil() {
+		i.GuildLocale, err = e2.ToString()
+		if err != nil {
+			return i, errors.Wrap(err, "could not get guild_locale")
+		}
+	}
+
 	e2, ok = eMap["data"]
 	if ok && !e2.IsNil() {
 		v, err := InteractionDataFromElement(e2)
@@ -153,9 +173,12 @@ type InteractionData struct {
 	Type     ApplicationCommandType
 	Resolved ResolvedData
 	Options  []ApplicationCommandInteractionOption
-	// CustomID string
-	// ComponentType
-	// Values
+
+	// These are set for message component and modal submit interactions
+	CustomID      string        `json:"custom_id"`
+	ComponentType ComponentType `json:"component_type"`
+	Values        []string      `json:"values"`
+	Components    []Component   `json:"components"`
 
 	IDString       string `json:"id"`
 	TargetIDString string `json:"target_id"`
@@ -193,15 +216,62 @@ func InteractionDataFromElement(e etfapi.Element) (InteractionData, error) {
 		d.TargetIDString = d.TargetIDSnowflake.ToString()
 	}
 
-	e2 = eMap["name"]
-	d.Name, err = e2.ToString()
-	if err != nil {
-		return d, errors.Wrap(err, "could not get name")
+	// component interactions have no name or type
+	e2, ok = eMap["name"]
+	if ok {
+		d.Name, err = e2.ToString()
+		if err != nil {
+			return d, errors.Wrap(err, "could not get name")
+		}
 	}
 
-	d.Type, err = ApplicationCommandTypeFromElement(eMap["type"])
-	if err != nil {
-		return d, errors.Wrap(err, "could not get type")
+	e2, ok = eMap["type"]
+	if ok {
+		d.Type, err = ApplicationCommandTypeFromElement(e2)
+		if err != nil {
+			return d, errors.Wrap(err, "could not get type")
+		}
+	}
+
+	e2, ok = eMap["custom_id"]
+	if ok && !e2.IsNil() {
+		d.CustomID, err = e2.ToString()
+		if err != nil {
+			return d, errors.Wrap(err, "could not get custom_id")
+		}
+	}
+
+	e2, ok = eMap["component_type"]
+	if ok && !e2.IsNil() {
+		d.ComponentType, err = ComponentTypeFromElement(e2)
+		if err != nil {
+			return d, errors.Wrap(err, "could not get component_type")
+		}
+	}
+
+	e2, ok = eMap["values"]
+	if ok && !e2.IsNil() {
+		el, err := e2.ToList()
+		if err != nil {
+			return d, errors.Wrap(err, "values was not a list")
+		}
+
+		d.Values = make([]string, 0, len(el))
+		for _, e3 := range el {
+			v, err := e3.ToString()
+			if err != nil {
+				return d, errors.Wrap(err, "could not get value")
+			}
+			d.Values = append(d.Values, v)
+		}
+	}
+
+	e2, ok = eMap["components"]
+	if ok && !e2.IsNil() {
+		d.Components, err = ComponentsFromElement(e2)
+		if err != nil {
+			return d, errors.Wrap(err, "could not get components")
+		}
 	}
 
 	e2, ok = eMap["resolved"]
@@ -232,12 +302,31 @@ func InteractionDataFromElement(e etfapi.Element) (InteractionData, error) {
 	return d, nil
 }
 
+// SubmittedValues returns the values of the text inputs of a modal submit interaction, by custom_id
+func (d *InteractionData) SubmittedValues() map[string]string {
+	vals := map[string]string{}
+
+	var walk func([]Component)
+	walk = func(cs []Component) {
+		for i := range cs {
+			if cs[i].Type == ComponentTextInput {
+				vals[cs[i].CustomID] = cs[i].Value
+			}
+			walk(cs[i].Components)
+		}
+	}
+	walk(d.Components)
+
+	return vals
+}
+
 // ResolvedData is the resolved references from entities in the InteractionData
 type ResolvedData struct {
 	Users    map[snowflake.Snowflake]User
 	Members  map[snowflake.Snowflake]GuildMember
 	Roles    map[snowflake.Snowflake]Role
 	Channels map[snowflake.Snowflake]Channel
+	Messages map[snowflake.Snowflake]Message
 }
 
 // ResolvedDataFromElement generates a new Interaction object from the given data
@@ -322,6 +411,24 @@ func ResolvedDataFromElement(e etfapi.Element) (ResolvedData, error) {
 		}
 	}
 
+	e2, ok = eMap["messages"]
+	if ok && !e2.IsNil() {
+		m, err = e2.ToSnowflakeMap()
+		if err != nil {
+			return d, errors.Wrap(err, "could not inflate messages map")
+		}
+
+		d.Messages = make(map[snowflake.Snowflake]Message, len(m))
+		for k, v := range m {
+			if !v.IsNil() {
+				d.Messages[k], err = MessageFromElement(v)
+				if err != nil {
+					return d, errors.Wrap(err, "could not inflate message")
+				}
+			}
+		}
+	}
+
 	return d, nil
 }
 
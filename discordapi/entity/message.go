@@ -78,6 +78,7 @@ type Message struct {
 	WebhookID           string            `json:"webhook_id"`
 	Type                MessageType       `json:"type"`
 	Flags               int               `json:"flags"`
+	Components          []Component       `json:"components"`
 
 	// Nonce is skipped
 	// Activity is skipped
@@ -231,20 +232,95 @@ func MessageFromElementMap(eMap map[string]etfapi.Element) (Message, error) {
 		return m, errors.Wrap(err, "could not get messageType")
 	}
 
+	m.Author, err = UserFromElement(eMap["author"])
+	if err != nil {
+		return m, errors.Wrap(err, "could not inflate message author")
+	}
+
+	err = m.UpdateFromElementMap(eMap)
+	return m, err
+}
+
+// UpdateFromElementMap applies the (possibly partial) message data to the message
+//
+// Only fields present in the data are changed; the id, channel, type, and author are left alone
+func (m *Message) UpdateFromElementMap(eMap map[string]etfapi.Element) error {
+	var err error
+
+	e2, ok := eMap["guild_id"]
+	if ok && !e2.IsNil() {
+		m.GuildIDSnowflake, err = etfapi.SnowflakeFromUnknownElement(e2)
+		if err != nil {
+			return errors.Wrap(err, "could not get guild_id snowflake.Snowflake")
+		}
+		m.GuildIDString = m.GuildIDSnowflake.ToString()
+	}
+
 	e2, ok = eMap["content"]
 	if ok {
 		m.Content, err = e2.ToString()
 		if err != nil {
-			return m, errors.Wrap(err, "could not get content")
+			return errors.Wrap(err, "could not get content")
 		}
 	}
 
-	m.Author, err = UserFromElement(eMap["author"])
-	if err != nil {
-		return m, errors.Wrap(err, "could not inflate message author")
+	e2, ok = eMap["timestamp"]
+	if ok && !e2.IsNil() {
+		m.Timestamp, err = e2.ToString()
+		if err != nil {
+			return errors.Wrap(err, "could not get timestamp")
+		}
+	}
+
+	e2, ok = eMap["edited_timestamp"]
+	if ok && !e2.IsNil() {
+		m.EditedTimestamp, err = e2.ToString()
+		if err != nil {
+			return errors.Wrap(err, "could not get edited_timestamp")
+		}
 	}
 
-	return m, nil
+	e2, ok = eMap["tts"]
+	if ok {
+		m.TTS, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get tts")
+		}
+	}
+
+	e2, ok = eMap["mention_everyone"]
+	if ok {
+		m.MentionEveryone, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get mention_everyone")
+		}
+	}
+
+	e2, ok = eMap["pinned"]
+	if ok {
+		m.Pinned, err = e2.ToBool()
+		if err != nil {
+			return errors.Wrap(err, "could not get pinned")
+		}
+	}
+
+	e2, ok = eMap["flags"]
+	if ok && !e2.IsNil() {
+		m.Flags, err = e2.ToInt()
+		if err != nil {
+			return errors.Wrap(err, "could not get flags")
+		}
+	}
+
+	e2, ok = eMap["components"]
+	if ok && !e2.IsNil() {
+		m.Components, err = ComponentsFromElement(e2)
+		if err != nil {
+			return errors.Wrap(err, "could not get components")
+		}
+	}
+
+	return nil
 }
 
 // MessageFromElement generates a new Message object from the given Element
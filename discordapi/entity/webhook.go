@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// WebhookType represents the type of a webhook
+type WebhookType int
+
+// These are the known WebhookType values
+const (
+	WebhookIncoming        WebhookType = 1
+	WebhookChannelFollower WebhookType = 2
+	WebhookApplication     WebhookType = 3
+)
+
+// Webhook is the data about a webhook received from the json api
+type Webhook struct {
+	IDString            string      `json:"id"`
+	Type                WebhookType `json:"type"`
+	GuildIDString       string      `json:"guild_id"`
+	ChannelIDString     string      `json:"channel_id"`
+	ApplicationIDString string      `json:"application_id"`
+	User                *User       `json:"user"`
+	Name                string      `json:"name"`
+	Avatar              string      `json:"avatar"`
+	Token               string      `json:"token"`
+	URL                 string      `json:"url"`
+
+	IDSnowflake            snowflake.Snowflake `json:"-"`
+	GuildIDSnowflake       snowflake.Snowflake `json:"-"`
+	ChannelIDSnowflake     snowflake.Snowflake `json:"-"`
+	ApplicationIDSnowflake snowflake.Snowflake `json:"-"`
+}
+
+// Snowflakify converts snowflake strings into real sowflakes
+func (w *Webhook) Snowflakify() error {
+	var err error
+
+	if w.IDSnowflake, err = snowflake.FromString(w.IDString); err != nil {
+		return errors.Wrap(err, "could not snowflakify ID")
+	}
+
+	if w.GuildIDString != "" {
+		if w.GuildIDSnowflake, err = snowflake.FromString(w.GuildIDString); err != nil {
+			return errors.Wrap(err, "could not snowflakify GuildID")
+		}
+	}
+
+	if w.ChannelIDString != "" {
+		if w.ChannelIDSnowflake, err = snowflake.FromString(w.ChannelIDString); err != nil {
+			return errors.Wrap(err, "could not snowflakify ChannelID")
+		}
+	}
+
+	if w.ApplicationIDString != "" {
+		if w.ApplicationIDSnowflake, err = snowflake.FromString(w.ApplicationIDString); err != nil {
+			return errors.Wrap(err, "could not snowflakify ApplicationID")
+		}
+	}
+
+	if w.User != nil {
+		if err = w.User.Snowflakify(); err != nil {
+			return errors.Wrap(err, "could not snowflakify User")
+		}
+	}
+
+	return nil
+}
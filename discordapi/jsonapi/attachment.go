@@ -0,0 +1,176 @@
+package jsonapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+)
+
+// ErrMissingFileData is the error returned when a File has no name or no reader
+var ErrMissingFileData = errors.New("missing file name or data")
+
+// File is a file to upload as a message attachment
+//
+// The data is streamed from Reader when the message is sent, so a File can only be sent once.
+// If Reader is also an io.Closer, it is closed once it has been read
+type File struct {
+	Name        string
+	Description string
+	Spoiler     bool
+	Reader      io.Reader
+}
+
+// FileName returns the name the file is uploaded with (prefixed with SPOILER_ if it is a spoiler)
+func (f File) FileName() string {
+	if f.Spoiler && !strings.HasPrefix(f.Name, "SPOILER_") {
+		return "SPOILER_" + f.Name
+	}
+
+	return f.Name
+}
+
+// AttachmentURL returns the url that references the uploaded file from within the
+// message's embeds (for example, as an embed image)
+func (f File) AttachmentURL() string {
+	return "attachment://" + f.FileName()
+}
+
+// FileUploader is the interface implemented by messages that may carry file attachments
+type FileUploader interface {
+	Uploads() []File
+}
+
+type attachmentMeta struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	Description string `json:"description,omitempty"`
+}
+
+// uploadsOf returns the files attached to a message, if any
+func uploadsOf(m interface{}) []File {
+	fu, ok := m.(FileUploader)
+	if !ok {
+		return nil
+	}
+
+	return fu.Uploads()
+}
+
+// withAttachments adds the attachments metadata describing the files to a json message payload
+//
+// The payload is returned unchanged if there are no files
+func withAttachments(payload []byte, files []File) ([]byte, error) {
+	if len(files) == 0 {
+		return payload, nil
+	}
+
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal message json")
+	}
+
+	metas := make([]attachmentMeta, 0, len(files))
+	for i, f := range files {
+		if f.Name == "" || f.Reader == nil {
+			return nil, errors.Wrap(ErrMissingFileData, "bad attachment", "index", i)
+		}
+
+		metas = append(metas, attachmentMeta{ID: i, Filename: f.FileName(), Description: f.Description})
+	}
+
+	b, err := json.Marshal(metas)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal attachments")
+	}
+	m["attachments"] = b
+
+	b, err = json.Marshal(m)
+	return b, errors.Wrap(err, "could not marshal message json")
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// multipartBody streams a multipart/form-data request body with the json payload and the files,
+// returning the body and its content type
+//
+// The body is produced by a goroutine as it is read, so the files are never fully buffered in memory.
+// The caller must read the body to the end or close it
+func multipartBody(payload []byte, files []File) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeMultipart(mw, payload, files)
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	return pr, mw.FormDataContentType()
+}
+
+func writeMultipart(mw *multipart.Writer, payload []byte, files []File) error {
+	defer func() {
+		for _, f := range files {
+			if c, ok := f.Reader.(io.Closer); ok {
+				_ = c.Close()
+			}
+		}
+	}()
+
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="payload_json"`)
+	h.Set("Content-Type", "application/json")
+
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return errors.Wrap(err, "could not create payload_json part")
+	}
+
+	if _, err = w.Write(payload); err != nil {
+		return errors.Wrap(err, "could not write payload_json part")
+	}
+
+	for i, f := range files {
+		ct := mime.TypeByExtension(filepath.Ext(f.Name))
+		if ct == "" {
+			ct = "application/octet-stream"
+		}
+
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="files[%d]"; filename="%s"`, i, quoteEscaper.Replace(f.FileName())))
+		h.Set("Content-Type", ct)
+
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return errors.Wrap(err, "could not create file part", "index", i)
+		}
+
+		if _, err = io.Copy(w, f.Reader); err != nil {
+			return errors.Wrap(err, "could not write file part", "index", i)
+		}
+	}
+
+	return nil
+}
+
+// requestBody creates the body of a message request, returning the body and its content type
+//
+// Messages with file attachments are streamed as multipart/form-data, and others are sent as json.
+// The payload should already contain the attachments metadata (see withAttachments)
+func requestBody(payload []byte, files []File) (io.Reader, string) {
+	if len(files) == 0 {
+		return bytes.NewReader(payload), "application/json"
+	}
+
+	return multipartBody(payload, files)
+}
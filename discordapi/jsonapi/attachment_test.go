@@ -0,0 +1,265 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/httpclient"
+)
+
+// uploadMessage is a message with attachments
+type uploadMessage struct {
+	files []File
+}
+
+func (m uploadMessage) MarshalToJSON() ([]byte, error) { return []byte(`{"content":"hi"}`), nil }
+func (m uploadMessage) Uploads() []File                { return m.files }
+
+// closeTracker is a file reader that reports when it is closed
+type closeTracker struct {
+	*strings.Reader
+	closed chan struct{}
+}
+
+func (c closeTracker) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestDiscordJSONClient_SendMessage_circuitOpen(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	d.deps.(*testDeps).http.SetCircuitBreaker(httpclient.NewCircuitBreaker(1, time.Hour))
+
+	ctx := context.Background()
+
+	// the first failure opens the breaker
+	if _, err := d.SendMessage(ctx, 1, uploadMessage{}); err == nil {
+		t.Fatal("SendMessage() error = nil, want the 500")
+	}
+
+	f := closeTracker{Reader: strings.NewReader("file contents"), closed: make(chan struct{})}
+	_, err := d.SendMessage(ctx, 1, uploadMessage{files: []File{{Name: "a.txt", Reader: f}}})
+	if !errors.Is(err, httpclient.ErrCircuitOpen) {
+		t.Fatalf("SendMessage() error = %v, want ErrCircuitOpen", err)
+	}
+
+	// the refused multipart body must be closed, which stops the goroutine writing it and closes the file
+	select {
+	case <-f.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the file of a refused request was never closed")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
+
+func TestFile_FileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		file File
+		want string
+	}{
+		{name: "plain", file: File{Name: "cat.png"}, want: "cat.png"},
+		{name: "spoiler", file: File{Name: "cat.png", Spoiler: true}, want: "SPOILER_cat.png"},
+		{name: "spoiler already prefixed", file: File{Name: "SPOILER_cat.png", Spoiler: true}, want: "SPOILER_cat.png"},
+		{name: "prefixed but not a spoiler", file: File{Name: "SPOILER_cat.png"}, want: "SPOILER_cat.png"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.file.FileName())
+			assert.Equal(t, "attachment://"+tt.want, tt.file.AttachmentURL())
+		})
+	}
+}
+
+func TestWithAttachments(t *testing.T) {
+	t.Parallel()
+
+	data := strings.NewReader("data")
+
+	tests := []struct {
+		name    string
+		payload string
+		files   []File
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "no files",
+			payload: `{"content":"hi"}`,
+			want:    `{"content":"hi"}`,
+		},
+		{
+			name:    "files",
+			payload: `{"content":"hi"}`,
+			files: []File{
+				{Name: "a.txt", Reader: data},
+				{Name: "b.png", Description: "a cat", Spoiler: true, Reader: data},
+			},
+			want: `{"content":"hi","attachments":[{"id":0,"filename":"a.txt"},{"id":1,"filename":"SPOILER_b.png","description":"a cat"}]}`,
+		},
+		{
+			name:    "missing name",
+			payload: `{"content":"hi"}`,
+			files:   []File{{Name: "a.txt", Reader: data}, {Reader: data}},
+			wantErr: ErrMissingFileData,
+		},
+		{
+			name:    "missing reader",
+			payload: `{"content":"hi"}`,
+			files:   []File{{Name: "a.txt"}},
+			wantErr: ErrMissingFileData,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := withAttachments([]byte(tt.payload), tt.files)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+// multipartPart is a decoded part of a multipart body
+type multipartPart struct {
+	name        string
+	fileName    string
+	contentType string
+	data        string
+}
+
+func readMultipart(t *testing.T, body io.Reader, contentType string) []multipartPart {
+	t.Helper()
+
+	mt, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/form-data", mt)
+
+	var parts []multipartPart
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(p)
+		require.NoError(t, err)
+
+		parts = append(parts, multipartPart{
+			name:        p.FormName(),
+			fileName:    p.FileName(),
+			contentType: p.Header.Get("Content-Type"),
+			data:        string(data),
+		})
+	}
+}
+
+func TestMultipartBody(t *testing.T) {
+	t.Parallel()
+
+	first := closeTracker{Reader: strings.NewReader("png bytes"), closed: make(chan struct{})}
+	second := closeTracker{Reader: strings.NewReader("text"), closed: make(chan struct{})}
+
+	body, contentType := requestBody([]byte(`{"content":"hi"}`), []File{
+		{Name: "cat.png", Spoiler: true, Reader: first},
+		{Name: `say "hi"`, Reader: second},
+	})
+	defer body.(io.Closer).Close()
+
+	assert.Equal(t, []multipartPart{
+		{name: "payload_json", contentType: "application/json", data: `{"content":"hi"}`},
+		{name: "files[0]", fileName: "SPOILER_cat.png", contentType: "image/png", data: "png bytes"},
+		{name: "files[1]", fileName: `say "hi"`, contentType: "application/octet-stream", data: "text"},
+	}, readMultipart(t, body, contentType))
+
+	for i, f := range []closeTracker{first, second} {
+		select {
+		case <-f.closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("file %d was never closed", i)
+		}
+	}
+}
+
+// failingReader is a file reader that fails partway through
+type failingReader struct {
+	closed chan struct{}
+}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func (f failingReader) Close() error {
+	close(f.closed)
+	return nil
+}
+
+func TestMultipartBody_readError(t *testing.T) {
+	t.Parallel()
+
+	ok := closeTracker{Reader: strings.NewReader("text"), closed: make(chan struct{})}
+	bad := failingReader{closed: make(chan struct{})}
+
+	body, _ := multipartBody([]byte(`{}`), []File{{Name: "a.txt", Reader: bad}, {Name: "b.txt", Reader: ok}})
+	defer body.Close()
+
+	_, err := io.ReadAll(body)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// every file is closed, including the ones never reached
+	for i, closed := range []chan struct{}{bad.closed, ok.closed} {
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("file %d was never closed", i)
+		}
+	}
+}
+
+func TestRequestBody_json(t *testing.T) {
+	t.Parallel()
+
+	body, contentType := requestBody([]byte(`{"content":"hi"}`), nil)
+	assert.Equal(t, "application/json", contentType)
+
+	b, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"content":"hi"}`, string(b))
+}
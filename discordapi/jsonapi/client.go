@@ -7,7 +7,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/gsmcwhirter/go-util/v10/errors"
 	"github.com/gsmcwhirter/go-util/v10/json"
@@ -50,19 +49,29 @@ type HTTPClient interface {
 	Put(context.Context, string, *http.Header, io.Reader) (*http.Response, error)
 	PutBody(context.Context, string, *http.Header, io.Reader) (*http.Response, []byte, error)
 	PutJSON(context.Context, string, *http.Header, io.Reader, interface{}) (*http.Response, error)
+	Patch(context.Context, string, *http.Header, io.Reader) (*http.Response, error)
+	PatchBody(context.Context, string, *http.Header, io.Reader) (*http.Response, []byte, error)
+	PatchJSON(context.Context, string, *http.Header, io.Reader, interface{}) (*http.Response, error)
+	Delete(context.Context, string, *http.Header, io.Reader) (*http.Response, error)
+	DeleteBody(context.Context, string, *http.Header, io.Reader) (*http.Response, []byte, error)
+	DeleteJSON(context.Context, string, *http.Header, io.Reader, interface{}) (*http.Response, error)
 }
 
-// marshaler is the interface implemented by types that
+// JSONMarshaler is the interface implemented by types that
 // can marshal themselves into valid JSON.
-type marshaler interface {
-	MarshalToJSON() ([]byte, error)
+type JSONMarshaler interface {
+	MarshalToJSON() ([]byte, error) // yes, this is intentionally different than stdlib
 }
 
+type marshaler = JSONMarshaler
+
 // DiscordJSONClient is a json client for interacting wth discord
 type DiscordJSONClient struct {
 	deps   dependencies
 	apiURL string
 
+	hierarchy HierarchyChecker
+
 	debug bool
 }
 
@@ -105,8 +114,10 @@ func (d *DiscordJSONClient) GetGuildMember(ctx context.Context, gid, uid snowfla
 	return respData, nil
 }
 
-// Err is the error that is wrapped and returned when there is a non-200 api response
-var Err = errors.New("error response")
+// Err is the error that is wrapped and returned when there is a non-2xx api response
+//
+// It is the same as httpclient.ErrResponse; use errors.As with an *APIError for the details
+var Err = httpclient.ErrResponse
 
 // GetGateway retrieves information about the gateway
 func (d *DiscordJSONClient) GetGateway(ctx context.Context) (entity.Gateway, error) {
@@ -150,16 +161,22 @@ func (d *DiscordJSONClient) SendMessage(ctx context.Context, cid snowflake.Snowf
 		return respData, errors.Wrap(err, "could not marshal message as json")
 	}
 
-	level.Info(logger).Message("sending message", "payload", string(b))
-	r := bytes.NewReader(b)
+	files := uploadsOf(m)
+	b, err = withAttachments(b, files)
+	if err != nil {
+		return respData, err
+	}
+
+	level.Info(logger).Message("sending message", "payload", string(b), "files", len(files))
 
 	err = d.deps.MessageRateLimiter().Wait(ctx)
 	if err != nil {
 		return respData, errors.Wrap(err, "error waiting for rate limiter")
 	}
 
+	r, contentType := requestBody(b, files)
 	header := &http.Header{}
-	header.Set("Content-Type", "application/json")
+	header.Set("Content-Type", contentType)
 	resp, err := d.deps.HTTPClient().PostJSON(ctx, fmt.Sprintf("%s/channels/%d/messages", d.apiURL, cid), header, r, &respData)
 	if err != nil {
 		return respData, errors.Wrap(err, "could not complete the message send")
@@ -178,8 +195,29 @@ func (d *DiscordJSONClient) SendMessage(ctx context.Context, cid snowflake.Snowf
 }
 
 // SendInteractionMessage sends an interaction response message
+//
+// If m implements CallbackTyper, its callback type is used instead (so a Modal opens the modal dialog)
 func (d *DiscordJSONClient) SendInteractionMessage(ctx context.Context, ixID snowflake.Snowflake, ixToken string, m marshaler) error {
-	err := d.sendInteractionResponse(ctx, ixID, ixToken, m, CallbackTypeChannelMessage)
+	typ := CallbackTypeChannelMessage
+	if ct, ok := m.(CallbackTyper); ok {
+		typ = ct.CallbackType()
+	}
+
+	err := d.sendInteractionResponse(ctx, ixID, ixToken, m, typ)
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.InteractionResponsesCount, 1); err != nil {
+		logger := logging.WithContext(ctx, d.deps.Logger())
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return err
+}
+
+// SendInteractionModal responds to an interaction by opening a modal dialog
+//
+// The submitted values arrive in a later interaction of type entity.InteractionModalSubmit
+func (d *DiscordJSONClient) SendInteractionModal(ctx context.Context, ixID snowflake.Snowflake, ixToken string, m Modal) error {
+	err := d.sendInteractionResponse(ctx, ixID, ixToken, m, CallbackTypeModal)
 
 	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.InteractionResponsesCount, 1); err != nil {
 		logger := logging.WithContext(ctx, d.deps.Logger())
@@ -202,17 +240,46 @@ func (d *DiscordJSONClient) SendInteractionAutocomplete(ctx context.Context, ixI
 }
 
 // DeferInteractionResponse sends a deferral for an interaction response
+//
+// The response must later be completed with EditOriginalResponse
 func (d *DiscordJSONClient) DeferInteractionResponse(ctx context.Context, ixID snowflake.Snowflake, ixToken string) error {
 	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeferInteractionResponse")
 	defer span.End()
 
+	return d.deferInteraction(ctx, ixID, ixToken, CallbackTypeDeferredChannelMessage)
+}
+
+// DeferInteractionUpdate acknowledges a component interaction without changing the message
+// the component is attached to
+//
+// The message may later be changed with EditOriginalResponse
+func (d *DiscordJSONClient) DeferInteractionUpdate(ctx context.Context, ixID snowflake.Snowflake, ixToken string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeferInteractionUpdate")
+	defer span.End()
+
+	return d.deferInteraction(ctx, ixID, ixToken, CallbackTypeDeferredUpdate)
+}
+
+// SendInteractionUpdate responds to a component interaction by editing the message the component is attached to
+func (d *DiscordJSONClient) SendInteractionUpdate(ctx context.Context, ixID snowflake.Snowflake, ixToken string, m marshaler) error {
+	err := d.sendInteractionResponse(ctx, ixID, ixToken, m, CallbackTypeUpdate)
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.InteractionResponsesCount, 1); err != nil {
+		logger := logging.WithContext(ctx, d.deps.Logger())
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return err
+}
+
+func (d *DiscordJSONClient) deferInteraction(ctx context.Context, ixID snowflake.Snowflake, ixToken string, typ InteractionCallbackType) error {
 	logger := logging.WithContext(ctx, d.deps.Logger())
 
 	var b []byte
 	var err error
 
 	im := InteractionCallbackMessage{
-		Type: CallbackTypeDeferredChannelMessage,
+		Type: typ,
 	}
 
 	b, err = json.Marshal(im)
@@ -236,7 +303,7 @@ func (d *DiscordJSONClient) DeferInteractionResponse(ctx context.Context, ixID s
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
-		return errors.Wrap(httpclient.ErrResponse, "non-200 response", "status_code", resp.StatusCode, "response_body", string(body))
+		return errors.Wrap(httpclient.NewAPIError(resp.StatusCode, body), "non-2xx response")
 	}
 
 	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.InteractionDeferralsCount, 1); err != nil {
@@ -259,6 +326,12 @@ func (d *DiscordJSONClient) sendInteractionResponse(ctx context.Context, ixID sn
 		return errors.Wrap(err, "could not marshal message as json")
 	}
 
+	files := uploadsOf(m)
+	b, err = withAttachments(b, files)
+	if err != nil {
+		return err
+	}
+
 	im := InteractionCallbackMessage{
 		Type: typ,
 	}
@@ -271,23 +344,23 @@ func (d *DiscordJSONClient) sendInteractionResponse(ctx context.Context, ixID sn
 		return errors.Wrap(err, "could not marshal InteractionCallbackMessage")
 	}
 
-	level.Info(logger).Message("sending message", "payload", string(b))
-	r := bytes.NewReader(b)
+	level.Info(logger).Message("sending message", "payload", string(b), "files", len(files))
 
 	err = d.deps.MessageRateLimiter().Wait(ctx)
 	if err != nil {
 		return errors.Wrap(err, "error waiting for rate limiter")
 	}
 
+	r, contentType := requestBody(b, files)
 	header := &http.Header{}
-	header.Set("Content-Type", "application/json")
+	header.Set("Content-Type", contentType)
 	resp, body, err := d.deps.HTTPClient().PostBody(ctx, fmt.Sprintf("%s/interactions/%d/%s/callback", d.apiURL, ixID, ixToken), header, r)
 	if err != nil {
 		return errors.Wrap(err, "could not complete the message send")
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
-		return errors.Wrap(httpclient.ErrResponse, "non-200 response", "status_code", resp.StatusCode, "response_body", string(body))
+		return errors.Wrap(httpclient.NewAPIError(resp.StatusCode, body), "non-2xx response")
 	}
 
 	return err
@@ -353,7 +426,10 @@ func (d *DiscordJSONClient) CreateReaction(ctx context.Context, cid, mid snowfla
 	logger := logging.WithContext(ctx, d.deps.Logger())
 	level.Info(logger).Message("creating reaction")
 
-	emoji = strings.TrimSuffix(emoji, ">")
+	emoji, err = ReactionEmoji(emoji)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not format reaction emoji")
+	}
 
 	err = d.deps.MessageRateLimiter().Wait(ctx)
 	if err != nil {
@@ -367,7 +443,7 @@ func (d *DiscordJSONClient) CreateReaction(ctx context.Context, cid, mid snowfla
 	}
 
 	if resp.StatusCode != http.StatusNoContent {
-		err = errors.Wrap(Err, "non-204 response", "status_code", resp.StatusCode, "emoji", emoji, "response_body", string(body))
+		err = errors.Wrap(httpclient.NewAPIError(resp.StatusCode, body), "non-204 response", "emoji", emoji)
 	}
 
 	return resp, err
@@ -386,7 +462,7 @@ func (d *DiscordJSONClient) GetGlobalCommands(ctx context.Context, aid string) (
 		return nil, errors.Wrap(err, "error waiting for rate limiter")
 	}
 
-	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/applications/%s/commands", d.apiURL, aid), nil, &cmds)
+	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/applications/%s/commands?with_localizations=true", d.apiURL, aid), nil, &cmds)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get global commands", "aid", aid)
 	}
@@ -454,7 +530,7 @@ func (d *DiscordJSONClient) GetGuildCommands(ctx context.Context, aid string, gi
 		return nil, errors.Wrap(err, "error waiting for rate limiter")
 	}
 
-	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/applications/%s/guilds/%d/commands", d.apiURL, aid, gid), nil, &cmds)
+	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/applications/%s/guilds/%d/commands?with_localizations=true", d.apiURL, aid, gid), nil, &cmds)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not get guild commands", "aid", aid, "gid", gid.ToString())
 	}
@@ -0,0 +1,144 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// sendCommand sends a single command definition and unmarshals the resulting command
+func (d *DiscordJSONClient) sendCommand(ctx context.Context, do jsonRequester, u string, cmd entity.ApplicationCommand) (respData entity.ApplicationCommand, err error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not marshal command as json")
+	}
+
+	err = d.deps.CommandRegistrationRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = do(ctx, u, nil, bytes.NewReader(b), &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the request")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify command")
+}
+
+// deleteCommand deletes a single command definition
+func (d *DiscordJSONClient) deleteCommand(ctx context.Context, u string) error {
+	err := d.deps.CommandRegistrationRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := d.deps.HTTPClient().DeleteBody(ctx, u, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the request")
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// CreateGlobalCommand creates a global command (replacing any global command with the same name)
+func (d *DiscordJSONClient) CreateGlobalCommand(ctx context.Context, aid string, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateGlobalCommand")
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("creating global command", "aid", aid, "name", cmd.Name)
+
+	respData, err := d.sendCommand(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/applications/%s/commands", d.apiURL, aid), cmd)
+	return respData, errors.Wrap(err, "could not create global command", "aid", aid, "name", cmd.Name)
+}
+
+// EditGlobalCommand replaces the definition of a global command
+func (d *DiscordJSONClient) EditGlobalCommand(ctx context.Context, aid string, cmdID snowflake.Snowflake, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditGlobalCommand", telemetry.WithAttributes(telemetry.KVString("cmd_id", cmdID.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("editing global command", "aid", aid, "cmd_id", cmdID.ToString(), "name", cmd.Name)
+
+	respData, err := d.sendCommand(ctx, d.deps.HTTPClient().PatchJSON, fmt.Sprintf("%s/applications/%s/commands/%d", d.apiURL, aid, cmdID), cmd)
+	return respData, errors.Wrap(err, "could not edit global command", "aid", aid, "cmd_id", cmdID.ToString())
+}
+
+// DeleteGlobalCommand deletes a global command
+func (d *DiscordJSONClient) DeleteGlobalCommand(ctx context.Context, aid string, cmdID snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteGlobalCommand", telemetry.WithAttributes(telemetry.KVString("cmd_id", cmdID.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting global command", "aid", aid, "cmd_id", cmdID.ToString())
+
+	err := d.deleteCommand(ctx, fmt.Sprintf("%s/applications/%s/commands/%d", d.apiURL, aid, cmdID))
+	return errors.Wrap(err, "could not delete global command", "aid", aid, "cmd_id", cmdID.ToString())
+}
+
+// CreateGuildCommand creates a guild command (replacing any command in the guild with the same name)
+func (d *DiscordJSONClient) CreateGuildCommand(ctx context.Context, aid string, gid snowflake.Snowflake, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateGuildCommand", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("creating guild command", "aid", aid, "gid", gid.ToString(), "name", cmd.Name)
+
+	respData, err := d.sendCommand(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/applications/%s/guilds/%d/commands", d.apiURL, aid, gid), cmd)
+	return respData, errors.Wrap(err, "could not create guild command", "aid", aid, "gid", gid.ToString(), "name", cmd.Name)
+}
+
+// EditGuildCommand replaces the definition of a guild command
+func (d *DiscordJSONClient) EditGuildCommand(ctx context.Context, aid string, gid, cmdID snowflake.Snowflake, cmd entity.ApplicationCommand) (entity.ApplicationCommand, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditGuildCommand", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cmd_id", cmdID.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("editing guild command", "aid", aid, "gid", gid.ToString(), "cmd_id", cmdID.ToString(), "name", cmd.Name)
+
+	respData, err := d.sendCommand(ctx, d.deps.HTTPClient().PatchJSON, fmt.Sprintf("%s/applications/%s/guilds/%d/commands/%d", d.apiURL, aid, gid, cmdID), cmd)
+	return respData, errors.Wrap(err, "could not edit guild command", "aid", aid, "gid", gid.ToString(), "cmd_id", cmdID.ToString())
+}
+
+// DeleteGuildCommand deletes a guild command
+func (d *DiscordJSONClient) DeleteGuildCommand(ctx context.Context, aid string, gid, cmdID snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteGuildCommand", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cmd_id", cmdID.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting guild command", "aid", aid, "gid", gid.ToString(), "cmd_id", cmdID.ToString())
+
+	err := d.deleteCommand(ctx, fmt.Sprintf("%s/applications/%s/guilds/%d/commands/%d", d.apiURL, aid, gid, cmdID))
+	return errors.Wrap(err, "could not delete guild command", "aid", aid, "gid", gid.ToString(), "cmd_id", cmdID.ToString())
+}
+
+// GetGuildCommandPermissions gets the permissions of every command of the application in a guild
+func (d *DiscordJSONClient) GetGuildCommandPermissions(ctx context.Context, aid string, gid snowflake.Snowflake) (perms []entity.ApplicationCommandPermissions, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "GetGuildCommandPermissions", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("listing guild command permissions", "aid", aid, "gid", gid.ToString())
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/applications/%s/guilds/%d/commands/permissions", d.apiURL, aid, gid), nil, &perms)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get guild command permissions", "aid", aid, "gid", gid.ToString())
+	}
+
+	for i := range perms {
+		if err := perms[i].Snowflakify(); err != nil {
+			return nil, errors.Wrap(err, "could not snowflakify command permissions")
+		}
+	}
+
+	return perms, nil
+}
@@ -0,0 +1,60 @@
+package jsonapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+func TestDiscordJSONClient_getCommandsWithLocalizations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+		get  func(*DiscordJSONClient) ([]entity.ApplicationCommand, error)
+	}{
+		{
+			name: "global",
+			path: "/applications/42/commands",
+			get: func(c *DiscordJSONClient) ([]entity.ApplicationCommand, error) {
+				return c.GetGlobalCommands(context.Background(), "42")
+			},
+		},
+		{
+			name: "guild",
+			path: "/applications/42/guilds/1/commands",
+			get: func(c *DiscordJSONClient) ([]entity.ApplicationCommand, error) {
+				return c.GetGuildCommands(context.Background(), "42", 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// without the query, discord leaves the localizations out
+				if r.URL.Path != tt.path || r.URL.Query().Get("with_localizations") != "true" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[{"id":"101","application_id":"42","type":1,"name":"ping","description":"Ping the bot","name_localizations":{"de":"pingen"}}]`))
+			}))
+
+			cmds, err := tt.get(c)
+			require.NoError(t, err)
+			require.Len(t, cmds, 1)
+			assert.Equal(t, entity.Localizations{"de": "pingen"}, cmds[0].NameLocalizations)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrMissingName is the error returned when creating a channel or role without a name
+var ErrMissingName = errors.New("missing name")
+
+type jsonRequester = func(context.Context, string, *http.Header, io.Reader, interface{}) (*http.Response, error)
+
+// manage sends a guild management request and unmarshals the response into respData
+func (d *DiscordJSONClient) manage(ctx context.Context, do jsonRequester, u, reason string, payload interface{}, respData interface{}) error {
+	header, r, err := auditedRequest(reason, payload)
+	if err != nil {
+		return err
+	}
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = do(ctx, u, header, r, respData)
+	return errors.Wrap(err, "could not complete the request")
+}
+
+// manageNoContent sends a guild management request that has no meaningful response body
+func (d *DiscordJSONClient) manageNoContent(ctx context.Context, do bodyRequester, u, reason string, payload interface{}) error {
+	header, r, err := auditedRequest(reason, payload)
+	if err != nil {
+		return err
+	}
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := do(ctx, u, header, r)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the request")
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// CreateChannel creates a new channel in a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) CreateChannel(ctx context.Context, gid snowflake.Snowflake, p ChannelParams, reason string) (respData entity.Channel, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateChannel", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	if p.Name == nil || *p.Name == "" {
+		return respData, errors.Wrap(ErrMissingName, "cannot create channel")
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("creating channel", "gid", gid.ToString(), "name", *p.Name)
+
+	err = d.manage(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/guilds/%d/channels", d.apiURL, gid), reason, p, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not create channel")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify channel")
+}
+
+// ModifyChannel changes the fields of a channel that are set in p
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) ModifyChannel(ctx context.Context, cid snowflake.Snowflake, p ChannelParams, reason string) (respData entity.Channel, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "ModifyChannel", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("modifying channel", "cid", cid.ToString())
+
+	err = d.manage(ctx, d.deps.HTTPClient().PatchJSON, fmt.Sprintf("%s/channels/%d", d.apiURL, cid), reason, p, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not modify channel")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify channel")
+}
+
+// DeleteChannel deletes a channel, returning its last known state
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) DeleteChannel(ctx context.Context, cid snowflake.Snowflake, reason string) (respData entity.Channel, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteChannel", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting channel", "cid", cid.ToString())
+
+	err = d.manage(ctx, d.deps.HTTPClient().DeleteJSON, fmt.Sprintf("%s/channels/%d", d.apiURL, cid), reason, nil, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not delete channel")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify channel")
+}
+
+type channelPositions []ChannelPosition
+
+func (c channelPositions) MarshalToJSON() ([]byte, error) {
+	ps := make([]map[string]interface{}, 0, len(c))
+	for _, p := range c {
+		ps = append(ps, p.toMap())
+	}
+
+	return json.Marshal(ps)
+}
+
+// ReorderChannels changes the positions (and optionally the parents) of channels in a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) ReorderChannels(ctx context.Context, gid snowflake.Snowflake, positions []ChannelPosition, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "ReorderChannels", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("reordering channels", "gid", gid.ToString(), "count", len(positions))
+
+	err := d.manageNoContent(ctx, d.deps.HTTPClient().PatchBody, fmt.Sprintf("%s/guilds/%d/channels", d.apiURL, gid), reason, channelPositions(positions))
+	return errors.Wrap(err, "could not reorder channels")
+}
+
+// EditChannelPermissions creates or replaces a permission overwrite on a channel
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) EditChannelPermissions(ctx context.Context, cid snowflake.Snowflake, ow OverwriteParams, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditChannelPermissions", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("oid", ow.ID.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("editing channel permission overwrite", "cid", cid.ToString(), "oid", ow.ID.ToString())
+
+	err := d.manageNoContent(ctx, d.deps.HTTPClient().PutBody, fmt.Sprintf("%s/channels/%d/permissions/%d", d.apiURL, cid, ow.ID), reason, ow)
+	return errors.Wrap(err, "could not edit channel permissions")
+}
+
+// DeleteChannelPermission removes the permission overwrite for a role or member from a channel
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) DeleteChannelPermission(ctx context.Context, cid, oid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteChannelPermission", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("oid", oid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting channel permission overwrite", "cid", cid.ToString(), "oid", oid.ToString())
+
+	err := d.manageNoContent(ctx, d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/channels/%d/permissions/%d", d.apiURL, cid, oid), reason, nil)
+	return errors.Wrap(err, "could not delete channel permission")
+}
+
+// CreateRole creates a new role in a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) CreateRole(ctx context.Context, gid snowflake.Snowflake, p RoleParams, reason string) (respData entity.Role, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateRole", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	if p.Name == nil || *p.Name == "" {
+		return respData, errors.Wrap(ErrMissingName, "cannot create role")
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("creating role", "gid", gid.ToString(), "name", *p.Name)
+
+	err = d.manage(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/guilds/%d/roles", d.apiURL, gid), reason, p, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not create role")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify role")
+}
+
+// ModifyRole changes the fields of a role that are set in p
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) ModifyRole(ctx context.Context, gid, rid snowflake.Snowflake, p RoleParams, reason string) (respData entity.Role, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "ModifyRole", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("rid", rid.ToString())))
+	defer span.End()
+
+	if err = d.checkCanManageRole(gid, rid, "modify role"); err != nil {
+		return respData, err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("modifying role", "gid", gid.ToString(), "rid", rid.ToString())
+
+	err = d.manage(ctx, d.deps.HTTPClient().PatchJSON, fmt.Sprintf("%s/guilds/%d/roles/%d", d.apiURL, gid, rid), reason, p, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not modify role")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify role")
+}
+
+type rolePositions []RolePosition
+
+func (r rolePositions) MarshalToJSON() ([]byte, error) {
+	ps := make([]map[string]interface{}, 0, len(r))
+	for _, p := range r {
+		ps = append(ps, map[string]interface{}{"id": p.ID.ToString(), "position": p.Position})
+	}
+
+	return json.Marshal(ps)
+}
+
+// ReorderRoles changes the positions of roles in a guild, returning all of the guild's roles
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) ReorderRoles(ctx context.Context, gid snowflake.Snowflake, positions []RolePosition, reason string) (roles []entity.Role, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "ReorderRoles", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	for _, p := range positions {
+		if err = d.checkCanManageRole(gid, p.ID, "move role"); err != nil {
+			return nil, err
+		}
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("reordering roles", "gid", gid.ToString(), "count", len(positions))
+
+	err = d.manage(ctx, d.deps.HTTPClient().PatchJSON, fmt.Sprintf("%s/guilds/%d/roles", d.apiURL, gid), reason, rolePositions(positions), &roles)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not reorder roles")
+	}
+
+	for i := range roles {
+		if err = roles[i].Snowflakify(); err != nil {
+			return nil, errors.Wrap(err, "could not snowflakify role")
+		}
+	}
+
+	return roles, nil
+}
+
+// DeleteRole deletes a role from a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) DeleteRole(ctx context.Context, gid, rid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteRole", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("rid", rid.ToString())))
+	defer span.End()
+
+	if err := d.checkCanManageRole(gid, rid, "delete role"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting role", "gid", gid.ToString(), "rid", rid.ToString())
+
+	err := d.manageNoContent(ctx, d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/guilds/%d/roles/%d", d.apiURL, gid, rid), reason, nil)
+	return errors.Wrap(err, "could not delete role")
+}
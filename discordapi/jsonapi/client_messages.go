@@ -0,0 +1,382 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/httpclient"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
+)
+
+// ErrInvalidBulkDelete is the error returned when a bulk delete request would be rejected by discord
+var ErrInvalidBulkDelete = errors.New("invalid bulk delete")
+
+// These are the limits discord imposes on bulk message deletion
+const (
+	BulkDeleteMin    = 2
+	BulkDeleteMax    = 100
+	BulkDeleteMaxAge = 14 * 24 * time.Hour
+)
+
+// messagesPageSize is the maximum number of messages discord returns per history request
+const messagesPageSize = 100
+
+func checkNoContent(resp *http.Response, body []byte) error {
+	if resp.StatusCode != http.StatusNoContent && (resp.StatusCode < http.StatusOK || resp.StatusCode >= 300) {
+		return errors.Wrap(httpclient.NewAPIError(resp.StatusCode, body), "non-2xx response")
+	}
+
+	return nil
+}
+
+// EditMessage edits a message previously sent by the bot
+func (d *DiscordJSONClient) EditMessage(ctx context.Context, cid, mid snowflake.Snowflake, m marshaler) (respData entity.Message, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditMessage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+
+	var b []byte
+
+	b, err = m.MarshalToJSON()
+	if err != nil {
+		return respData, errors.Wrap(err, "could not marshal message as json")
+	}
+
+	files := uploadsOf(m)
+	b, err = withAttachments(b, files)
+	if err != nil {
+		return respData, err
+	}
+
+	level.Info(logger).Message("editing message", "payload", string(b), "files", len(files))
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	r, contentType := requestBody(b, files)
+	header := &http.Header{}
+	header.Set("Content-Type", contentType)
+	resp, err := d.deps.HTTPClient().PatchJSON(ctx, fmt.Sprintf("%s/channels/%d/messages/%d", d.apiURL, cid, mid), header, r, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message edit")
+	}
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.MessagesEditedCount, 1, telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	err = respData.Snowflakify()
+	if err != nil {
+		return respData, errors.Wrap(err, "could not snowflakify message response information")
+	}
+
+	return respData, nil
+}
+
+// DeleteMessage deletes a message
+func (d *DiscordJSONClient) DeleteMessage(ctx context.Context, cid, mid snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteMessage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+	level.Info(logger).Message("deleting message", "cid", cid.ToString(), "mid", mid.ToString())
+
+	err := d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := d.deps.HTTPClient().DeleteBody(ctx, fmt.Sprintf("%s/channels/%d/messages/%d", d.apiURL, cid, mid), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the message delete")
+	}
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.MessagesDeletedCount, 1, telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// BulkDeleteMessages deletes between 2 and 100 messages at once
+//
+// Discord refuses to bulk delete messages older than 14 days, so such requests are
+// rejected with ErrInvalidBulkDelete before being sent
+func (d *DiscordJSONClient) BulkDeleteMessages(ctx context.Context, cid snowflake.Snowflake, mids []snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "BulkDeleteMessages", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+
+	if len(mids) < BulkDeleteMin || len(mids) > BulkDeleteMax {
+		return errors.Wrap(ErrInvalidBulkDelete, "wrong number of messages", "count", len(mids))
+	}
+
+	cutoff := time.Now().Add(-BulkDeleteMaxAge)
+	ids := make([]string, 0, len(mids))
+	for _, mid := range mids {
+		if mid.Time().Before(cutoff) {
+			return errors.Wrap(ErrInvalidBulkDelete, "message is too old", "mid", mid.ToString())
+		}
+		ids = append(ids, mid.ToString())
+	}
+
+	b, err := json.Marshal(struct {
+		Messages []string `json:"messages"`
+	}{ids})
+	if err != nil {
+		return errors.Wrap(err, "could not marshal message ids as json")
+	}
+
+	level.Info(logger).Message("bulk deleting messages", "cid", cid.ToString(), "count", len(ids))
+	r := bytes.NewReader(b)
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	header := &http.Header{}
+	header.Set("Content-Type", "application/json")
+	resp, body, err := d.deps.HTTPClient().PostBody(ctx, fmt.Sprintf("%s/channels/%d/messages/bulk-delete", d.apiURL, cid), header, r)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the bulk message delete")
+	}
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.MessagesDeletedCount, int64(len(ids)), telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// PinMessage pins a message in its channel
+func (d *DiscordJSONClient) PinMessage(ctx context.Context, cid, mid snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "PinMessage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+	level.Info(logger).Message("pinning message", "cid", cid.ToString(), "mid", mid.ToString())
+
+	err := d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := d.deps.HTTPClient().PutBody(ctx, fmt.Sprintf("%s/channels/%d/pins/%d", d.apiURL, cid, mid), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the message pin")
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// UnpinMessage unpins a message in its channel
+func (d *DiscordJSONClient) UnpinMessage(ctx context.Context, cid, mid snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "UnpinMessage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+	level.Info(logger).Message("unpinning message", "cid", cid.ToString(), "mid", mid.ToString())
+
+	err := d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := d.deps.HTTPClient().DeleteBody(ctx, fmt.Sprintf("%s/channels/%d/pins/%d", d.apiURL, cid, mid), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the message unpin")
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// CrosspostMessage publishes a message in an announcement channel to the channels following it
+func (d *DiscordJSONClient) CrosspostMessage(ctx context.Context, cid, mid snowflake.Snowflake) (respData entity.Message, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CrosspostMessage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, d.deps.Logger())
+	level.Info(logger).Message("crossposting message", "cid", cid.ToString(), "mid", mid.ToString())
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().PostJSON(ctx, fmt.Sprintf("%s/channels/%d/messages/%d/crosspost", d.apiURL, cid, mid), nil, nil, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message crosspost")
+	}
+
+	err = respData.Snowflakify()
+	if err != nil {
+		return respData, errors.Wrap(err, "could not snowflakify message response information")
+	}
+
+	return respData, nil
+}
+
+// MessageHistoryOptions selects which part of a channel's history ChannelMessages pages through
+//
+// At most one of Before, After, and Around should be set. Before pages backwards in time
+// (newest first), After pages forwards in time (oldest first), and Around returns a single
+// page of messages centered on the given message. With none set, history is paged backwards
+// from the most recent message. Limit caps the total number of messages returned (0 means no cap).
+type MessageHistoryOptions struct {
+	Before snowflake.Snowflake
+	After  snowflake.Snowflake
+	Around snowflake.Snowflake
+	Limit  int
+}
+
+// MessageIterator pages through the history of a channel
+//
+// Usage:
+//
+//	it := client.ChannelMessages(ctx, cid, jsonapi.MessageHistoryOptions{})
+//	for it.Next() {
+//		m := it.Message()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type MessageIterator struct {
+	client *DiscordJSONClient
+	ctx    context.Context
+	cid    snowflake.Snowflake
+	opts   MessageHistoryOptions
+
+	page    []entity.Message
+	current entity.Message
+	seen    int
+	done    bool
+	err     error
+}
+
+// ChannelMessages creates an iterator over the message history of a channel
+//
+// Pages are fetched lazily as the iterator advances, and each page waits on the message rate limiter
+func (d *DiscordJSONClient) ChannelMessages(ctx context.Context, cid snowflake.Snowflake, opts MessageHistoryOptions) *MessageIterator {
+	return &MessageIterator{
+		client: d,
+		ctx:    ctx,
+		cid:    cid,
+		opts:   opts,
+	}
+}
+
+// Next advances the iterator, returning false once there are no more messages or an error occurred
+func (it *MessageIterator) Next() bool {
+	if it.err != nil || (it.opts.Limit > 0 && it.seen >= it.opts.Limit) {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		if it.err = it.fetch(); it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+	it.seen++
+	return true
+}
+
+// Message returns the message the iterator is currently positioned at
+func (it *MessageIterator) Message() entity.Message {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *MessageIterator) Err() error {
+	return it.err
+}
+
+func (it *MessageIterator) fetch() error {
+	limit := messagesPageSize
+	if it.opts.Limit > 0 && it.opts.Limit-it.seen < limit {
+		limit = it.opts.Limit - it.seen
+	}
+
+	q := url.Values{}
+	q.Set("limit", fmt.Sprintf("%d", limit))
+
+	switch {
+	case it.opts.Around != 0:
+		q.Set("around", it.opts.Around.ToString())
+	case it.opts.After != 0:
+		q.Set("after", it.opts.After.ToString())
+	case it.opts.Before != 0:
+		q.Set("before", it.opts.Before.ToString())
+	}
+
+	page, err := it.client.getChannelMessagesPage(it.ctx, it.cid, q)
+	if err != nil {
+		return err
+	}
+
+	if it.opts.Around != 0 || len(page) < limit {
+		it.done = true
+	}
+
+	if len(page) == 0 {
+		return nil
+	}
+
+	if it.opts.After != 0 && it.opts.Around == 0 {
+		sort.Slice(page, func(i, j int) bool { return page[i].IDSnowflake < page[j].IDSnowflake })
+		it.opts.After = page[len(page)-1].IDSnowflake
+	} else {
+		sort.Slice(page, func(i, j int) bool { return page[i].IDSnowflake > page[j].IDSnowflake })
+		it.opts.Before = page[len(page)-1].IDSnowflake
+	}
+
+	it.page = page
+	return nil
+}
+
+func (d *DiscordJSONClient) getChannelMessagesPage(ctx context.Context, cid snowflake.Snowflake, q url.Values) (msgs []entity.Message, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "getChannelMessagesPage", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/channels/%d/messages?%s", d.apiURL, cid, q.Encode()), nil, &msgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get channel messages", "cid", cid.ToString())
+	}
+
+	for i := range msgs {
+		if err = msgs[i].Snowflakify(); err != nil {
+			return nil, errors.Wrap(err, "could not snowflakify message")
+		}
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,275 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// historyServer serves the history of channel 1, which has the messages with ids 1 to count
+type historyServer struct {
+	count int
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (s *historyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.RawQuery)
+	s.mu.Unlock()
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	before, _ := strconv.Atoi(q.Get("before"))
+	after, _ := strconv.Atoi(q.Get("after"))
+	around, _ := strconv.Atoi(q.Get("around"))
+
+	// discord returns every page newest first
+	var ids []int
+	switch {
+	case around != 0:
+		for id := around + limit/2; id > around-limit/2 && len(ids) < limit; id-- {
+			if id >= 1 && id <= s.count {
+				ids = append(ids, id)
+			}
+		}
+	case after != 0:
+		for id := after + 1; id <= s.count && len(ids) < limit; id++ {
+			ids = append(ids, id)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	default:
+		start := s.count
+		if before != 0 {
+			start = before - 1
+		}
+		for id := start; id >= 1 && len(ids) < limit; id-- {
+			ids = append(ids, id)
+		}
+	}
+
+	msgs := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, map[string]string{"id": strconv.Itoa(id), "channel_id": "1"})
+	}
+
+	b, _ := json.Marshal(msgs)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func TestMessageIterator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        MessageHistoryOptions
+		wantFirst   snowflake.Snowflake
+		wantLast    snowflake.Snowflake
+		wantCount   int
+		wantQueries []string
+	}{
+		{
+			name:      "all history, newest first",
+			opts:      MessageHistoryOptions{},
+			wantFirst: 250,
+			wantLast:  1,
+			wantCount: 250,
+			wantQueries: []string{
+				"limit=100",
+				"before=151&limit=100",
+				"before=51&limit=100",
+			},
+		},
+		{
+			name:      "limit spanning pages",
+			opts:      MessageHistoryOptions{Limit: 150},
+			wantFirst: 250,
+			wantLast:  101,
+			wantCount: 150,
+			wantQueries: []string{
+				"limit=100",
+				"before=151&limit=50",
+			},
+		},
+		{
+			name:      "before",
+			opts:      MessageHistoryOptions{Before: 120},
+			wantFirst: 119,
+			wantLast:  1,
+			wantCount: 119,
+			wantQueries: []string{
+				"before=120&limit=100",
+				"before=20&limit=100",
+			},
+		},
+		{
+			name:      "after, oldest first",
+			opts:      MessageHistoryOptions{After: 90},
+			wantFirst: 91,
+			wantLast:  250,
+			wantCount: 160,
+			wantQueries: []string{
+				"after=90&limit=100",
+				"after=190&limit=100",
+			},
+		},
+		{
+			name:      "around is a single page",
+			opts:      MessageHistoryOptions{Around: 100, Limit: 10},
+			wantFirst: 105,
+			wantLast:  96,
+			wantCount: 10,
+			wantQueries: []string{
+				"around=100&limit=10",
+			},
+		},
+		{
+			name:      "exact multiple of the page size",
+			opts:      MessageHistoryOptions{Before: 201},
+			wantFirst: 200,
+			wantLast:  1,
+			wantCount: 200,
+			wantQueries: []string{
+				"before=201&limit=100",
+				"before=101&limit=100",
+				"before=1&limit=100",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := &historyServer{count: 250}
+			d := newTestClient(t, srv)
+
+			it := d.ChannelMessages(context.Background(), 1, tt.opts)
+
+			var ids []snowflake.Snowflake
+			for it.Next() {
+				ids = append(ids, it.Message().IDSnowflake)
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+
+			if len(ids) != tt.wantCount {
+				t.Fatalf("got %d messages, want %d", len(ids), tt.wantCount)
+			}
+
+			if ids[0] != tt.wantFirst || ids[len(ids)-1] != tt.wantLast {
+				t.Errorf("got messages %v to %v, want %v to %v", ids[0], ids[len(ids)-1], tt.wantFirst, tt.wantLast)
+			}
+
+			for i := 1; i < len(ids); i++ {
+				if ids[i] == ids[i-1] {
+					t.Errorf("message %v repeated", ids[i])
+				}
+			}
+
+			if strings.Join(srv.queries, " ") != strings.Join(tt.wantQueries, " ") {
+				t.Errorf("queries = %q, want %q", srv.queries, tt.wantQueries)
+			}
+		})
+	}
+}
+
+func TestMessageIterator_error(t *testing.T) {
+	t.Parallel()
+
+	d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"message": "Missing Access", "code": 50001}`)
+	}))
+
+	it := d.ChannelMessages(context.Background(), 1, MessageHistoryOptions{})
+	if it.Next() {
+		t.Fatal("Next() = true, want false")
+	}
+
+	if it.Err() == nil {
+		t.Error("Err() = nil, want an error")
+	}
+}
+
+func TestDiscordJSONClient_BulkDeleteMessages(t *testing.T) {
+	t.Parallel()
+
+	recent := snowflake.FromTime(time.Now().Add(-time.Hour))
+	old := snowflake.FromTime(time.Now().Add(-BulkDeleteMaxAge - time.Hour))
+
+	many := func(n int) []snowflake.Snowflake {
+		mids := make([]snowflake.Snowflake, n)
+		for i := range mids {
+			mids[i] = recent + snowflake.Snowflake(i)
+		}
+		return mids
+	}
+
+	tests := []struct {
+		name     string
+		mids     []snowflake.Snowflake
+		wantErr  error
+		wantSent bool
+	}{
+		{name: "one message", mids: many(1), wantErr: ErrInvalidBulkDelete},
+		{name: "no messages", mids: nil, wantErr: ErrInvalidBulkDelete},
+		{name: "too many messages", mids: many(BulkDeleteMax + 1), wantErr: ErrInvalidBulkDelete},
+		{name: "too old", mids: []snowflake.Snowflake{recent, old}, wantErr: ErrInvalidBulkDelete},
+		{name: "minimum", mids: many(BulkDeleteMin), wantSent: true},
+		{name: "maximum", mids: many(BulkDeleteMax), wantSent: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sent []string
+			d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/channels/1/messages/bulk-delete" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				var body struct {
+					Messages []string `json:"messages"`
+				}
+				_ = json.UnmarshalFromReader(r.Body, &body)
+				sent = body.Messages
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			err := d.BulkDeleteMessages(context.Background(), 1, tt.mids)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("BulkDeleteMessages() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if (sent != nil) != tt.wantSent {
+				t.Fatalf("request sent = %v, want %v", sent != nil, tt.wantSent)
+			}
+
+			if tt.wantSent && (len(sent) != len(tt.mids) || sent[0] != fmt.Sprint(uint64(tt.mids[0]))) {
+				t.Errorf("sent %v, want %v", sent, tt.mids)
+			}
+		})
+	}
+}
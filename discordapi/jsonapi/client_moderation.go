@@ -0,0 +1,441 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
+)
+
+// ErrInvalidModeration is the error returned when a moderation request would be rejected by discord
+var ErrInvalidModeration = errors.New("invalid moderation request")
+
+// These are the limits discord imposes on moderation requests
+const (
+	MaxAuditLogReasonLength = 512
+	MaxBanDeleteWindow      = 7 * 24 * time.Hour
+	MaxTimeoutDuration      = 28 * 24 * time.Hour
+)
+
+// bansPageSize is the maximum number of bans discord returns per request
+const bansPageSize = 1000
+
+// HierarchyError is the error returned when the bot does not sit high enough in the
+// guild role hierarchy to perform a moderation action on a member or role
+type HierarchyError struct {
+	Action string
+	Guild  snowflake.Snowflake
+	Target snowflake.Snowflake
+}
+
+// Error returns the error message
+func (e *HierarchyError) Error() string {
+	return fmt.Sprintf("insufficient role hierarchy to %s %s in guild %s", e.Action, e.Target.ToString(), e.Guild.ToString())
+}
+
+// HierarchyChecker determines if the bot sits high enough in the role hierarchy of a guild to
+// perform a moderation action, so that the moderation endpoints can refuse requests that discord
+// would reject (see SetHierarchyChecker)
+//
+// Both methods should return true when they cannot tell (for example, if the guild is not known yet),
+// so that discord makes the decision
+type HierarchyChecker interface {
+	// CanModerate determines if the bot can kick, ban, time out, or rename the member with id uid
+	CanModerate(gid, uid snowflake.Snowflake) bool
+	// CanManageRole determines if the bot can grant or remove the role with id rid
+	CanManageRole(gid, rid snowflake.Snowflake) bool
+}
+
+// SetHierarchyChecker turns on the role hierarchy checks of the moderation endpoints (nil turns them off)
+//
+// A bot can use the checker of its session (session.Session.BotHierarchy)
+func (d *DiscordJSONClient) SetHierarchyChecker(hc HierarchyChecker) {
+	d.hierarchy = hc
+}
+
+// checkCanModerate returns a HierarchyError if the hierarchy checker knows that the bot cannot act on the target member
+func (d *DiscordJSONClient) checkCanModerate(gid, uid snowflake.Snowflake, action string) error {
+	if d.hierarchy == nil || d.hierarchy.CanModerate(gid, uid) {
+		return nil
+	}
+
+	return &HierarchyError{Action: action, Guild: gid, Target: uid}
+}
+
+// checkCanManageRole returns a HierarchyError if the hierarchy checker knows that the bot cannot grant or remove the role
+func (d *DiscordJSONClient) checkCanManageRole(gid, rid snowflake.Snowflake, action string) error {
+	if d.hierarchy == nil || d.hierarchy.CanManageRole(gid, rid) {
+		return nil
+	}
+
+	return &HierarchyError{Action: action, Guild: gid, Target: rid}
+}
+
+func auditLogHeader(reason string) *http.Header {
+	header := &http.Header{}
+
+	if reason != "" {
+		r := []rune(reason)
+		if len(r) > MaxAuditLogReasonLength {
+			r = r[:MaxAuditLogReasonLength]
+		}
+		header.Set("X-Audit-Log-Reason", url.PathEscape(string(r)))
+	}
+
+	return header
+}
+
+// auditedRequest prepares the headers and json body of a request that records an audit log reason
+func auditedRequest(reason string, payload interface{}) (*http.Header, io.Reader, error) {
+	header := auditLogHeader(reason)
+
+	if payload == nil {
+		return header, nil, nil
+	}
+
+	var b []byte
+	var err error
+
+	if m, ok := payload.(marshaler); ok {
+		b, err = m.MarshalToJSON()
+	} else {
+		b, err = json.Marshal(payload)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not marshal payload as json")
+	}
+
+	header.Set("Content-Type", "application/json")
+	return header, bytes.NewReader(b), nil
+}
+
+type bodyRequester = func(context.Context, string, *http.Header, io.Reader) (*http.Response, []byte, error)
+
+// moderate sends a moderation request that has no meaningful response body
+func (d *DiscordJSONClient) moderate(ctx context.Context, action string, do bodyRequester, u, reason string, payload interface{}) error {
+	logger := logging.WithContext(ctx, d.deps.Logger())
+
+	header, r, err := auditedRequest(reason, payload)
+	if err != nil {
+		return err
+	}
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := do(ctx, u, header, r)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the moderation request", "action", action)
+	}
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.ModerationActionsCount, 1, telemetry.KVString(stats.TagAction, action), telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// KickMember removes a member from a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) KickMember(ctx context.Context, gid, uid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "KickMember", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString())))
+	defer span.End()
+
+	if err := d.checkCanModerate(gid, uid, "kick"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("kicking member", "gid", gid.ToString(), "uid", uid.ToString())
+
+	return d.moderate(ctx, "kick", d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/guilds/%d/members/%d", d.apiURL, gid, uid), reason, nil)
+}
+
+// BanMember bans a user from a guild, deleting the messages they sent in the
+// preceding deleteMessages window (at most 7 days; 0 deletes nothing)
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) BanMember(ctx context.Context, gid, uid snowflake.Snowflake, deleteMessages time.Duration, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "BanMember", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString())))
+	defer span.End()
+
+	if deleteMessages < 0 || deleteMessages > MaxBanDeleteWindow {
+		return errors.Wrap(ErrInvalidModeration, "ban message deletion window out of range", "window", deleteMessages.String())
+	}
+
+	if err := d.checkCanModerate(gid, uid, "ban"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("banning member", "gid", gid.ToString(), "uid", uid.ToString())
+
+	payload := struct {
+		DeleteMessageSeconds int64 `json:"delete_message_seconds"`
+	}{int64(deleteMessages / time.Second)}
+
+	return d.moderate(ctx, "ban", d.deps.HTTPClient().PutBody, fmt.Sprintf("%s/guilds/%d/bans/%d", d.apiURL, gid, uid), reason, payload)
+}
+
+// UnbanMember lifts the ban of a user from a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) UnbanMember(ctx context.Context, gid, uid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "UnbanMember", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("unbanning member", "gid", gid.ToString(), "uid", uid.ToString())
+
+	return d.moderate(ctx, "unban", d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/guilds/%d/bans/%d", d.apiURL, gid, uid), reason, nil)
+}
+
+// TimeoutMember prevents a member from communicating in a guild until the given time (at most 28 days away)
+//
+// A zero until removes any existing timeout. If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) TimeoutMember(ctx context.Context, gid, uid snowflake.Snowflake, until time.Time, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "TimeoutMember", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString())))
+	defer span.End()
+
+	var disabledUntil *string
+	if !until.IsZero() {
+		if time.Until(until) > MaxTimeoutDuration {
+			return errors.Wrap(ErrInvalidModeration, "timeout too long", "until", until.String())
+		}
+
+		ts := until.UTC().Format(time.RFC3339)
+		disabledUntil = &ts
+	}
+
+	if err := d.checkCanModerate(gid, uid, "timeout"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("timing out member", "gid", gid.ToString(), "uid", uid.ToString(), "until", until.String())
+
+	payload := struct {
+		CommunicationDisabledUntil *string `json:"communication_disabled_until"`
+	}{disabledUntil}
+
+	return d.moderate(ctx, "timeout", d.deps.HTTPClient().PatchBody, fmt.Sprintf("%s/guilds/%d/members/%d", d.apiURL, gid, uid), reason, payload)
+}
+
+// SetMemberNick changes the nickname of a member of a guild (see SetOwnNick for the bot itself)
+//
+// An empty nick resets the nickname. If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) SetMemberNick(ctx context.Context, gid, uid snowflake.Snowflake, nick, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "SetMemberNick", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString())))
+	defer span.End()
+
+	if err := d.checkCanModerate(gid, uid, "rename"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("setting member nickname", "gid", gid.ToString(), "uid", uid.ToString(), "nick", nick)
+
+	return d.moderate(ctx, "nick", d.deps.HTTPClient().PatchBody, fmt.Sprintf("%s/guilds/%d/members/%d", d.apiURL, gid, uid), reason, nickPayload(nick))
+}
+
+// SetOwnNick changes the nickname of the bot in a guild
+//
+// An empty nick resets the nickname. If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) SetOwnNick(ctx context.Context, gid snowflake.Snowflake, nick, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "SetOwnNick", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("setting own nickname", "gid", gid.ToString(), "nick", nick)
+
+	return d.moderate(ctx, "nick", d.deps.HTTPClient().PatchBody, fmt.Sprintf("%s/guilds/%d/members/@me", d.apiURL, gid), reason, nickPayload(nick))
+}
+
+func nickPayload(nick string) interface{} {
+	var n *string
+	if nick != "" {
+		n = &nick
+	}
+
+	return struct {
+		Nick *string `json:"nick"`
+	}{n}
+}
+
+// AddMemberRole grants a role to a member of a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) AddMemberRole(ctx context.Context, gid, uid, rid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "AddMemberRole", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString()), telemetry.KVString("rid", rid.ToString())))
+	defer span.End()
+
+	if err := d.checkCanManageRole(gid, rid, "grant role"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("adding member role", "gid", gid.ToString(), "uid", uid.ToString(), "rid", rid.ToString())
+
+	return d.moderate(ctx, "add_role", d.deps.HTTPClient().PutBody, fmt.Sprintf("%s/guilds/%d/members/%d/roles/%d", d.apiURL, gid, uid, rid), reason, nil)
+}
+
+// RemoveMemberRole removes a role from a member of a guild
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) RemoveMemberRole(ctx context.Context, gid, uid, rid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "RemoveMemberRole", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("uid", uid.ToString()), telemetry.KVString("rid", rid.ToString())))
+	defer span.End()
+
+	if err := d.checkCanManageRole(gid, rid, "remove role"); err != nil {
+		return err
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("removing member role", "gid", gid.ToString(), "uid", uid.ToString(), "rid", rid.ToString())
+
+	return d.moderate(ctx, "remove_role", d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/guilds/%d/members/%d/roles/%d", d.apiURL, gid, uid, rid), reason, nil)
+}
+
+// BanListOptions selects which part of a guild's ban list Bans pages through
+//
+// Bans are ordered by user id. If Before is set, the list is paged backwards from
+// that user id; otherwise it is paged forwards from After (the start of the list
+// if not set). Limit caps the total number of bans returned (0 means no cap).
+type BanListOptions struct {
+	Before snowflake.Snowflake
+	After  snowflake.Snowflake
+	Limit  int
+}
+
+// BanIterator pages through the ban list of a guild
+//
+// It is used in the same way as MessageIterator
+type BanIterator struct {
+	client    *DiscordJSONClient
+	ctx       context.Context
+	gid       snowflake.Snowflake
+	opts      BanListOptions
+	backwards bool
+
+	page    []entity.Ban
+	current entity.Ban
+	seen    int
+	done    bool
+	err     error
+}
+
+// Bans creates an iterator over the ban list of a guild
+//
+// Pages are fetched lazily as the iterator advances, and each page waits on the message rate limiter
+func (d *DiscordJSONClient) Bans(ctx context.Context, gid snowflake.Snowflake, opts BanListOptions) *BanIterator {
+	return &BanIterator{
+		client:    d,
+		ctx:       ctx,
+		gid:       gid,
+		opts:      opts,
+		backwards: opts.Before != 0,
+	}
+}
+
+// Next advances the iterator, returning false once there are no more bans or an error occurred
+func (it *BanIterator) Next() bool {
+	if it.err != nil || (it.opts.Limit > 0 && it.seen >= it.opts.Limit) {
+		return false
+	}
+
+	if len(it.page) == 0 {
+		if it.done {
+			return false
+		}
+
+		if it.err = it.fetch(); it.err != nil || len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.current, it.page = it.page[0], it.page[1:]
+	it.seen++
+	return true
+}
+
+// Ban returns the ban the iterator is currently positioned at
+func (it *BanIterator) Ban() entity.Ban {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *BanIterator) Err() error {
+	return it.err
+}
+
+func (it *BanIterator) fetch() error {
+	limit := bansPageSize
+	if it.opts.Limit > 0 && it.opts.Limit-it.seen < limit {
+		limit = it.opts.Limit - it.seen
+	}
+
+	q := url.Values{}
+	q.Set("limit", fmt.Sprintf("%d", limit))
+
+	if it.backwards {
+		q.Set("before", it.opts.Before.ToString())
+	} else if it.opts.After != 0 {
+		q.Set("after", it.opts.After.ToString())
+	}
+
+	page, err := it.client.getBansPage(it.ctx, it.gid, q)
+	if err != nil {
+		return err
+	}
+
+	if len(page) < limit {
+		it.done = true
+	}
+
+	if len(page) == 0 {
+		return nil
+	}
+
+	if it.backwards {
+		sort.Slice(page, func(i, j int) bool { return page[i].User.IDSnowflake > page[j].User.IDSnowflake })
+		it.opts.Before = page[len(page)-1].User.IDSnowflake
+	} else {
+		sort.Slice(page, func(i, j int) bool { return page[i].User.IDSnowflake < page[j].User.IDSnowflake })
+		it.opts.After = page[len(page)-1].User.IDSnowflake
+	}
+
+	it.page = page
+	return nil
+}
+
+func (d *DiscordJSONClient) getBansPage(ctx context.Context, gid snowflake.Snowflake, q url.Values) (bans []entity.Ban, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "getBansPage", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().GetJSON(ctx, fmt.Sprintf("%s/guilds/%d/bans?%s", d.apiURL, gid, q.Encode()), nil, &bans)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get guild bans", "gid", gid.ToString())
+	}
+
+	for i := range bans {
+		if err = bans[i].Snowflakify(); err != nil {
+			return nil, errors.Wrap(err, "could not snowflakify ban")
+		}
+	}
+
+	return bans, nil
+}
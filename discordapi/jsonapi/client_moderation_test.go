@@ -0,0 +1,236 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+type recordedRequest struct {
+	method string
+	path   string
+	reason string
+	body   string
+}
+
+// recorder records the requests it receives and answers them with 204 No Content
+type recorder struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, recordedRequest{
+		method: r.Method,
+		path:   r.URL.EscapedPath(),
+		reason: r.Header.Get("X-Audit-Log-Reason"),
+		body:   string(b),
+	})
+	rec.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// fakeHierarchy allows moderating everyone but the members in denied, and managing every role but those in denied
+type fakeHierarchy struct {
+	denied map[snowflake.Snowflake]bool
+}
+
+func (h fakeHierarchy) CanModerate(gid, uid snowflake.Snowflake) bool   { return !h.denied[uid] }
+func (h fakeHierarchy) CanManageRole(gid, rid snowflake.Snowflake) bool { return !h.denied[rid] }
+
+func TestDiscordJSONClient_moderation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	until := time.Now().Add(time.Hour).UTC()
+
+	tests := []struct {
+		name    string
+		call    func(d *DiscordJSONClient) error
+		want    recordedRequest
+		wantErr error
+	}{
+		{
+			name: "kick",
+			call: func(d *DiscordJSONClient) error { return d.KickMember(ctx, 1, 2, "spam") },
+			want: recordedRequest{method: "DELETE", path: "/guilds/1/members/2", reason: "spam"},
+		},
+		{
+			name: "ban",
+			call: func(d *DiscordJSONClient) error { return d.BanMember(ctx, 1, 2, 24*time.Hour, "") },
+			want: recordedRequest{method: "PUT", path: "/guilds/1/bans/2", body: `{"delete_message_seconds":86400}`},
+		},
+		{
+			name:    "ban window too long",
+			call:    func(d *DiscordJSONClient) error { return d.BanMember(ctx, 1, 2, MaxBanDeleteWindow+time.Second, "") },
+			wantErr: ErrInvalidModeration,
+		},
+		{
+			name:    "ban window negative",
+			call:    func(d *DiscordJSONClient) error { return d.BanMember(ctx, 1, 2, -time.Second, "") },
+			wantErr: ErrInvalidModeration,
+		},
+		{
+			name: "unban",
+			call: func(d *DiscordJSONClient) error { return d.UnbanMember(ctx, 1, 2, "appealed") },
+			want: recordedRequest{method: "DELETE", path: "/guilds/1/bans/2", reason: "appealed"},
+		},
+		{
+			name: "timeout",
+			call: func(d *DiscordJSONClient) error { return d.TimeoutMember(ctx, 1, 2, until, "") },
+			want: recordedRequest{method: "PATCH", path: "/guilds/1/members/2", body: `{"communication_disabled_until":"` + until.Format(time.RFC3339) + `"}`},
+		},
+		{
+			name: "remove timeout",
+			call: func(d *DiscordJSONClient) error { return d.TimeoutMember(ctx, 1, 2, time.Time{}, "") },
+			want: recordedRequest{method: "PATCH", path: "/guilds/1/members/2", body: `{"communication_disabled_until":null}`},
+		},
+		{
+			name: "timeout too long",
+			call: func(d *DiscordJSONClient) error {
+				return d.TimeoutMember(ctx, 1, 2, time.Now().Add(MaxTimeoutDuration+time.Hour), "")
+			},
+			wantErr: ErrInvalidModeration,
+		},
+		{
+			name: "nick",
+			call: func(d *DiscordJSONClient) error { return d.SetMemberNick(ctx, 1, 2, "Bob", "") },
+			want: recordedRequest{method: "PATCH", path: "/guilds/1/members/2", body: `{"nick":"Bob"}`},
+		},
+		{
+			name: "reset nick",
+			call: func(d *DiscordJSONClient) error { return d.SetMemberNick(ctx, 1, 2, "", "") },
+			want: recordedRequest{method: "PATCH", path: "/guilds/1/members/2", body: `{"nick":null}`},
+		},
+		{
+			name: "own nick",
+			call: func(d *DiscordJSONClient) error { return d.SetOwnNick(ctx, 1, "Helper", "") },
+			want: recordedRequest{method: "PATCH", path: "/guilds/1/members/@me", body: `{"nick":"Helper"}`},
+		},
+		{
+			name: "add role",
+			call: func(d *DiscordJSONClient) error { return d.AddMemberRole(ctx, 1, 2, 3, "") },
+			want: recordedRequest{method: "PUT", path: "/guilds/1/members/2/roles/3"},
+		},
+		{
+			name: "remove role",
+			call: func(d *DiscordJSONClient) error { return d.RemoveMemberRole(ctx, 1, 2, 3, "") },
+			want: recordedRequest{method: "DELETE", path: "/guilds/1/members/2/roles/3"},
+		},
+		{
+			name: "escaped reason",
+			call: func(d *DiscordJSONClient) error { return d.KickMember(ctx, 1, 2, "rule 3: no ünicode/spam") },
+			want: recordedRequest{method: "DELETE", path: "/guilds/1/members/2", reason: url.PathEscape("rule 3: no ünicode/spam")},
+		},
+		{
+			name: "truncated reason",
+			call: func(d *DiscordJSONClient) error {
+				return d.KickMember(ctx, 1, 2, strings.Repeat("é", MaxAuditLogReasonLength+10))
+			},
+			want: recordedRequest{method: "DELETE", path: "/guilds/1/members/2", reason: url.PathEscape(strings.Repeat("é", MaxAuditLogReasonLength))},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &recorder{}
+			d := newTestClient(t, rec)
+
+			err := tt.call(d)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(rec.requests) != 0 {
+					t.Errorf("sent %v for an invalid request", rec.requests)
+				}
+				return
+			}
+
+			if len(rec.requests) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(rec.requests))
+			}
+
+			if rec.requests[0] != tt.want {
+				t.Errorf("sent %+v, want %+v", rec.requests[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscordJSONClient_SetHierarchyChecker(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	rec := &recorder{}
+	d := newTestClient(t, rec)
+
+	// without a checker, discord decides
+	if err := d.KickMember(ctx, 1, 66, ""); err != nil {
+		t.Fatalf("KickMember() without a checker = %v", err)
+	}
+
+	d.SetHierarchyChecker(fakeHierarchy{denied: map[snowflake.Snowflake]bool{66: true, 77: true}})
+
+	calls := []struct {
+		name   string
+		call   func() error
+		action string
+		target snowflake.Snowflake
+	}{
+		{"kick", func() error { return d.KickMember(ctx, 1, 66, "") }, "kick", 66},
+		{"ban", func() error { return d.BanMember(ctx, 1, 66, 0, "") }, "ban", 66},
+		{"timeout", func() error { return d.TimeoutMember(ctx, 1, 66, time.Now().Add(time.Hour), "") }, "timeout", 66},
+		{"nick", func() error { return d.SetMemberNick(ctx, 1, 66, "x", "") }, "rename", 66},
+		{"add role", func() error { return d.AddMemberRole(ctx, 1, 2, 77, "") }, "grant role", 77},
+		{"remove role", func() error { return d.RemoveMemberRole(ctx, 1, 2, 77, "") }, "remove role", 77},
+	}
+
+	for _, c := range calls {
+		var herr *HierarchyError
+		if err := c.call(); !errors.As(err, &herr) {
+			t.Errorf("%s: error = %v, want a HierarchyError", c.name, err)
+			continue
+		}
+
+		if herr.Action != c.action || herr.Guild != 1 || herr.Target != c.target {
+			t.Errorf("%s: error = %+v", c.name, herr)
+		}
+	}
+
+	if len(rec.requests) != 1 {
+		t.Errorf("sent %d requests, want only the one made without a checker", len(rec.requests))
+	}
+
+	// allowed targets go through
+	if err := d.KickMember(ctx, 1, 2, ""); err != nil {
+		t.Errorf("KickMember() of an allowed target = %v", err)
+	}
+
+	d.SetHierarchyChecker(nil)
+	if err := d.KickMember(ctx, 1, 66, ""); err != nil {
+		t.Errorf("KickMember() with the checker removed = %v", err)
+	}
+
+	if len(rec.requests) != 3 {
+		t.Errorf("sent %d requests, want 3", len(rec.requests))
+	}
+}
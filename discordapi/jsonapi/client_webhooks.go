@@ -0,0 +1,323 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
+)
+
+// ErrInteractionExpired is the error returned when using an interaction token that is no longer valid
+var ErrInteractionExpired = errors.New("interaction token expired")
+
+// InteractionTokenLifetime is how long discord accepts an interaction token for webhook requests
+const InteractionTokenLifetime = 15 * time.Minute
+
+// MessageResponse is the interface implemented by responses that can be sent as
+// webhook and interaction webhook messages (in particular, any cmdhandler.Response)
+type MessageResponse interface {
+	ToMessage() JSONMarshaler
+}
+
+// InteractionWebhook identifies the webhook of an interaction, through which the original
+// response and follow-up messages are managed
+type InteractionWebhook struct {
+	ApplicationID snowflake.Snowflake
+	InteractionID snowflake.Snowflake
+	Token         string
+}
+
+// InteractionWebhookFor creates the InteractionWebhook for the provided interaction
+func InteractionWebhookFor(ix entity.Interaction) InteractionWebhook {
+	return InteractionWebhook{
+		ApplicationID: ix.ApplicationIDSnowflake,
+		InteractionID: ix.IDSnowflake,
+		Token:         ix.Token,
+	}
+}
+
+// Expires returns the time at which the interaction token stops being valid
+//
+// This is the zero time if InteractionID is not set
+func (w InteractionWebhook) Expires() time.Time {
+	if w.InteractionID == 0 {
+		return time.Time{}
+	}
+
+	return w.InteractionID.Time().Add(InteractionTokenLifetime)
+}
+
+// Expired determines if the interaction token is known to no longer be valid
+func (w InteractionWebhook) Expired() bool {
+	exp := w.Expires()
+	return !exp.IsZero() && time.Now().After(exp)
+}
+
+func (w InteractionWebhook) messageURL(apiURL, mid string) string {
+	return fmt.Sprintf("%s/webhooks/%d/%s/messages/%s", apiURL, w.ApplicationID, w.Token, mid)
+}
+
+func (w InteractionWebhook) check() error {
+	if w.Expired() {
+		return errors.Wrap(ErrInteractionExpired, "cannot use interaction webhook", "expired_at", w.Expires().String())
+	}
+
+	return nil
+}
+
+// sendWebhookMessage sends a message payload through an interaction webhook
+func (d *DiscordJSONClient) sendWebhookMessage(ctx context.Context, do jsonRequester, u string, r MessageResponse) (respData entity.Message, err error) {
+	logger := logging.WithContext(ctx, d.deps.Logger())
+
+	m := r.ToMessage()
+
+	b, err := m.MarshalToJSON()
+	if err != nil {
+		return respData, errors.Wrap(err, "could not marshal message as json")
+	}
+
+	files := uploadsOf(m)
+	b, err = withAttachments(b, files)
+	if err != nil {
+		return respData, err
+	}
+
+	level.Info(logger).Message("sending interaction webhook message", "payload", string(b), "files", len(files))
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	body, contentType := requestBody(b, files)
+	header := &http.Header{}
+	header.Set("Content-Type", contentType)
+	_, err = do(ctx, u, header, body, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message send")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify message information")
+}
+
+// deleteWebhookMessage deletes a message sent through an interaction webhook
+func (d *DiscordJSONClient) deleteWebhookMessage(ctx context.Context, u string) error {
+	err := d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	resp, body, err := d.deps.HTTPClient().DeleteBody(ctx, u, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the message delete")
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// EditOriginalResponse edits the original response to an interaction
+//
+// This is how a deferred interaction response is completed
+func (d *DiscordJSONClient) EditOriginalResponse(ctx context.Context, w InteractionWebhook, r MessageResponse) (entity.Message, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditOriginalResponse")
+	defer span.End()
+
+	if err := w.check(); err != nil {
+		return entity.Message{}, err
+	}
+
+	return d.sendWebhookMessage(ctx, d.deps.HTTPClient().PatchJSON, w.messageURL(d.apiURL, "@original"), r)
+}
+
+// DeleteOriginalResponse deletes the original response to an interaction
+func (d *DiscordJSONClient) DeleteOriginalResponse(ctx context.Context, w InteractionWebhook) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteOriginalResponse")
+	defer span.End()
+
+	if err := w.check(); err != nil {
+		return err
+	}
+
+	return d.deleteWebhookMessage(ctx, w.messageURL(d.apiURL, "@original"))
+}
+
+// CreateFollowup sends a follow-up message for an interaction
+//
+// Responses that may be too long for a single message should be Split first, sending a follow-up for each part
+func (d *DiscordJSONClient) CreateFollowup(ctx context.Context, w InteractionWebhook, r MessageResponse) (respData entity.Message, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateFollowup")
+	defer span.End()
+
+	if err = w.check(); err != nil {
+		return respData, err
+	}
+
+	respData, err = d.sendWebhookMessage(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/webhooks/%d/%s", d.apiURL, w.ApplicationID, w.Token), r)
+
+	if err := stats.IncCounter(ctx, d.deps.Telemetry(), "jsonapi", stats.InteractionFollowupsCount, 1); err != nil {
+		logger := logging.WithContext(ctx, d.deps.Logger())
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return respData, err
+}
+
+// GetFollowup retrieves a follow-up message of an interaction
+func (d *DiscordJSONClient) GetFollowup(ctx context.Context, w InteractionWebhook, mid snowflake.Snowflake) (respData entity.Message, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "GetFollowup", telemetry.WithAttributes(telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	if err = w.check(); err != nil {
+		return respData, err
+	}
+
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().GetJSON(ctx, w.messageURL(d.apiURL, mid.ToString()), nil, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message get")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify message information")
+}
+
+// EditFollowup edits a follow-up message of an interaction
+func (d *DiscordJSONClient) EditFollowup(ctx context.Context, w InteractionWebhook, mid snowflake.Snowflake, r MessageResponse) (entity.Message, error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditFollowup", telemetry.WithAttributes(telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	if err := w.check(); err != nil {
+		return entity.Message{}, err
+	}
+
+	return d.sendWebhookMessage(ctx, d.deps.HTTPClient().PatchJSON, w.messageURL(d.apiURL, mid.ToString()), r)
+}
+
+// DeleteFollowup deletes a follow-up message of an interaction
+func (d *DiscordJSONClient) DeleteFollowup(ctx context.Context, w InteractionWebhook, mid snowflake.Snowflake) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteFollowup", telemetry.WithAttributes(telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	if err := w.check(); err != nil {
+		return err
+	}
+
+	return d.deleteWebhookMessage(ctx, w.messageURL(d.apiURL, mid.ToString()))
+}
+
+func (d *DiscordJSONClient) webhooks() webhookExecutor {
+	return webhookExecutor{deps: d.deps, apiURL: d.apiURL}
+}
+
+// CreateWebhook creates a new incoming webhook in a channel
+//
+// avatar is optional, and must be an image data uri if set. If reason is not empty,
+// it will be recorded in the guild audit log
+func (d *DiscordJSONClient) CreateWebhook(ctx context.Context, cid snowflake.Snowflake, name, avatar, reason string) (respData entity.Webhook, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "CreateWebhook", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	if name == "" {
+		return respData, errors.Wrap(ErrMissingName, "cannot create webhook")
+	}
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("creating webhook", "cid", cid.ToString(), "name", name)
+
+	payload := struct {
+		Name   string `json:"name"`
+		Avatar string `json:"avatar,omitempty"`
+	}{name, avatar}
+
+	err = d.manage(ctx, d.deps.HTTPClient().PostJSON, fmt.Sprintf("%s/channels/%d/webhooks", d.apiURL, cid), reason, payload, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not create webhook")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify webhook")
+}
+
+// ChannelWebhooks lists the webhooks of a channel
+func (d *DiscordJSONClient) ChannelWebhooks(ctx context.Context, cid snowflake.Snowflake) (hooks []entity.Webhook, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "ChannelWebhooks", telemetry.WithAttributes(telemetry.KVString("cid", cid.ToString())))
+	defer span.End()
+
+	return d.listWebhooks(ctx, fmt.Sprintf("%s/channels/%d/webhooks", d.apiURL, cid))
+}
+
+// GuildWebhooks lists the webhooks of all the channels in a guild
+func (d *DiscordJSONClient) GuildWebhooks(ctx context.Context, gid snowflake.Snowflake) (hooks []entity.Webhook, err error) {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "GuildWebhooks", telemetry.WithAttributes(telemetry.KVString("gid", gid.ToString())))
+	defer span.End()
+
+	return d.listWebhooks(ctx, fmt.Sprintf("%s/guilds/%d/webhooks", d.apiURL, gid))
+}
+
+func (d *DiscordJSONClient) listWebhooks(ctx context.Context, u string) (hooks []entity.Webhook, err error) {
+	err = d.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	_, err = d.deps.HTTPClient().GetJSON(ctx, u, nil, &hooks)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list webhooks")
+	}
+
+	for i := range hooks {
+		if err = hooks[i].Snowflakify(); err != nil {
+			return nil, errors.Wrap(err, "could not snowflakify webhook")
+		}
+	}
+
+	return hooks, nil
+}
+
+// DeleteWebhook deletes a webhook
+//
+// If reason is not empty, it will be recorded in the guild audit log
+func (d *DiscordJSONClient) DeleteWebhook(ctx context.Context, whid snowflake.Snowflake, reason string) error {
+	ctx, span := d.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteWebhook", telemetry.WithAttributes(telemetry.KVString("webhook_id", whid.ToString())))
+	defer span.End()
+
+	level.Info(logging.WithContext(ctx, d.deps.Logger())).Message("deleting webhook", "webhook_id", whid.ToString())
+
+	err := d.manageNoContent(ctx, d.deps.HTTPClient().DeleteBody, fmt.Sprintf("%s/webhooks/%d", d.apiURL, whid), reason, nil)
+	return errors.Wrap(err, "could not delete webhook")
+}
+
+// ExecuteWebhook posts a message through a webhook
+//
+// The returned message is only filled in if opts.Wait is set
+func (d *DiscordJSONClient) ExecuteWebhook(ctx context.Context, whid snowflake.Snowflake, token string, r MessageResponse, opts WebhookMessageOptions) (entity.Message, error) {
+	return d.webhooks().execute(ctx, whid, token, r, opts)
+}
+
+// GetWebhookMessage retrieves a message previously posted through a webhook
+func (d *DiscordJSONClient) GetWebhookMessage(ctx context.Context, whid snowflake.Snowflake, token string, mid, threadID snowflake.Snowflake) (entity.Message, error) {
+	return d.webhooks().message(ctx, whid, token, mid, threadID)
+}
+
+// EditWebhookMessage edits a message previously posted through a webhook
+func (d *DiscordJSONClient) EditWebhookMessage(ctx context.Context, whid snowflake.Snowflake, token string, mid snowflake.Snowflake, r MessageResponse, opts WebhookMessageOptions) (entity.Message, error) {
+	return d.webhooks().edit(ctx, whid, token, mid, r, opts)
+}
+
+// DeleteWebhookMessage deletes a message previously posted through a webhook
+func (d *DiscordJSONClient) DeleteWebhookMessage(ctx context.Context, whid snowflake.Snowflake, token string, mid, threadID snowflake.Snowflake) error {
+	return d.webhooks().delete(ctx, whid, token, mid, threadID)
+}
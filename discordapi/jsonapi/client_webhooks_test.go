@@ -0,0 +1,217 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// testResponse is a MessageResponse wrapping a fixed message
+type testResponse struct {
+	msg JSONMarshaler
+}
+
+func (r testResponse) ToMessage() JSONMarshaler { return r.msg }
+
+func TestInteractionWebhook_Expires(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	fresh := snowflake.FromTime(now.Add(-time.Minute))
+	stale := snowflake.FromTime(now.Add(-InteractionTokenLifetime - time.Minute))
+
+	tests := []struct {
+		name        string
+		w           InteractionWebhook
+		wantExpires time.Time
+		wantExpired bool
+	}{
+		{name: "no interaction id", w: InteractionWebhook{Token: "tok"}},
+		{name: "fresh", w: InteractionWebhook{InteractionID: fresh}, wantExpires: fresh.Time().Add(InteractionTokenLifetime)},
+		{name: "stale", w: InteractionWebhook{InteractionID: stale}, wantExpires: stale.Time().Add(InteractionTokenLifetime), wantExpired: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantExpires, tt.w.Expires())
+			assert.Equal(t, tt.wantExpired, tt.w.Expired())
+		})
+	}
+}
+
+func TestDiscordJSONClient_interactionWebhook(t *testing.T) {
+	t.Parallel()
+
+	fresh := InteractionWebhook{ApplicationID: 9, InteractionID: snowflake.FromTime(time.Now()), Token: "tok"}
+	stale := fresh
+	stale.InteractionID = snowflake.FromTime(time.Now().Add(-InteractionTokenLifetime - time.Minute))
+
+	resp := testResponse{Message{Content: "hello"}}
+
+	tests := []struct {
+		name       string
+		call       func(d *DiscordJSONClient, w InteractionWebhook) error
+		wantMethod string
+		wantURI    string
+		wantBody   bool
+	}{
+		{
+			name: "edit original",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				_, err := d.EditOriginalResponse(context.Background(), w, resp)
+				return err
+			},
+			wantMethod: http.MethodPatch,
+			wantURI:    "/webhooks/9/tok/messages/@original",
+			wantBody:   true,
+		},
+		{
+			name: "delete original",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				return d.DeleteOriginalResponse(context.Background(), w)
+			},
+			wantMethod: http.MethodDelete,
+			wantURI:    "/webhooks/9/tok/messages/@original",
+		},
+		{
+			name: "create follow-up",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				_, err := d.CreateFollowup(context.Background(), w, resp)
+				return err
+			},
+			wantMethod: http.MethodPost,
+			wantURI:    "/webhooks/9/tok",
+			wantBody:   true,
+		},
+		{
+			name: "get follow-up",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				_, err := d.GetFollowup(context.Background(), w, 2)
+				return err
+			},
+			wantMethod: http.MethodGet,
+			wantURI:    "/webhooks/9/tok/messages/2",
+		},
+		{
+			name: "edit follow-up",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				_, err := d.EditFollowup(context.Background(), w, 2, resp)
+				return err
+			},
+			wantMethod: http.MethodPatch,
+			wantURI:    "/webhooks/9/tok/messages/2",
+			wantBody:   true,
+		},
+		{
+			name: "delete follow-up",
+			call: func(d *DiscordJSONClient, w InteractionWebhook) error {
+				return d.DeleteFollowup(context.Background(), w, 2)
+			},
+			wantMethod: http.MethodDelete,
+			wantURI:    "/webhooks/9/tok/messages/2",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			var gotMethod, gotURI string
+			var gotBody map[string]interface{}
+			d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				gotMethod, gotURI = r.Method, r.URL.RequestURI()
+				_ = json.UnmarshalFromReader(r.Body, &gotBody)
+
+				if r.Method == http.MethodDelete {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"id": "2", "channel_id": "4", "content": "hello"}`)
+			}))
+
+			// expired tokens are refused before anything is sent
+			err := tt.call(d, stale)
+			require.ErrorIs(t, err, ErrInteractionExpired)
+			assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+			require.NoError(t, tt.call(d, fresh))
+			assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+			assert.Equal(t, tt.wantMethod, gotMethod)
+			assert.Equal(t, tt.wantURI, gotURI)
+
+			if tt.wantBody {
+				assert.Equal(t, "hello", gotBody["content"])
+			}
+		})
+	}
+}
+
+func TestDiscordJSONClient_interactionUpdateCallbacks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		call        func(d *DiscordJSONClient) error
+		wantType    InteractionCallbackType
+		wantContent string
+	}{
+		{
+			name: "deferred update",
+			call: func(d *DiscordJSONClient) error {
+				return d.DeferInteractionUpdate(context.Background(), 5, "tok")
+			},
+			wantType: CallbackTypeDeferredUpdate,
+		},
+		{
+			name: "update",
+			call: func(d *DiscordJSONClient) error {
+				return d.SendInteractionUpdate(context.Background(), 5, "tok", Message{Content: "edited"})
+			},
+			wantType:    CallbackTypeUpdate,
+			wantContent: "edited",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotMethod, gotURI string
+			var got struct {
+				Type InteractionCallbackType `json:"type"`
+				Data struct {
+					Content string `json:"content"`
+				} `json:"data"`
+			}
+			d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotURI = r.Method, r.URL.RequestURI()
+				_ = json.UnmarshalFromReader(r.Body, &got)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			require.NoError(t, tt.call(d))
+			assert.Equal(t, http.MethodPost, gotMethod)
+			assert.Equal(t, "/interactions/5/tok/callback", gotURI)
+			assert.Equal(t, tt.wantType, got.Type)
+			assert.Equal(t, tt.wantContent, got.Data.Content)
+		})
+	}
+}
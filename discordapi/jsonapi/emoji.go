@@ -0,0 +1,80 @@
+package jsonapi
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// ErrBadEmoji is the error returned when an emoji string cannot be used as a reaction
+var ErrBadEmoji = errors.New("bad emoji")
+
+// ReactionEmoji validates an emoji and formats it the way the reaction endpoints expect
+//
+// Custom emoji may be given as they appear in message content (<:name:id> or <a:name:id>)
+// or as name:id; they are returned as name:id. Unicode emoji are returned unchanged.
+func ReactionEmoji(emoji string) (string, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return "", errors.Wrap(ErrBadEmoji, "empty emoji")
+	}
+
+	if strings.HasPrefix(emoji, "<") || strings.Contains(emoji, ":") {
+		return customReactionEmoji(emoji)
+	}
+
+	for _, r := range emoji {
+		if unicode.IsSpace(r) || r == '<' || r == '>' {
+			return "", errors.Wrap(ErrBadEmoji, "unicode emoji contains invalid characters", "emoji", emoji)
+		}
+	}
+
+	// plain ascii text is not an emoji (keycap emoji include a non-ascii combining mark)
+	ascii := true
+	for _, r := range emoji {
+		if r > unicode.MaxASCII {
+			ascii = false
+			break
+		}
+	}
+
+	if ascii {
+		return "", errors.Wrap(ErrBadEmoji, "not a unicode emoji", "emoji", emoji)
+	}
+
+	return emoji, nil
+}
+
+func customReactionEmoji(emoji string) (string, error) {
+	inner := emoji
+	if strings.HasPrefix(inner, "<") {
+		if !strings.HasSuffix(inner, ">") {
+			return "", errors.Wrap(ErrBadEmoji, "unterminated custom emoji", "emoji", emoji)
+		}
+		inner = strings.TrimSuffix(strings.TrimPrefix(inner, "<"), ">")
+		if strings.HasPrefix(inner, "a:") {
+			inner = inner[1:]
+		}
+	}
+	inner = strings.TrimPrefix(inner, ":")
+
+	parts := strings.Split(inner, ":")
+	if len(parts) != 2 || parts[0] == "" {
+		return "", errors.Wrap(ErrBadEmoji, "custom emoji must have a name and an id", "emoji", emoji)
+	}
+
+	if _, err := snowflake.FromString(parts[1]); err != nil {
+		return "", errors.Wrap(ErrBadEmoji, "custom emoji id is not a snowflake", "emoji", emoji)
+	}
+
+	for _, r := range parts[0] {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "", errors.Wrap(ErrBadEmoji, "custom emoji name contains invalid characters", "emoji", emoji)
+		}
+	}
+
+	return parts[0] + ":" + parts[1], nil
+}
@@ -0,0 +1,54 @@
+package jsonapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionEmoji(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		emoji   string
+		want    string
+		wantErr bool
+	}{
+		{name: "custom", emoji: "<:party_blob:123456789012345678>", want: "party_blob:123456789012345678"},
+		{name: "animated", emoji: "<a:wave:123456789012345678>", want: "wave:123456789012345678"},
+		{name: "name and id", emoji: "wave:123456789012345678", want: "wave:123456789012345678"},
+		{name: "leading colon", emoji: ":wave:123456789012345678", want: "wave:123456789012345678"},
+		{name: "surrounding space", emoji: " <:wave:1> ", want: "wave:1"},
+		{name: "unicode", emoji: "👍", want: "👍"},
+		{name: "unicode with modifier", emoji: "👍🏽", want: "👍🏽"},
+		{name: "keycap", emoji: "1️⃣", want: "1️⃣"},
+		{name: "empty", emoji: "  ", wantErr: true},
+		{name: "ascii text", emoji: "thumbsup", wantErr: true},
+		{name: "shortcode", emoji: ":thumbsup:", wantErr: true},
+		{name: "unterminated", emoji: "<:wave:123", wantErr: true},
+		{name: "missing id", emoji: "<:wave:>", wantErr: true},
+		{name: "missing name", emoji: "<::123>", wantErr: true},
+		{name: "id is not a snowflake", emoji: "<:wave:abc>", wantErr: true},
+		{name: "too many parts", emoji: "<:wave:1:2>", wantErr: true},
+		{name: "bad name", emoji: "<:wa-ve:1>", wantErr: true},
+		{name: "unicode with space", emoji: "👍 👍", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ReactionEmoji(tt.emoji)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrBadEmoji)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
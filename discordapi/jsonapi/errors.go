@@ -0,0 +1,24 @@
+package jsonapi
+
+import "github.com/gsmcwhirter/discord-bot-lib/v24/httpclient"
+
+// APIError is the error returned (wrapped) by DiscordJSONClient methods for a non-2xx api response
+type APIError = httpclient.APIError
+
+// FieldError is a single field-validation error from an APIError
+type FieldError = httpclient.FieldError
+
+// AsAPIError finds the APIError in the chain of err, if there is one
+func AsAPIError(err error) (*APIError, bool) {
+	return httpclient.AsAPIError(err)
+}
+
+// IsMissingPermissions determines if err is an APIError caused by the bot lacking a permission
+func IsMissingPermissions(err error) bool {
+	return httpclient.IsMissingPermissions(err)
+}
+
+// IsUnknownResource determines if err is an APIError caused by a resource that does not exist
+func IsUnknownResource(err error) bool {
+	return httpclient.IsUnknownResource(err)
+}
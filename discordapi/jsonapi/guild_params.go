@@ -0,0 +1,204 @@
+package jsonapi
+
+import (
+	"strconv"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// Ptr returns a pointer to the provided value, for filling in the optional fields of parameter structs
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// OverwriteType is the kind of entity a permission overwrite applies to
+type OverwriteType int
+
+// These are the OverwriteType values
+const (
+	OverwriteTypeRole   OverwriteType = 0
+	OverwriteTypeMember OverwriteType = 1
+)
+
+// OverwriteParams describes a permission overwrite on a channel
+//
+// Allow and Deny are permission bitsets
+type OverwriteParams struct {
+	ID    snowflake.Snowflake
+	Type  OverwriteType
+	Allow int64
+	Deny  int64
+}
+
+func (o OverwriteParams) toMap(withID bool) map[string]interface{} {
+	m := map[string]interface{}{
+		"type":  o.Type,
+		"allow": strconv.FormatInt(o.Allow, 10),
+		"deny":  strconv.FormatInt(o.Deny, 10),
+	}
+
+	if withID {
+		m["id"] = o.ID.ToString()
+	}
+
+	return m
+}
+
+// MarshalToJSON marshals an OverwriteParams into json (without the id, which is part of the url)
+func (o OverwriteParams) MarshalToJSON() ([]byte, error) {
+	return json.Marshal(o.toMap(false))
+}
+
+// ChannelParams is the set of fields to set when creating or modifying a guild channel
+//
+// Only the non-nil fields are sent, so a modification leaves every other field unchanged.
+// A ParentID pointing at 0 moves the channel out of its category, and a non-nil but empty
+// PermissionOverwrites removes all overwrites
+type ChannelParams struct {
+	Name                 *string
+	Type                 *entity.ChannelType
+	Topic                *string
+	Position             *int
+	NSFW                 *bool
+	Bitrate              *int
+	UserLimit            *int
+	RateLimitPerUser     *int
+	ParentID             *snowflake.Snowflake
+	PermissionOverwrites []OverwriteParams
+}
+
+// MarshalToJSON marshals a ChannelParams into json
+func (p ChannelParams) MarshalToJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+
+	if p.Name != nil {
+		m["name"] = *p.Name
+	}
+
+	if p.Type != nil {
+		m["type"] = int(*p.Type)
+	}
+
+	if p.Topic != nil {
+		m["topic"] = *p.Topic
+	}
+
+	if p.Position != nil {
+		m["position"] = *p.Position
+	}
+
+	if p.NSFW != nil {
+		m["nsfw"] = *p.NSFW
+	}
+
+	if p.Bitrate != nil {
+		m["bitrate"] = *p.Bitrate
+	}
+
+	if p.UserLimit != nil {
+		m["user_limit"] = *p.UserLimit
+	}
+
+	if p.RateLimitPerUser != nil {
+		m["rate_limit_per_user"] = *p.RateLimitPerUser
+	}
+
+	if p.ParentID != nil {
+		m["parent_id"] = nullableSnowflake(*p.ParentID)
+	}
+
+	if p.PermissionOverwrites != nil {
+		ows := make([]map[string]interface{}, 0, len(p.PermissionOverwrites))
+		for _, ow := range p.PermissionOverwrites {
+			ows = append(ows, ow.toMap(true))
+		}
+		m["permission_overwrites"] = ows
+	}
+
+	return json.Marshal(m)
+}
+
+// RoleParams is the set of fields to set when creating or modifying a guild role
+//
+// Only the non-nil fields are sent, so a modification leaves every other field unchanged.
+// Permissions is a permission bitset
+type RoleParams struct {
+	Name        *string
+	Permissions *int64
+	Color       *int
+	Hoist       *bool
+	Mentionable *bool
+}
+
+// MarshalToJSON marshals a RoleParams into json
+func (p RoleParams) MarshalToJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+
+	if p.Name != nil {
+		m["name"] = *p.Name
+	}
+
+	if p.Permissions != nil {
+		m["permissions"] = strconv.FormatInt(*p.Permissions, 10)
+	}
+
+	if p.Color != nil {
+		m["color"] = *p.Color
+	}
+
+	if p.Hoist != nil {
+		m["hoist"] = *p.Hoist
+	}
+
+	if p.Mentionable != nil {
+		m["mentionable"] = *p.Mentionable
+	}
+
+	return json.Marshal(m)
+}
+
+// ChannelPosition is an entry in a channel reordering request
+//
+// LockPermissions syncs the channel's overwrites with its new parent, and a ParentID
+// pointing at 0 moves the channel out of its category
+type ChannelPosition struct {
+	ID              snowflake.Snowflake
+	Position        *int
+	LockPermissions *bool
+	ParentID        *snowflake.Snowflake
+}
+
+func (c ChannelPosition) toMap() map[string]interface{} {
+	m := map[string]interface{}{"id": c.ID.ToString()}
+
+	if c.Position != nil {
+		m["position"] = *c.Position
+	}
+
+	if c.LockPermissions != nil {
+		m["lock_permissions"] = *c.LockPermissions
+	}
+
+	if c.ParentID != nil {
+		m["parent_id"] = nullableSnowflake(*c.ParentID)
+	}
+
+	return m
+}
+
+// RolePosition is an entry in a role reordering request
+type RolePosition struct {
+	ID       snowflake.Snowflake
+	Position int
+}
+
+func nullableSnowflake(s snowflake.Snowflake) interface{} {
+	if s == 0 {
+		return nil
+	}
+
+	return s.ToString()
+}
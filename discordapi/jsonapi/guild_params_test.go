@@ -0,0 +1,140 @@
+package jsonapi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/json"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+// decodeParams unmarshals marshaled params into a map, where an explicit null is a present nil value
+func decodeParams(t *testing.T, m JSONMarshaler) map[string]interface{} {
+	t.Helper()
+
+	b, err := m.MarshalToJSON()
+	if err != nil {
+		t.Fatalf("MarshalToJSON() error = %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("MarshalToJSON() produced invalid json %s: %v", b, err)
+	}
+
+	return out
+}
+
+func TestChannelParams_MarshalToJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params ChannelParams
+		want   map[string]interface{}
+	}{
+		{
+			name:   "nothing set",
+			params: ChannelParams{},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "only set fields",
+			params: ChannelParams{Name: Ptr("general"), Type: Ptr(entity.ChannelType(0))},
+			want:   map[string]interface{}{"name": "general", "type": float64(0)},
+		},
+		{
+			name: "zero values",
+			params: ChannelParams{
+				Topic:            Ptr(""),
+				Position:         Ptr(0),
+				NSFW:             Ptr(false),
+				Bitrate:          Ptr(0),
+				UserLimit:        Ptr(0),
+				RateLimitPerUser: Ptr(0),
+			},
+			want: map[string]interface{}{
+				"topic":               "",
+				"position":            float64(0),
+				"nsfw":                false,
+				"bitrate":             float64(0),
+				"user_limit":          float64(0),
+				"rate_limit_per_user": float64(0),
+			},
+		},
+		{
+			name:   "parent",
+			params: ChannelParams{ParentID: Ptr(snowflake.Snowflake(123456789012345678))},
+			want:   map[string]interface{}{"parent_id": "123456789012345678"},
+		},
+		{
+			name:   "no parent is an explicit null",
+			params: ChannelParams{ParentID: Ptr(snowflake.Snowflake(0))},
+			want:   map[string]interface{}{"parent_id": nil},
+		},
+		{
+			name:   "empty overwrites clear them",
+			params: ChannelParams{PermissionOverwrites: []OverwriteParams{}},
+			want:   map[string]interface{}{"permission_overwrites": []interface{}{}},
+		},
+		{
+			name: "overwrites",
+			params: ChannelParams{PermissionOverwrites: []OverwriteParams{
+				{ID: 5, Type: OverwriteTypeMember, Allow: 1024, Deny: 0},
+			}},
+			want: map[string]interface{}{"permission_overwrites": []interface{}{
+				map[string]interface{}{"id": "5", "type": float64(1), "allow": "1024", "deny": "0"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := decodeParams(t, tt.params); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MarshalToJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleParams_MarshalToJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params RoleParams
+		want   map[string]interface{}
+	}{
+		{
+			name:   "nothing set",
+			params: RoleParams{},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "only set fields",
+			params: RoleParams{Name: Ptr("mods"), Permissions: Ptr(int64(1 << 40))},
+			want:   map[string]interface{}{"name": "mods", "permissions": "1099511627776"},
+		},
+		{
+			name:   "zero values",
+			params: RoleParams{Permissions: Ptr(int64(0)), Color: Ptr(0), Hoist: Ptr(false), Mentionable: Ptr(false)},
+			want:   map[string]interface{}{"permissions": "0", "color": float64(0), "hoist": false, "mentionable": false},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := decodeParams(t, tt.params); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MarshalToJSON() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package jsonapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+	"golang.org/x/time/rate"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/httpclient"
+)
+
+type nopLogger struct{}
+
+func (l nopLogger) Log(kv ...interface{}) error              { return nil }
+func (l nopLogger) Err(m string, e error, kv ...interface{}) {}
+func (l nopLogger) Message(m string, kv ...interface{})      {}
+func (l nopLogger) Printf(f string, a ...interface{})        {}
+
+type nopExporter struct{}
+
+func (nopExporter) ExportSpans(context.Context, []telemetry.ReadOnlySpan) error { return nil }
+func (nopExporter) Shutdown(context.Context) error                              { return nil }
+
+type testDeps struct {
+	telemeter *telemetry.Telemeter
+	doer      httpclient.Doer
+	http      *httpclient.HTTPClient
+}
+
+func (d *testDeps) Logger() Logger                    { return nopLogger{} }
+func (d *testDeps) Telemetry() *telemetry.Telemeter   { return d.telemeter }
+func (d *testDeps) HTTPDoer() httpclient.Doer         { return d.doer }
+func (d *testDeps) HTTPClient() HTTPClient            { return d.http }
+func (d *testDeps) MessageRateLimiter() *rate.Limiter { return rate.NewLimiter(rate.Inf, 1) }
+func (d *testDeps) CommandRegistrationRateLimiter() *rate.Limiter {
+	return rate.NewLimiter(rate.Inf, 1)
+}
+
+// newTestClient creates a DiscordJSONClient that talks to a test server using the handler
+func newTestClient(t *testing.T, h http.Handler) *DiscordJSONClient {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	deps := &testDeps{
+		telemeter: telemetry.NewTelemeter("test", "test", "test", nopExporter{}, nonrecording.NewNoopMeterProvider(), 1.0),
+		doer:      srv.Client(),
+	}
+	deps.http = httpclient.NewHTTPClient(deps)
+	deps.http.SetRetryPolicy(httpclient.RetryPolicy{MaxAttempts: 1})
+
+	return NewDiscordJSONClient(deps, srv.URL)
+}
@@ -16,10 +16,12 @@ type MessageReference struct {
 // Message is the json object that is sent to the discord api
 // to post a plain-text message to a server
 type Message struct {
-	Content string            `json:"content"`
-	Tts     bool              `json:"tts"`
-	ReplyTo *MessageReference `json:"message_reference,omitempty"`
-	Flags   int               `json:"flags,omitempty"`
+	Content    string             `json:"content"`
+	Tts        bool               `json:"tts"`
+	ReplyTo    *MessageReference  `json:"message_reference,omitempty"`
+	Flags      int                `json:"flags,omitempty"`
+	Components []entity.Component `json:"components,omitempty"`
+	Files      []File             `json:"-"`
 }
 
 // MarshalToJSON marshals a Message into json
@@ -27,14 +29,21 @@ func (m Message) MarshalToJSON() ([]byte, error) {
 	return json.MarshalToBuffer(m)
 }
 
+// Uploads returns the files to attach to the Message
+func (m Message) Uploads() []File {
+	return m.Files
+}
+
 // MessageWithEmbed is the json object that is sent to the discord api
 // to post an embed message to a server
 type MessageWithEmbed struct {
-	Content string            `json:"content"`
-	Tts     bool              `json:"tts"`
-	Embeds  []Embed           `json:"embeds"`
-	ReplyTo *MessageReference `json:"message_reference,omitempty"`
-	Flags   int               `json:"flags,omitempty"`
+	Content    string             `json:"content"`
+	Tts        bool               `json:"tts"`
+	Embeds     []Embed            `json:"embeds"`
+	ReplyTo    *MessageReference  `json:"message_reference,omitempty"`
+	Flags      int                `json:"flags,omitempty"`
+	Components []entity.Component `json:"components,omitempty"`
+	Files      []File             `json:"-"`
 }
 
 // MarshalToJSON marshals a MessageWithEmbed into json
@@ -42,6 +51,11 @@ func (m MessageWithEmbed) MarshalToJSON() ([]byte, error) {
 	return json.MarshalToBuffer(m)
 }
 
+// Uploads returns the files to attach to the MessageWithEmbed
+func (m MessageWithEmbed) Uploads() []File {
+	return m.Files
+}
+
 // Embed is a json object that represents an embed in a MessageWithEmbed
 type Embed struct {
 	Title       string       `json:"title,omitempty"`
@@ -51,6 +65,15 @@ type Embed struct {
 	Color       int          `json:"color,omitempty"`
 	Fields      []EmbedField `json:"fields,omitempty"`
 	Footer      EmbedFooter  `json:"footer,omitempty"`
+	Image       *EmbedImage  `json:"image,omitempty"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+}
+
+// EmbedImage is a json object that represents the image or thumbnail of an Embed
+//
+// URL may reference a file uploaded with the message (see File.AttachmentURL)
+type EmbedImage struct {
+	URL string `json:"url"`
 }
 
 // EmbedField is a json object that represents a field in an Embed
@@ -76,14 +99,49 @@ const (
 	CallbackTypeDeferredUpdate         InteractionCallbackType = 6
 	CallbackTypeUpdate                 InteractionCallbackType = 7
 	CallbackTypeAutocomplete           InteractionCallbackType = 8
+	CallbackTypeModal                  InteractionCallbackType = 9
 )
 
+// CallbackTyper is implemented by interaction responses that need a callback type other than
+// CallbackTypeChannelMessage (for example, a Modal)
+type CallbackTyper interface {
+	CallbackType() InteractionCallbackType
+}
+
 // InteractionCallbackMessage is the message from an interaction callback
 type InteractionCallbackMessage struct {
 	Type InteractionCallbackType `json:"type"`
 	Data json.RawMessage         `json:"data,omitempty"`
 }
 
+// Modal is the json object that is sent to the discord api to open a modal dialog
+//
+// Each text input is placed in an action row of its own when the Modal is marshaled
+type Modal struct {
+	CustomID   string             `json:"custom_id"`
+	Title      string             `json:"title"`
+	Components []entity.Component `json:"components"`
+}
+
+// MarshalToJSON marshals a Modal into json
+func (m Modal) MarshalToJSON() ([]byte, error) {
+	rows := make([]entity.Component, 0, len(m.Components))
+	for _, c := range m.Components {
+		if c.Type != entity.ComponentActionRow {
+			c = entity.ActionRow(c)
+		}
+		rows = append(rows, c)
+	}
+	m.Components = rows
+
+	return json.MarshalToBuffer(m)
+}
+
+// CallbackType returns CallbackTypeModal
+func (m Modal) CallbackType() InteractionCallbackType {
+	return CallbackTypeModal
+}
+
 // InteractionAutocompleteResponse represents an interaction autocomplete response
 type InteractionAutocompleteResponse struct {
 	Choices []entity.ApplicationCommandOptionChoice `json:"choices"`
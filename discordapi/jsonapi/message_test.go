@@ -0,0 +1,65 @@
+package jsonapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+func TestMessage_MarshalToJSON_components(t *testing.T) {
+	t.Parallel()
+
+	row := entity.ActionRow(
+		entity.Button(entity.ButtonPrimary, "Yes", "poll:yes"),
+		entity.LinkButton("Docs", "https://example.com"),
+	)
+	rowJSON := `[{"type":1,"components":[
+		{"type":2,"custom_id":"poll:yes","style":1,"label":"Yes"},
+		{"type":2,"style":5,"label":"Docs","url":"https://example.com"}
+	]}]`
+
+	tests := []struct {
+		name string
+		msg  JSONMarshaler
+		want string
+	}{
+		{
+			name: "message without components",
+			msg:  Message{Content: "hi"},
+			want: `{"content":"hi","tts":false}`,
+		},
+		{
+			name: "message",
+			msg:  Message{Content: "hi", Components: []entity.Component{row}},
+			want: `{"content":"hi","tts":false,"components":` + rowJSON + `}`,
+		},
+		{
+			name: "message with embed",
+			msg:  MessageWithEmbed{Content: "hi", Embeds: []Embed{{Title: "t"}}, Components: []entity.Component{row}},
+			want: `{"content":"hi","tts":false,"embeds":[{"title":"t","footer":{"text":""}}],"components":` + rowJSON + `}`,
+		},
+		{
+			name: "select menu",
+			msg: Message{Components: []entity.Component{entity.ActionRow(
+				entity.StringSelect("color", entity.SelectOption{Label: "Red", Value: "r"}).WithValues(0, 1),
+			)}},
+			want: `{"content":"","tts":false,"components":[{"type":1,"components":[
+				{"type":3,"custom_id":"color","options":[{"label":"Red","value":"r"}],"min_values":0,"max_values":1}
+			]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := tt.msg.MarshalToJSON()
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(b))
+		})
+	}
+}
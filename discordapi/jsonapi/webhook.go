@@ -0,0 +1,321 @@
+package jsonapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+	"github.com/gsmcwhirter/go-util/v10/logging/level"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"golang.org/x/time/rate"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
+)
+
+// ErrBadWebhookURL is the error returned when a webhook url cannot be parsed
+var ErrBadWebhookURL = errors.New("bad webhook url")
+
+type webhookDependencies interface {
+	Logger() Logger
+	Telemetry() *telemetry.Telemeter
+	MessageRateLimiter() *rate.Limiter
+	HTTPClient() HTTPClient
+}
+
+// WebhookMessageOptions controls how a webhook message is posted or edited
+//
+// Username and AvatarURL override the webhook's defaults for a single message (they are
+// ignored for edits). ThreadID targets a thread of the webhook's channel. If Wait is
+// set, ExecuteWebhook waits for the message to be created and returns it
+type WebhookMessageOptions struct {
+	Username  string
+	AvatarURL string
+	ThreadID  snowflake.Snowflake
+	Wait      bool
+}
+
+func (o WebhookMessageOptions) query(wait bool) string {
+	q := url.Values{}
+
+	if wait {
+		q.Set("wait", "true")
+	}
+
+	if o.ThreadID != 0 {
+		q.Set("thread_id", o.ThreadID.ToString())
+	}
+
+	if len(q) == 0 {
+		return ""
+	}
+
+	return "?" + q.Encode()
+}
+
+// payload renders the message, adding the per-message identity overrides and the
+// metadata of any attached files (which are also returned)
+func (o WebhookMessageOptions) payload(r MessageResponse, withIdentity bool) ([]byte, []File, error) {
+	msg := r.ToMessage()
+
+	b, err := msg.MarshalToJSON()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not marshal message as json")
+	}
+
+	files := uploadsOf(msg)
+	b, err = withAttachments(b, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !withIdentity || (o.Username == "" && o.AvatarURL == "") {
+		return b, files, nil
+	}
+
+	m := map[string]json.RawMessage{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, nil, errors.Wrap(err, "could not unmarshal message json")
+	}
+
+	for k, v := range map[string]string{"username": o.Username, "avatar_url": o.AvatarURL} {
+		if v == "" {
+			continue
+		}
+
+		if m[k], err = json.Marshal(v); err != nil {
+			return nil, nil, errors.Wrap(err, "could not marshal "+k)
+		}
+	}
+
+	b, err = json.Marshal(m)
+	return b, files, errors.Wrap(err, "could not marshal webhook message as json")
+}
+
+// webhookExecutor implements the token-authenticated webhook endpoints shared by
+// DiscordJSONClient and WebhookClient
+type webhookExecutor struct {
+	deps   webhookDependencies
+	apiURL string
+}
+
+func (w webhookExecutor) execute(ctx context.Context, id snowflake.Snowflake, token string, r MessageResponse, opts WebhookMessageOptions) (respData entity.Message, err error) {
+	ctx, span := w.deps.Telemetry().StartSpan(ctx, "jsonapi", "ExecuteWebhook", telemetry.WithAttributes(telemetry.KVString("webhook_id", id.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, w.deps.Logger())
+
+	b, files, err := opts.payload(r, true)
+	if err != nil {
+		return respData, err
+	}
+
+	level.Info(logger).Message("executing webhook", "webhook_id", id.ToString(), "payload", string(b), "files", len(files))
+
+	err = w.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	u := fmt.Sprintf("%s/webhooks/%d/%s%s", w.apiURL, id, token, opts.query(opts.Wait))
+	reqBody, contentType := requestBody(b, files)
+	header := &http.Header{}
+	header.Set("Content-Type", contentType)
+
+	var resp *http.Response
+	if opts.Wait {
+		resp, err = w.deps.HTTPClient().PostJSON(ctx, u, header, reqBody, &respData)
+		if err == nil {
+			err = respData.Snowflakify()
+		}
+	} else {
+		var body []byte
+		resp, body, err = w.deps.HTTPClient().PostBody(ctx, u, header, reqBody)
+		if err == nil {
+			err = checkNoContent(resp, body)
+		}
+	}
+
+	if resp != nil {
+		if err := stats.IncCounter(ctx, w.deps.Telemetry(), "jsonapi", stats.MessagesPostedCount, 1, telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+			level.Error(logger).Err("could not record stat", err)
+		}
+	}
+
+	return respData, errors.Wrap(err, "could not complete the webhook execution")
+}
+
+func (w webhookExecutor) message(ctx context.Context, id snowflake.Snowflake, token string, mid snowflake.Snowflake, threadID snowflake.Snowflake) (respData entity.Message, err error) {
+	ctx, span := w.deps.Telemetry().StartSpan(ctx, "jsonapi", "WebhookMessage", telemetry.WithAttributes(telemetry.KVString("webhook_id", id.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	err = w.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	u := fmt.Sprintf("%s/webhooks/%d/%s/messages/%d%s", w.apiURL, id, token, mid, WebhookMessageOptions{ThreadID: threadID}.query(false))
+	_, err = w.deps.HTTPClient().GetJSON(ctx, u, nil, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message get")
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify message information")
+}
+
+func (w webhookExecutor) edit(ctx context.Context, id snowflake.Snowflake, token string, mid snowflake.Snowflake, r MessageResponse, opts WebhookMessageOptions) (respData entity.Message, err error) {
+	ctx, span := w.deps.Telemetry().StartSpan(ctx, "jsonapi", "EditWebhookMessage", telemetry.WithAttributes(telemetry.KVString("webhook_id", id.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, w.deps.Logger())
+
+	b, files, err := opts.payload(r, false)
+	if err != nil {
+		return respData, err
+	}
+
+	level.Info(logger).Message("editing webhook message", "webhook_id", id.ToString(), "mid", mid.ToString(), "payload", string(b), "files", len(files))
+
+	err = w.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return respData, errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	u := fmt.Sprintf("%s/webhooks/%d/%s/messages/%d%s", w.apiURL, id, token, mid, opts.query(false))
+	reqBody, contentType := requestBody(b, files)
+	header := &http.Header{}
+	header.Set("Content-Type", contentType)
+	resp, err := w.deps.HTTPClient().PatchJSON(ctx, u, header, reqBody, &respData)
+	if err != nil {
+		return respData, errors.Wrap(err, "could not complete the message edit")
+	}
+
+	if err := stats.IncCounter(ctx, w.deps.Telemetry(), "jsonapi", stats.MessagesEditedCount, 1, telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	err = respData.Snowflakify()
+	return respData, errors.Wrap(err, "could not snowflakify message information")
+}
+
+func (w webhookExecutor) delete(ctx context.Context, id snowflake.Snowflake, token string, mid snowflake.Snowflake, threadID snowflake.Snowflake) error {
+	ctx, span := w.deps.Telemetry().StartSpan(ctx, "jsonapi", "DeleteWebhookMessage", telemetry.WithAttributes(telemetry.KVString("webhook_id", id.ToString()), telemetry.KVString("mid", mid.ToString())))
+	defer span.End()
+
+	logger := logging.WithContext(ctx, w.deps.Logger())
+	level.Info(logger).Message("deleting webhook message", "webhook_id", id.ToString(), "mid", mid.ToString())
+
+	err := w.deps.MessageRateLimiter().Wait(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error waiting for rate limiter")
+	}
+
+	u := fmt.Sprintf("%s/webhooks/%d/%s/messages/%d%s", w.apiURL, id, token, mid, WebhookMessageOptions{ThreadID: threadID}.query(false))
+	resp, body, err := w.deps.HTTPClient().DeleteBody(ctx, u, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not complete the message delete")
+	}
+
+	if err := stats.IncCounter(ctx, w.deps.Telemetry(), "jsonapi", stats.MessagesDeletedCount, 1, telemetry.KVInt(stats.TagStatus, resp.StatusCode)); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+
+	return checkNoContent(resp, body)
+}
+
+// ParseWebhookURL splits a webhook url (https://discord.com/api/webhooks/{id}/{token})
+// into the api base url, the webhook id, and the webhook token
+func ParseWebhookURL(webhookURL string) (apiURL string, id snowflake.Snowflake, token string, err error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "could not parse url", "error", err.Error())
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "url is not absolute")
+	}
+
+	idx := strings.LastIndex(u.Path, "/webhooks/")
+	if idx < 0 {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "missing webhooks path")
+	}
+
+	parts := strings.Split(strings.Trim(u.Path[idx+len("/webhooks/"):], "/"), "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "missing webhook id or token")
+	}
+
+	id, err = snowflake.FromString(parts[0])
+	if err != nil {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "bad webhook id", "error", err.Error())
+	}
+
+	if id == 0 {
+		return "", 0, "", errors.Wrap(ErrBadWebhookURL, "bad webhook id")
+	}
+
+	u.Path = u.Path[:idx]
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return u.String(), id, parts[1], nil
+}
+
+// WebhookClient posts and manages messages through a single webhook
+//
+// It authenticates with the webhook token alone, so it needs neither a bot token nor a DiscordBot.
+// The HTTPClient dependency should not be configured with bot authorization headers
+type WebhookClient struct {
+	exec  webhookExecutor
+	id    snowflake.Snowflake
+	token string
+}
+
+// NewWebhookClient creates a new WebhookClient for the webhook at the given url
+func NewWebhookClient(deps webhookDependencies, webhookURL string) (*WebhookClient, error) {
+	apiURL, id, token, err := ParseWebhookURL(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookClient{
+		exec:  webhookExecutor{deps: deps, apiURL: apiURL},
+		id:    id,
+		token: token,
+	}, nil
+}
+
+// ID returns the id of the webhook
+func (c *WebhookClient) ID() snowflake.Snowflake {
+	return c.id
+}
+
+// Execute posts a message through the webhook
+//
+// The returned message is only filled in if opts.Wait is set
+func (c *WebhookClient) Execute(ctx context.Context, r MessageResponse, opts WebhookMessageOptions) (entity.Message, error) {
+	return c.exec.execute(ctx, c.id, c.token, r, opts)
+}
+
+// Message retrieves a message previously posted through the webhook
+func (c *WebhookClient) Message(ctx context.Context, mid, threadID snowflake.Snowflake) (entity.Message, error) {
+	return c.exec.message(ctx, c.id, c.token, mid, threadID)
+}
+
+// EditMessage edits a message previously posted through the webhook
+func (c *WebhookClient) EditMessage(ctx context.Context, mid snowflake.Snowflake, r MessageResponse, opts WebhookMessageOptions) (entity.Message, error) {
+	return c.exec.edit(ctx, c.id, c.token, mid, r, opts)
+}
+
+// DeleteMessage deletes a message previously posted through the webhook
+func (c *WebhookClient) DeleteMessage(ctx context.Context, mid, threadID snowflake.Snowflake) error {
+	return c.exec.delete(ctx, c.id, c.token, mid, threadID)
+}
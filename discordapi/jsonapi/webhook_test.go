@@ -0,0 +1,142 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+)
+
+func TestParseWebhookURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		url       string
+		wantAPI   string
+		wantID    snowflake.Snowflake
+		wantToken string
+		wantErr   bool
+	}{
+		{
+			name:      "discord.com",
+			url:       "https://discord.com/api/webhooks/123/abc-DEF_456",
+			wantAPI:   "https://discord.com/api",
+			wantID:    123,
+			wantToken: "abc-DEF_456",
+		},
+		{
+			name:      "legacy discordapp.com host",
+			url:       "https://discordapp.com/api/webhooks/123/tok",
+			wantAPI:   "https://discordapp.com/api",
+			wantID:    123,
+			wantToken: "tok",
+		},
+		{
+			name:      "canary host",
+			url:       "https://canary.discord.com/api/webhooks/123/tok",
+			wantAPI:   "https://canary.discord.com/api",
+			wantID:    123,
+			wantToken: "tok",
+		},
+		{
+			name:      "versioned api path",
+			url:       "https://discord.com/api/v10/webhooks/123/tok",
+			wantAPI:   "https://discord.com/api/v10",
+			wantID:    123,
+			wantToken: "tok",
+		},
+		{
+			name:      "trailing slash",
+			url:       "https://discord.com/api/webhooks/123/tok/",
+			wantAPI:   "https://discord.com/api",
+			wantID:    123,
+			wantToken: "tok",
+		},
+		{
+			name:      "query and fragment dropped",
+			url:       "https://discord.com/api/webhooks/123/tok?wait=true#x",
+			wantAPI:   "https://discord.com/api",
+			wantID:    123,
+			wantToken: "tok",
+		},
+		{name: "non-numeric id", url: "https://discord.com/api/webhooks/abc/tok", wantErr: true},
+		{name: "negative id", url: "https://discord.com/api/webhooks/-1/tok", wantErr: true},
+		{name: "zero id", url: "https://discord.com/api/webhooks/0/tok", wantErr: true},
+		{name: "missing token", url: "https://discord.com/api/webhooks/123", wantErr: true},
+		{name: "empty token", url: "https://discord.com/api/webhooks/123//", wantErr: true},
+		{name: "extra path segment", url: "https://discord.com/api/webhooks/123/tok/github", wantErr: true},
+		{name: "not a webhook", url: "https://discord.com/api/channels/123/messages", wantErr: true},
+		{name: "no scheme", url: "discord.com/api/webhooks/123/tok", wantErr: true},
+		{name: "unparseable", url: "https://discord.com/api/webhooks/%zz/tok", wantErr: true},
+		{name: "empty", url: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiURL, id, token, err := ParseWebhookURL(tt.url)
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadWebhookURL) {
+					t.Errorf("ParseWebhookURL(%q) error = %v, want ErrBadWebhookURL", tt.url, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseWebhookURL(%q) error = %v", tt.url, err)
+			}
+
+			if apiURL != tt.wantAPI || id != tt.wantID || token != tt.wantToken {
+				t.Errorf("ParseWebhookURL(%q) = %q, %v, %q, want %q, %v, %q", tt.url, apiURL, id, token, tt.wantAPI, tt.wantID, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestDiscordJSONClient_GetWebhookMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		threadID snowflake.Snowflake
+		wantURI  string
+	}{
+		{name: "channel message", wantURI: "/webhooks/1/tok/messages/2"},
+		{name: "thread message", threadID: 3, wantURI: "/webhooks/1/tok/messages/2?thread_id=3"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotMethod, gotURI string
+			d := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotURI = r.Method, r.URL.RequestURI()
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"id": "2", "channel_id": "4", "content": "hello"}`)
+			}))
+
+			msg, err := d.GetWebhookMessage(context.Background(), 1, "tok", 2, tt.threadID)
+			if err != nil {
+				t.Fatalf("GetWebhookMessage() error = %v", err)
+			}
+
+			if gotMethod != http.MethodGet || gotURI != tt.wantURI {
+				t.Errorf("sent %s %s, want GET %s", gotMethod, gotURI, tt.wantURI)
+			}
+
+			if msg.IDSnowflake != 2 || msg.ChannelIDSnowflake != 4 || msg.Content != "hello" {
+				t.Errorf("got message %+v", msg)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gsmcwhirter/discord-bot-lib/v24/bot"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/bot/session"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
@@ -43,6 +44,10 @@ type Dispatcher struct {
 
 	dispatcherLock *sync.Mutex
 	eventDispatch  map[string][]DispatchHandlerFunc
+	changeDispatch map[string][]ChangeHandlerFunc
+
+	messageCache         *session.MessageCache
+	messageCacheHandlers bool
 
 	debug bool
 }
@@ -59,6 +64,7 @@ func NewDispatcher(deps dependencies) *Dispatcher {
 	c := &Dispatcher{
 		deps:           deps,
 		dispatcherLock: &sync.Mutex{},
+		changeDispatch: map[string][]ChangeHandlerFunc{},
 	}
 
 	c.opCodeDispatch = map[discordapi.OpCode]DispatchHandlerFunc{
@@ -71,19 +77,28 @@ func NewDispatcher(deps dependencies) *Dispatcher {
 	}
 
 	c.eventDispatch = map[string][]DispatchHandlerFunc{
-		"READY":               {c.handleReady},
-		"GUILD_CREATE":        {c.handleGuildCreate},
-		"GUILD_UPDATE":        {c.handleGuildUpdate},
-		"GUILD_DELETE":        {c.handleGuildDelete},
-		"CHANNEL_CREATE":      {c.handleChannelCreate},
-		"CHANNEL_UPDATE":      {c.handleChannelUpdate},
-		"CHANNEL_DELETE":      {c.handleChannelDelete},
-		"GUILD_MEMBER_ADD":    {c.handleGuildMemberCreate},
-		"GUILD_MEMBER_UPDATE": {c.handleGuildMemberUpdate},
-		"GUILD_MEMBER_REMOVE": {c.handleGuildMemberDelete},
-		"GUILD_ROLE_CREATE":   {c.handleGuildRoleCreate},
-		"GUILD_ROLE_UPDATE":   {c.handleGuildRoleUpdate},
-		"GUILD_ROLE_DELETE":   {c.handleGuildRoleDelete},
+		"READY":                 {c.handleReady},
+		"GUILD_CREATE":          {c.handleGuildCreate},
+		"GUILD_UPDATE":          {c.handleGuildUpdate},
+		"GUILD_DELETE":          {c.handleGuildDelete},
+		"CHANNEL_CREATE":        {c.handleChannelCreate},
+		"CHANNEL_UPDATE":        {c.handleChannelUpdate},
+		"CHANNEL_DELETE":        {c.handleChannelDelete},
+		"GUILD_MEMBER_ADD":      {c.handleGuildMemberCreate},
+		"GUILD_MEMBER_UPDATE":   {c.handleGuildMemberUpdate},
+		"GUILD_MEMBER_REMOVE":   {c.handleGuildMemberDelete},
+		"GUILD_ROLE_CREATE":     {c.handleGuildRoleCreate},
+		"GUILD_ROLE_UPDATE":     {c.handleGuildRoleUpdate},
+		"GUILD_ROLE_DELETE":     {c.handleGuildRoleDelete},
+		"THREAD_CREATE":         {c.handleThreadCreate},
+		"THREAD_UPDATE":         {c.handleThreadUpdate},
+		"THREAD_DELETE":         {c.handleThreadDelete},
+		"THREAD_LIST_SYNC":      {c.handleThreadListSync},
+		"THREAD_MEMBER_UPDATE":  {c.handleThreadMemberUpdate},
+		"THREAD_MEMBERS_UPDATE": {c.handleThreadMembersUpdate},
+		"VOICE_STATE_UPDATE":    {c.handleVoiceStateUpdate},
+		"GUILD_EMOJIS_UPDATE":   {c.handleGuildEmojisUpdate},
+		"GUILD_STICKERS_UPDATE": {c.handleGuildStickersUpdate},
 	}
 
 	return c
@@ -132,6 +147,73 @@ func (c *Dispatcher) AddHandler(event string, handler DispatchHandlerFunc) {
 	c.eventDispatch[event] = append(handlers, handler)
 }
 
+// EnableMessageCache feeds the provided message cache from the MESSAGE_* gateway events
+//
+// Once enabled, MESSAGE_EDITED and MESSAGE_DELETED change events are published with the
+// prior content of cached messages. Calling it again replaces the cache that is fed
+func (c *Dispatcher) EnableMessageCache(cache *session.MessageCache) {
+	c.dispatcherLock.Lock()
+	defer c.dispatcherLock.Unlock()
+
+	c.messageCache = cache
+
+	if c.messageCacheHandlers {
+		return
+	}
+	c.messageCacheHandlers = true
+
+	for event, handler := range map[string]DispatchHandlerFunc{
+		"MESSAGE_CREATE":      c.handleMessageCreate,
+		"MESSAGE_UPDATE":      c.handleMessageUpdate,
+		"MESSAGE_DELETE":      c.handleMessageDelete,
+		"MESSAGE_DELETE_BULK": c.handleMessageDelete,
+	} {
+		c.eventDispatch[event] = append(c.eventDispatch[event], handler)
+	}
+}
+
+// MessageCache returns the message cache enabled with EnableMessageCache (or nil if there is none)
+func (c *Dispatcher) MessageCache() *session.MessageCache {
+	c.dispatcherLock.Lock()
+	defer c.dispatcherLock.Unlock()
+
+	return c.messageCache
+}
+
+// AddChangeHandler adds a new handler for session change events (see the session.Event* constants)
+//
+// Change handlers are called synchronously after the session state has been updated
+func (c *Dispatcher) AddChangeHandler(event string, handler ChangeHandlerFunc) {
+	c.dispatcherLock.Lock()
+	defer c.dispatcherLock.Unlock()
+
+	handlers := c.changeDispatch[event]
+	c.changeDispatch[event] = append(handlers, handler)
+}
+
+func (c *Dispatcher) publishChanges(ctx context.Context, evts []session.ChangeEvent) {
+	if len(evts) == 0 {
+		return
+	}
+
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "dispatcher", "publishChanges")
+	defer span.End()
+
+	for _, evt := range evts {
+		c.dispatcherLock.Lock()
+		handlers := c.changeDispatch[evt.EventName()]
+		c.dispatcherLock.Unlock()
+
+		if c.debug {
+			level.Debug(logging.WithContext(ctx, c.deps.Logger())).Message("publishing change event", "change_event", evt.EventName(), "handlers", len(handlers))
+		}
+
+		for _, handler := range handlers {
+			handler(ctx, evt)
+		}
+	}
+}
+
 // HandleRequest dispatches a message and queues a response, if there is one
 func (c *Dispatcher) HandleRequest(req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
 	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "HandleRequest")
@@ -465,10 +547,13 @@ func (c *Dispatcher) handleChannelCreate(p Payload, req wsapi.WSMessage, resp ch
 	if c.debug {
 		level.Debug(logger).Message("upserting channel debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "CHANNEL_CREATE")
 	}
-	gid, cid, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	diff, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
 	level.Info(logger).Message("upserting channel", "event_name", "CHANNEL_CREATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
 	if err != nil {
 		level.Error(logger).Err("error processing channel create", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
 
@@ -491,10 +576,13 @@ func (c *Dispatcher) handleChannelUpdate(p Payload, req wsapi.WSMessage, resp ch
 	if c.debug {
 		level.Debug(logger).Message("upserting channel debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "CHANNEL_UPDATE")
 	}
-	gid, cid, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	diff, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
 	level.Info(logger).Message("upserting channel", "event_name", "CHANNEL_UPDATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
 	if err != nil {
 		level.Error(logger).Err("error processing channel update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
 
@@ -517,10 +605,16 @@ func (c *Dispatcher) handleChannelDelete(p Payload, req wsapi.WSMessage, resp ch
 	if c.debug {
 		level.Debug(logger).Message("deleting channel debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "CHANNEL_DELETE")
 	}
-	gid, cid, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
-	level.Info(logger).Message("upserting channel", "event_name", "CHANNEL_DELETE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	diff, err := c.deps.BotSession().RemoveChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	if cache := c.MessageCache(); cache != nil && cid != 0 {
+		cache.DeleteChannel(cid)
+	}
+	level.Info(logger).Message("removing channel", "event_name", "CHANNEL_DELETE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
 	if err != nil {
 		level.Error(logger).Err("error processing channel delete", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
 
@@ -543,10 +637,13 @@ func (c *Dispatcher) handleGuildMemberCreate(p Payload, req wsapi.WSMessage, res
 	if c.debug {
 		level.Debug(logger).Message("upserting guild member debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_MEMBER_ADD")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildMemberFromElementMap(data)
+	diff, err := c.deps.BotSession().UpsertGuildMemberFromElementMap(data)
+	gid := diff.Guild
 	level.Info(logger).Message("upserting guild member", "event_name", "GUILD_MEMBER_ADD", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild member create", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
@@ -569,10 +666,13 @@ func (c *Dispatcher) handleGuildMemberUpdate(p Payload, req wsapi.WSMessage, res
 	if c.debug {
 		level.Debug(logger).Message("upserting guild member debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_MEMBER_UPDATE")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildMemberFromElementMap(data)
+	diff, err := c.deps.BotSession().UpdateGuildMemberFromElementMap(data)
+	gid := diff.Guild
 	level.Info(logger).Message("upserting guild member", "event_name", "GUILD_MEMBER_UPDATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild member update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
@@ -595,10 +695,13 @@ func (c *Dispatcher) handleGuildMemberDelete(p Payload, req wsapi.WSMessage, res
 	if c.debug {
 		level.Debug(logger).Message("deleting guild member debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_MEMBER_REMOVE")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildMemberFromElementMap(data)
-	level.Info(logger).Message("upserting guild member", "event_name", "GUILD_MEMBER_REMOVE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
+	diff, err := c.deps.BotSession().RemoveGuildMemberFromElementMap(data)
+	gid := diff.Guild
+	level.Info(logger).Message("removing guild member", "event_name", "GUILD_MEMBER_REMOVE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild member delete", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
@@ -621,10 +724,13 @@ func (c *Dispatcher) handleGuildRoleCreate(p Payload, req wsapi.WSMessage, resp
 	if c.debug {
 		level.Debug(logger).Message("upserting guild role debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_ROLE_CREATE")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildRoleFromElementMap(data)
+	diff, err := c.deps.BotSession().UpsertGuildRoleFromElementMap(data)
+	gid := diff.Guild
 	level.Info(logger).Message("upserting guild role", "event_name", "GUILD_ROLE_CREATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild role create", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
@@ -647,10 +753,13 @@ func (c *Dispatcher) handleGuildRoleUpdate(p Payload, req wsapi.WSMessage, resp
 	if c.debug {
 		level.Debug(logger).Message("upserting guild role debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_ROLE_UPDATE")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildRoleFromElementMap(data)
+	diff, err := c.deps.BotSession().UpsertGuildRoleFromElementMap(data)
+	gid := diff.Guild
 	level.Info(logger).Message("upserting guild role", "event_name", "GUILD_ROLE_UPDATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild role update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
@@ -673,10 +782,377 @@ func (c *Dispatcher) handleGuildRoleDelete(p Payload, req wsapi.WSMessage, resp
 	if c.debug {
 		level.Debug(logger).Message("deleting guild role debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_ROLE_DELETE")
 	}
-	gid, err := c.deps.BotSession().UpsertGuildRoleFromElementMap(data)
-	level.Info(logger).Message("upserting guild role", "event_name", "GUILD_ROLE_DELETE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
+	diff, err := c.deps.BotSession().RemoveGuildRoleFromElementMap(data)
+	gid := diff.Guild
+	level.Info(logger).Message("removing guild role", "event_name", "GUILD_ROLE_DELETE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid)
 	if err != nil {
 		level.Error(logger).Err("error processing guild role delete", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadCreate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadCreate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("upserting thread debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_CREATE")
+	}
+	diff, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	level.Info(logger).Message("upserting thread", "event_name", "THREAD_CREATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	if err != nil {
+		level.Error(logger).Err("error processing thread create", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("upserting thread debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_UPDATE")
+	}
+	diff, err := c.deps.BotSession().UpsertChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	level.Info(logger).Message("upserting thread", "event_name", "THREAD_UPDATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	if err != nil {
+		level.Error(logger).Err("error processing thread update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadDelete(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadDelete")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("deleting thread debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_DELETE")
+	}
+	diff, err := c.deps.BotSession().RemoveChannelFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	level.Info(logger).Message("removing thread", "event_name", "THREAD_DELETE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	if err != nil {
+		level.Error(logger).Err("error processing thread delete", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadListSync(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadListSync")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("syncing threads debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_LIST_SYNC")
+	}
+	gid, diffs, err := c.deps.BotSession().SyncThreadsFromElementMap(data)
+	level.Info(logger).Message("syncing threads", "event_name", "THREAD_LIST_SYNC", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid, "changes", len(diffs))
+	if err != nil {
+		level.Error(logger).Err("error processing thread list sync", err)
+	}
+	for _, diff := range diffs {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadMemberUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadMemberUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("updating thread member debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_MEMBER_UPDATE")
+	}
+	diff, err := c.deps.BotSession().UpdateThreadMemberFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	level.Info(logger).Message("updating thread member", "event_name", "THREAD_MEMBER_UPDATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	if err != nil {
+		level.Error(logger).Err("error processing thread member update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleThreadMembersUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleThreadMembersUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("updating thread members debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "THREAD_MEMBERS_UPDATE")
+	}
+	diff, err := c.deps.BotSession().UpdateThreadMembersFromElementMap(data)
+	gid, cid := diff.Guild, diff.ChannelID()
+	level.Info(logger).Message("updating thread members", "event_name", "THREAD_MEMBERS_UPDATE", "channel_id_elem", fmt.Sprintf("%+v", data["id"]), "guild_id", gid, "channel_id", cid)
+	if err != nil {
+		level.Error(logger).Err("error processing thread members update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()), telemetry.KVString("cid", cid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleVoiceStateUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleVoiceStateUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("upserting voice state debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "VOICE_STATE_UPDATE")
+	}
+	diff, err := c.deps.BotSession().UpsertVoiceStateFromElementMap(data)
+	gid, uid := diff.Guild, diff.UserID()
+	level.Info(logger).Message("upserting voice state", "event_name", "VOICE_STATE_UPDATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid, "user_id", uid)
+	if err != nil {
+		level.Error(logger).Err("error processing voice state update", err)
+	} else {
+		c.publishChanges(req.Ctx, diff.Events())
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleMessageCreate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleMessageCreate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	cache := c.MessageCache()
+	if cache == nil {
+		return 0
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	m, err := entity.MessageFromElementMap(p.Contents())
+	if err != nil {
+		level.Error(logger).Err("error caching message", err)
+		return 0
+	}
+
+	if c.debug {
+		level.Debug(logger).Message("caching message", "event_name", "MESSAGE_CREATE", "channel_id", m.ChannelIDSnowflake, "message_id", m.IDSnowflake)
+	}
+	cache.Add(m)
+	span.SetAttributes(telemetry.KVString("gid", m.GuildIDSnowflake.ToString()))
+
+	return m.GuildIDSnowflake
+}
+
+func (c *Dispatcher) handleMessageUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleMessageUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	cache := c.MessageCache()
+	if cache == nil {
+		return 0
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	evt, err := cache.UpdateFromElementMap(p.Contents())
+	if err != nil {
+		level.Error(logger).Err("error processing message update", err)
+		return 0
+	}
+
+	if c.debug {
+		level.Debug(logger).Message("updated cached message", "event_name", "MESSAGE_UPDATE", "channel_id", evt.Channel, "message_id", evt.New.IDSnowflake, "cached", evt.Cached)
+	}
+	c.publishChanges(req.Ctx, []session.ChangeEvent{evt})
+	span.SetAttributes(telemetry.KVString("gid", evt.Guild.ToString()))
+
+	return evt.Guild
+}
+
+func (c *Dispatcher) handleMessageDelete(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleMessageDelete")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	cache := c.MessageCache()
+	if cache == nil {
+		return 0
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	deleted, err := cache.DeleteFromElementMap(p.Contents())
+	if err != nil {
+		level.Error(logger).Err("error processing message delete", err, "event_name", p.EventName())
+		return 0
+	}
+
+	var gid snowflake.Snowflake
+	evts := make([]session.ChangeEvent, 0, len(deleted))
+	for _, evt := range deleted {
+		evts = append(evts, evt)
+		gid = evt.Guild
+	}
+
+	if c.debug {
+		level.Debug(logger).Message("deleted cached messages", "event_name", p.EventName(), "count", len(evts))
+	}
+	c.publishChanges(req.Ctx, evts)
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleGuildEmojisUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleGuildEmojisUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("updating guild emojis debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_EMOJIS_UPDATE")
+	}
+	evt, err := c.deps.BotSession().UpdateGuildEmojisFromElementMap(data)
+	gid := evt.Guild
+	level.Info(logger).Message("updating guild emojis", "event_name", "GUILD_EMOJIS_UPDATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid, "added", len(evt.Added), "removed", len(evt.Removed), "changed", len(evt.Changed))
+	if err != nil {
+		level.Error(logger).Err("error processing guild emojis update", err)
+	} else if len(evt.Added)+len(evt.Removed)+len(evt.Changed) > 0 {
+		c.publishChanges(req.Ctx, []session.ChangeEvent{evt})
+	}
+	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
+
+	return gid
+}
+
+func (c *Dispatcher) handleGuildStickersUpdate(p Payload, req wsapi.WSMessage, resp chan<- wsapi.WSMessage) snowflake.Snowflake {
+	ctx, span := c.deps.Telemetry().StartSpan(req.Ctx, "dispatcher", "handleGuildStickersUpdate")
+	defer span.End()
+	req.Ctx = ctx
+
+	select {
+	case <-req.Ctx.Done():
+		return 0
+	default:
+	}
+
+	logger := logging.WithContext(req.Ctx, c.deps.Logger())
+	data := p.Contents()
+	if c.debug {
+		level.Debug(logger).Message("updating guild stickers debug", "pdata", fmt.Sprintf("%+v", data), "event_name", "GUILD_STICKERS_UPDATE")
+	}
+	evt, err := c.deps.BotSession().UpdateGuildStickersFromElementMap(data)
+	gid := evt.Guild
+	level.Info(logger).Message("updating guild stickers", "event_name", "GUILD_STICKERS_UPDATE", "guild_id_elem", fmt.Sprintf("%+v", data["guild_id"]), "guild_id", gid, "added", len(evt.Added), "removed", len(evt.Removed), "changed", len(evt.Changed))
+	if err != nil {
+		level.Error(logger).Err("error processing guild stickers update", err)
+	} else if len(evt.Added)+len(evt.Removed)+len(evt.Changed) > 0 {
+		c.publishChanges(req.Ctx, []session.ChangeEvent{evt})
 	}
 	span.SetAttributes(telemetry.KVString("gid", gid.ToString()))
 
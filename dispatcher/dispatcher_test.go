@@ -0,0 +1,145 @@
+package dispatcher
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/bot/session"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/wsapi"
+)
+
+func TestDispatcher_EnableMessageCache(t *testing.T) {
+	t.Parallel()
+
+	d := NewDispatcher(nil)
+
+	first := session.NewMessageCache(session.MessageCacheOptions{})
+	second := session.NewMessageCache(session.MessageCacheOptions{})
+
+	d.EnableMessageCache(first)
+	d.EnableMessageCache(second)
+
+	// enabling again swaps the cache without adding a second set of handlers
+	assert.Same(t, second, d.MessageCache())
+	for _, event := range []string{"MESSAGE_CREATE", "MESSAGE_UPDATE", "MESSAGE_DELETE", "MESSAGE_DELETE_BULK"} {
+		assert.Len(t, d.eventDispatch[event], 1, event)
+	}
+}
+
+// changeRecorder records the change events it is given
+type changeRecorder struct {
+	mu     sync.Mutex
+	events []session.ChangeEvent
+}
+
+func (r *changeRecorder) handle(ctx context.Context, evt session.ChangeEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, evt)
+}
+
+func (r *changeRecorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := []string{}
+	for _, evt := range r.events {
+		names = append(names, evt.EventName())
+	}
+	return names
+}
+
+func TestDispatcher_publishChanges(t *testing.T) {
+	t.Parallel()
+
+	d, _ := newTestDispatcher()
+
+	var nicks, roles, all changeRecorder
+	d.AddChangeHandler(session.EventMemberNickChanged, nicks.handle)
+	d.AddChangeHandler(session.EventMemberRolesChanged, roles.handle)
+	d.AddChangeHandler(session.EventMemberNickChanged, all.handle)
+	d.AddChangeHandler(session.EventMemberRolesChanged, all.handle)
+
+	d.publishChanges(context.Background(), nil)
+	d.publishChanges(context.Background(), []session.ChangeEvent{
+		session.MemberUpdated{Guild: 1},
+		session.MemberNickChanged{Guild: 1},
+		session.MemberRolesChanged{Guild: 1},
+		session.MemberNickChanged{Guild: 1},
+	})
+
+	// events without handlers are dropped, and each handler sees its events in order
+	assert.Equal(t, []string{session.EventMemberNickChanged, session.EventMemberNickChanged}, nicks.names())
+	assert.Equal(t, []string{session.EventMemberRolesChanged}, roles.names())
+	assert.Equal(t, []string{session.EventMemberNickChanged, session.EventMemberRolesChanged, session.EventMemberNickChanged}, all.names())
+}
+
+func memberPayload(name string, gid, uid int64, roles ...int64) testPayload {
+	roleElems := make([]etfapi.Element, 0, len(roles))
+	for _, rid := range roles {
+		roleElems = append(roleElems, mustElement(etfapi.NewSmallBigElement(rid)))
+	}
+
+	return testPayload{name: name, contents: map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(gid)),
+		"user": mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+			"id":       mustElement(etfapi.NewSmallBigElement(uid)),
+			"username": mustElement(etfapi.NewStringElement("someone")),
+		})),
+		"roles": mustElement(etfapi.NewListElement(roleElems)),
+	}}
+}
+
+func TestDispatcher_memberChangeEvents(t *testing.T) {
+	t.Parallel()
+
+	d, sess := newTestDispatcher()
+
+	_, err := sess.UpsertGuildFromElementMap(map[string]etfapi.Element{
+		"id": mustElement(etfapi.NewSmallBigElement(1)),
+		"roles": mustElement(etfapi.NewListElement([]etfapi.Element{
+			mustElement(etfapi.NewMapElement(map[string]etfapi.Element{
+				"id":   mustElement(etfapi.NewSmallBigElement(3)),
+				"name": mustElement(etfapi.NewStringElement("mods")),
+			})),
+		})),
+	})
+	require.NoError(t, err)
+
+	var rec changeRecorder
+	for _, event := range []string{
+		session.EventMemberAdded, session.EventMemberUpdated, session.EventMemberRolesChanged, session.EventRoleDeleted,
+	} {
+		d.AddChangeHandler(event, rec.handle)
+	}
+
+	req := wsapi.WSMessage{Ctx: context.Background()}
+
+	// an update for a member that was never cached is not a join
+	gid := d.handleGuildMemberUpdate(memberPayload("GUILD_MEMBER_UPDATE", 1, 7, 3), req, nil)
+	assert.Equal(t, snowflake.Snowflake(1), gid)
+	require.Equal(t, []string{session.EventMemberUpdated}, rec.names())
+	assert.True(t, rec.events[0].(session.MemberUpdated).OldUnknown)
+
+	d.handleGuildMemberCreate(memberPayload("GUILD_MEMBER_ADD", 1, 8, 3), req, nil)
+	assert.Equal(t, []string{session.EventMemberUpdated, session.EventMemberAdded}, rec.names())
+
+	// deleting a role reports the role changes of the members who had it
+	d.handleGuildRoleDelete(testPayload{name: "GUILD_ROLE_DELETE", contents: map[string]etfapi.Element{
+		"guild_id": mustElement(etfapi.NewSmallBigElement(1)),
+		"role_id":  mustElement(etfapi.NewSmallBigElement(3)),
+	}}, req, nil)
+	assert.Equal(t, []string{
+		session.EventMemberUpdated, session.EventMemberAdded,
+		session.EventRoleDeleted,
+		session.EventMemberUpdated, session.EventMemberRolesChanged,
+		session.EventMemberUpdated, session.EventMemberRolesChanged,
+	}, rec.names())
+}
@@ -0,0 +1,60 @@
+package dispatcher
+
+import (
+	"context"
+
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+	"golang.org/x/time/rate"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/bot/session"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
+)
+
+type nopLogger struct{}
+
+func (l nopLogger) Log(kv ...interface{}) error              { return nil }
+func (l nopLogger) Err(m string, e error, kv ...interface{}) {}
+func (l nopLogger) Message(m string, kv ...interface{})      {}
+func (l nopLogger) Printf(f string, a ...interface{})        {}
+
+type nopExporter struct{}
+
+func (nopExporter) ExportSpans(context.Context, []telemetry.ReadOnlySpan) error { return nil }
+func (nopExporter) Shutdown(context.Context) error                              { return nil }
+
+type testDeps struct {
+	telemeter *telemetry.Telemeter
+	session   *session.Session
+}
+
+func (d *testDeps) Logger() Logger                    { return nopLogger{} }
+func (d *testDeps) BotSession() *session.Session      { return d.session }
+func (d *testDeps) MessageRateLimiter() *rate.Limiter { return rate.NewLimiter(rate.Inf, 1) }
+func (d *testDeps) Telemetry() *telemetry.Telemeter   { return d.telemeter }
+
+// newTestDispatcher creates a Dispatcher with a fresh session
+func newTestDispatcher() (*Dispatcher, *session.Session) {
+	deps := &testDeps{
+		telemeter: telemetry.NewTelemeter("test", "test", "test", nopExporter{}, nonrecording.NewNoopMeterProvider(), 1.0),
+		session:   session.NewSession(),
+	}
+
+	return NewDispatcher(deps), deps.session
+}
+
+// testPayload is a gateway dispatch payload
+type testPayload struct {
+	name     string
+	contents map[string]etfapi.Element
+}
+
+func (p testPayload) EventName() string                   { return p.name }
+func (p testPayload) Contents() map[string]etfapi.Element { return p.contents }
+
+func mustElement(e etfapi.Element, err error) etfapi.Element {
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
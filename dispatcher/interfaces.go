@@ -1,6 +1,9 @@
 package dispatcher
 
 import (
+	"context"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/bot/session"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/etfapi"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/snowflake"
 	"github.com/gsmcwhirter/discord-bot-lib/v24/wsapi"
@@ -14,3 +17,6 @@ type Payload = interface {
 
 // DispatchHandlerFunc is the api that a bot expects a handler function to have
 type DispatchHandlerFunc = func(Payload, wsapi.WSMessage, chan<- wsapi.WSMessage) snowflake.Snowflake
+
+// ChangeHandlerFunc is the api that a handler for session change events is expected to have
+type ChangeHandlerFunc = func(context.Context, session.ChangeEvent)
@@ -6,6 +6,7 @@ require (
 	github.com/golangci/golangci-lint v1.49.0
 	github.com/gorilla/websocket v1.5.0
 	github.com/gsmcwhirter/go-util/v10 v10.2.1
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.8.0
 	go.opentelemetry.io/otel/metric v0.30.0
 	golang.org/x/sync v0.0.0-20220923202941-7f9b1623fab7
@@ -148,7 +149,6 @@ require (
 	github.com/spf13/cast v1.5.0 // indirect
 	github.com/spf13/cobra v1.5.0 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.12.0 // indirect
 	github.com/ssgreg/nlreturn/v2 v2.2.1 // indirect
 	github.com/stbenjam/no-sprintf-host-port v0.1.1 // indirect
@@ -0,0 +1,226 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+)
+
+// These are the json error codes of common discord api errors
+const (
+	CodeGeneral                        = 0
+	CodeUnknownAccount                 = 10001
+	CodeUnknownApplication             = 10002
+	CodeUnknownChannel                 = 10003
+	CodeUnknownGuild                   = 10004
+	CodeUnknownIntegration             = 10005
+	CodeUnknownInvite                  = 10006
+	CodeUnknownMember                  = 10007
+	CodeUnknownMessage                 = 10008
+	CodeUnknownOverwrite               = 10009
+	CodeUnknownRole                    = 10011
+	CodeUnknownToken                   = 10012
+	CodeUnknownUser                    = 10013
+	CodeUnknownEmoji                   = 10014
+	CodeUnknownWebhook                 = 10015
+	CodeUnknownBan                     = 10026
+	CodeUnknownSticker                 = 10060
+	CodeUnknownInteraction             = 10062
+	CodeUnknownApplicationCommand      = 10063
+	CodeBotsCannotUseEndpoint          = 20001
+	CodeMaxGuilds                      = 30001
+	CodeMaxPins                        = 30003
+	CodeMaxRoles                       = 30005
+	CodeMaxWebhooks                    = 30007
+	CodeMaxReactions                   = 30010
+	CodeMaxChannels                    = 30013
+	CodeUnauthorized                   = 40001
+	CodeRequestTooLarge                = 40005
+	CodeInteractionAlreadyAcknowledged = 40060
+	CodeMissingAccess                  = 50001
+	CodeInvalidAccountType             = 50002
+	CodeCannotEditOtherUsersMessage    = 50005
+	CodeCannotSendEmptyMessage         = 50006
+	CodeCannotSendMessagesToUser       = 50007
+	CodeMissingPermissions             = 50013
+	CodeInvalidToken                   = 50014
+	CodeMessageTooOldToBulkDelete      = 50034
+	CodeInvalidFormBody                = 50035
+	CodeThreadArchived                 = 50083
+	CodeReactionBlocked                = 90001
+)
+
+// FieldError is a single field-validation error from the errors tree of an APIError
+//
+// Path is the dotted path to the offending field (for example, embeds.0.title)
+type FieldError struct {
+	Path    string
+	Code    string
+	Message string
+}
+
+// APIError is the error returned for a non-2xx discord api response
+//
+// Code and Message come from discord's json error body, if there was one; Body is always
+// the raw response body. An APIError unwraps to ErrResponse
+type APIError struct {
+	StatusCode  int
+	Code        int
+	Message     string
+	FieldErrors []FieldError
+	Body        string
+}
+
+// NewAPIError creates an APIError from the status code and body of a response, decoding the body if possible
+func NewAPIError(statusCode int, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: statusCode,
+		Body:       string(body),
+	}
+
+	var decoded struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+
+	if len(body) == 0 || json.Unmarshal(body, &decoded) != nil {
+		return e
+	}
+
+	e.Code = decoded.Code
+	e.Message = decoded.Message
+	e.FieldErrors = flattenFieldErrors("", decoded.Errors, nil)
+	sort.Slice(e.FieldErrors, func(i, j int) bool { return e.FieldErrors[i].Path < e.FieldErrors[j].Path })
+
+	return e
+}
+
+// flattenFieldErrors walks discord's nested errors tree, collecting the _errors lists at its leaves
+func flattenFieldErrors(path string, raw json.RawMessage, acc []FieldError) []FieldError {
+	if len(raw) == 0 {
+		return acc
+	}
+
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return acc
+	}
+
+	for k, v := range node {
+		if k == "_errors" {
+			var leaves []struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(v, &leaves); err != nil {
+				continue
+			}
+
+			for _, l := range leaves {
+				acc = append(acc, FieldError{Path: path, Code: l.Code, Message: l.Message})
+			}
+			continue
+		}
+
+		sub := k
+		if path != "" {
+			sub = path + "." + k
+		}
+		acc = flattenFieldErrors(sub, v, acc)
+	}
+
+	return acc
+}
+
+// responseError reads the body of a non-2xx response into an APIError
+func responseError(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	apiErr := NewAPIError(resp.StatusCode, body)
+	if err != nil {
+		return errors.Wrap(apiErr, "could not read the response body", "read_error", err.Error())
+	}
+
+	return apiErr
+}
+
+// Error returns the error message
+func (e *APIError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "discord api error (status %d", e.StatusCode)
+	if e.Code != CodeGeneral {
+		fmt.Fprintf(&b, ", code %d", e.Code)
+	}
+	b.WriteString(")")
+
+	switch {
+	case e.Message != "":
+		b.WriteString(": " + e.Message)
+	case e.Body != "":
+		b.WriteString(": " + e.Body)
+	}
+
+	for _, fe := range e.FieldErrors {
+		fmt.Fprintf(&b, "; %s: %s", fe.Path, fe.Message)
+	}
+
+	return b.String()
+}
+
+// Unwrap returns ErrResponse, so that errors.Is(err, ErrResponse) holds for any APIError
+func (e *APIError) Unwrap() error {
+	return ErrResponse
+}
+
+// AsAPIError finds the APIError in the chain of err, if there is one
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+
+	return nil, false
+}
+
+// HasCode determines if err is an APIError with the given json error code
+func HasCode(err error, code int) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.Code == code
+}
+
+// IsMissingPermissions determines if err is an APIError caused by the bot lacking a permission
+func IsMissingPermissions(err error) bool {
+	return HasCode(err, CodeMissingPermissions)
+}
+
+// IsMissingAccess determines if err is an APIError caused by the bot lacking access to a resource
+func IsMissingAccess(err error) bool {
+	return HasCode(err, CodeMissingAccess)
+}
+
+// IsUnknownResource determines if err is an APIError caused by a resource (message, channel,
+// member, etc.) that does not exist, whether or not discord provided an Unknown-type error code
+func IsUnknownResource(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+
+	if apiErr.Code >= 10000 && apiErr.Code < 20000 {
+		return true
+	}
+
+	return apiErr.Code == CodeGeneral && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsRateLimited determines if err is an APIError caused by exceeding a rate limit
+func IsRateLimited(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == http.StatusTooManyRequests
+}
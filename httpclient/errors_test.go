@@ -0,0 +1,150 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantCode        int
+		wantMessage     string
+		wantFieldErrors []FieldError
+		wantError       string
+	}{
+		{
+			name:        "plain json error",
+			status:      http.StatusForbidden,
+			body:        `{"message": "Missing Permissions", "code": 50013}`,
+			wantCode:    CodeMissingPermissions,
+			wantMessage: "Missing Permissions",
+			wantError:   "discord api error (status 403, code 50013): Missing Permissions",
+		},
+		{
+			name:   "invalid form body with nested errors",
+			status: http.StatusBadRequest,
+			body: `{
+				"code": 50035,
+				"errors": {
+					"embeds": {
+						"0": {
+							"title": {"_errors": [{"code": "BASE_TYPE_MAX_LENGTH", "message": "Must be 256 or fewer in length."}]},
+							"fields": {"1": {"value": {"_errors": [{"code": "BASE_TYPE_REQUIRED", "message": "This field is required"}]}}}
+						}
+					},
+					"content": {"_errors": [
+						{"code": "BASE_TYPE_MAX_LENGTH", "message": "Must be 2000 or fewer in length."},
+						{"code": "CONTENT_TYPE_INVALID", "message": "Invalid content."}
+					]}
+				},
+				"message": "Invalid Form Body"
+			}`,
+			wantCode:    CodeInvalidFormBody,
+			wantMessage: "Invalid Form Body",
+			wantFieldErrors: []FieldError{
+				{Path: "content", Code: "BASE_TYPE_MAX_LENGTH", Message: "Must be 2000 or fewer in length."},
+				{Path: "content", Code: "CONTENT_TYPE_INVALID", Message: "Invalid content."},
+				{Path: "embeds.0.fields.1.value", Code: "BASE_TYPE_REQUIRED", Message: "This field is required"},
+				{Path: "embeds.0.title", Code: "BASE_TYPE_MAX_LENGTH", Message: "Must be 256 or fewer in length."},
+			},
+			wantError: "discord api error (status 400, code 50035): Invalid Form Body" +
+				"; content: Must be 2000 or fewer in length." +
+				"; content: Invalid content." +
+				"; embeds.0.fields.1.value: This field is required" +
+				"; embeds.0.title: Must be 256 or fewer in length.",
+		},
+		{
+			name:      "top-level _errors",
+			status:    http.StatusBadRequest,
+			body:      `{"code": 50035, "message": "Invalid Form Body", "errors": {"_errors": [{"code": "X", "message": "bad"}]}}`,
+			wantCode:  CodeInvalidFormBody,
+			wantError: "discord api error (status 400, code 50035): Invalid Form Body; : bad",
+			wantFieldErrors: []FieldError{
+				{Path: "", Code: "X", Message: "bad"},
+			},
+			wantMessage: "Invalid Form Body",
+		},
+		{
+			name:      "html from a proxy",
+			status:    http.StatusBadGateway,
+			body:      "<html>\r\n<head><title>502 Bad Gateway</title></head>\r\n<body>\r\n<center><h1>502 Bad Gateway</h1></center>\r\n<hr><center>cloudflare</center>\r\n</body>\r\n</html>\r\n",
+			wantError: "discord api error (status 502): <html>\r\n<head><title>502 Bad Gateway</title></head>\r\n<body>\r\n<center><h1>502 Bad Gateway</h1></center>\r\n<hr><center>cloudflare</center>\r\n</body>\r\n</html>\r\n",
+		},
+		{
+			name:      "empty body",
+			status:    http.StatusNotFound,
+			body:      "",
+			wantError: "discord api error (status 404)",
+		},
+		{
+			name:        "404 with an unknown code",
+			status:      http.StatusNotFound,
+			body:        `{"message": "Unknown Message", "code": 10008}`,
+			wantCode:    CodeUnknownMessage,
+			wantMessage: "Unknown Message",
+			wantError:   "discord api error (status 404, code 10008): Unknown Message",
+		},
+		{
+			name:        "malformed errors tree is ignored",
+			status:      http.StatusBadRequest,
+			body:        `{"code": 50035, "message": "Invalid Form Body", "errors": ["not", "a", "map"]}`,
+			wantCode:    CodeInvalidFormBody,
+			wantMessage: "Invalid Form Body",
+			wantError:   "discord api error (status 400, code 50035): Invalid Form Body",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewAPIError(tt.status, []byte(tt.body))
+			assert.Equal(t, tt.status, err.StatusCode)
+			assert.Equal(t, tt.wantCode, err.Code)
+			assert.Equal(t, tt.wantMessage, err.Message)
+			assert.Equal(t, tt.wantFieldErrors, err.FieldErrors)
+			assert.Equal(t, tt.body, err.Body)
+			assert.Equal(t, tt.wantError, err.Error())
+			assert.ErrorIs(t, err, ErrResponse)
+		})
+	}
+}
+
+func TestIsUnknownResource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "unknown message", err: NewAPIError(http.StatusNotFound, []byte(`{"message": "Unknown Message", "code": 10008}`)), want: true},
+		{name: "unknown code with another status", err: NewAPIError(http.StatusBadRequest, []byte(`{"message": "Unknown Emoji", "code": 10014}`)), want: true},
+		{name: "bare 404", err: NewAPIError(http.StatusNotFound, nil), want: true},
+		{name: "404 with html", err: NewAPIError(http.StatusNotFound, []byte("<html>not found</html>")), want: true},
+		{name: "wrapped", err: errors.Wrap(NewAPIError(http.StatusNotFound, nil), "could not get message"), want: true},
+		{name: "missing access", err: NewAPIError(http.StatusForbidden, []byte(`{"message": "Missing Access", "code": 50001}`)), want: false},
+		{name: "404 with a non-unknown code", err: NewAPIError(http.StatusNotFound, []byte(`{"message": "Missing Access", "code": 50001}`)), want: false},
+		{name: "bare 502", err: NewAPIError(http.StatusBadGateway, nil), want: false},
+		{name: "not an api error", err: errors.New("connection reset"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, IsUnknownResource(tt.err))
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/gsmcwhirter/go-util/v10/telemetry"
 
 	"github.com/gsmcwhirter/discord-bot-lib/v24/logging"
+	"github.com/gsmcwhirter/discord-bot-lib/v24/stats"
 )
 
 type dependencies interface {
@@ -29,7 +30,7 @@ type Logger = interface {
 	Printf(string, ...interface{})
 }
 
-// ErrResponse is the error that is wrapped and returned when there is a non-200 api response
+// ErrResponse is the error that is wrapped and returned when there is a non-2xx api response (see APIError)
 var ErrResponse = errors.New("error response")
 
 // HTTPClient is a wrapped http client for interacting with discord
@@ -37,6 +38,9 @@ type HTTPClient struct {
 	deps    dependencies
 	headers *http.Header
 
+	retry   RetryPolicy
+	breaker *CircuitBreaker
+
 	debug bool
 }
 
@@ -45,6 +49,8 @@ func NewHTTPClient(deps dependencies) *HTTPClient {
 	return &HTTPClient{
 		deps:    deps,
 		headers: &http.Header{},
+		retry:   DefaultRetryPolicy(),
+		breaker: NewCircuitBreaker(10, 30*time.Second),
 	}
 }
 
@@ -57,6 +63,16 @@ func addHeaders(to *http.Header, from http.Header) {
 	}
 }
 
+// SetRetryPolicy replaces the policy for retrying transient failures
+func (c *HTTPClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// SetCircuitBreaker replaces the circuit breaker (nil disables it)
+func (c *HTTPClient) SetCircuitBreaker(b *CircuitBreaker) {
+	c.breaker = b
+}
+
 // SetDebug turns on/off debug mode
 func (c *HTTPClient) SetDebug(val bool) {
 	c.debug = val
@@ -65,6 +81,10 @@ func (c *HTTPClient) SetDebug(val bool) {
 func (c *HTTPClient) doRequest(ctx context.Context, logger Logger, method, url string, headers *http.Header, body io.Reader) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
+		// the caller handed over the body, so it must not be leaked (it may be backed by a goroutine)
+		if c, ok := body.(io.Closer); ok {
+			_ = c.Close()
+		}
 		return nil, err
 	}
 
@@ -73,15 +93,105 @@ func (c *HTTPClient) doRequest(ctx context.Context, logger Logger, method, url s
 		addHeaders(&req.Header, *headers)
 	}
 
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		// a streamed body cannot be replayed
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			prev := req.Body
+			req.Body, err = req.GetBody()
+			if err != nil {
+				closeBody(prev)
+				return nil, errors.Wrap(err, "could not replay the request body")
+			}
+		}
+
+		resp, err := c.send(ctx, logger, req)
+		if attempt >= attempts || !shouldRetry(method, resp, err) {
+			return resp, err
+		}
+
+		delay := c.retry.backoff(attempt)
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+			if d, ok := retryAfter(resp); ok && status == http.StatusTooManyRequests {
+				if c.retry.MaxDelay > 0 && d > c.retry.MaxDelay {
+					// discord asked for a longer wait than the policy allows, so hand back the 429
+					return resp, err
+				}
+				delay = d
+			}
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// there is not enough time left to retry, so hand back what we have
+			return resp, err
+		}
+
+		if resp != nil && resp.Body != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		level.Info(logger).Message("retrying http request",
+			"method", method,
+			"attempt", attempt+1,
+			"status_code", status,
+			"delay", delay.String(),
+			"error", err,
+		)
+
+		if serr := stats.IncCounter(ctx, c.deps.Telemetry(), "httpclient", stats.HTTPRetriesCount, 1, telemetry.KVString(stats.TagMethod, method), telemetry.KVInt(stats.TagStatus, status)); serr != nil {
+			level.Error(logger).Err("could not record stat", serr)
+		}
+
+		if serr := sleepCtx(ctx, delay); serr != nil {
+			return nil, errors.Wrap(serr, "could not wait to retry the request", "last_error", err, "last_status_code", status)
+		}
+	}
+}
+
+// send makes a single attempt at a request, subject to the circuit breaker
+func (c *HTTPClient) send(ctx context.Context, logger Logger, req *http.Request) (*http.Response, error) {
+	if c.breaker != nil {
+		ok, state, changed := c.breaker.allow()
+		if changed {
+			c.breakerChanged(ctx, logger, state)
+		}
+
+		if !ok {
+			// Do would have closed the body, so close it here instead
+			closeBody(req.Body)
+			return nil, errors.Wrap(ErrCircuitOpen, "request refused", "method", req.Method)
+		}
+	}
+
 	if c.debug {
 		level.Debug(logger).Message("http request start",
-			"method", method,
-			"url", url,
+			"method", req.Method,
+			"url", req.URL.String(),
 			"headers", fmt.Sprintf("%+v", NonSensitiveHeaders(req.Header)),
 		)
 	}
 	start := time.Now()
 	resp, err := c.deps.HTTPDoer().Do(req)
+
+	if c.breaker != nil {
+		switch {
+		case err != nil && ctx.Err() != nil:
+			c.breaker.abandon()
+		default:
+			failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+			if state, changed := c.breaker.record(failed); changed {
+				c.breakerChanged(ctx, logger, state)
+			}
+		}
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -96,6 +206,21 @@ func (c *HTTPClient) doRequest(ctx context.Context, logger Logger, method, url s
 	return resp, nil
 }
 
+// closeBody closes a request body that will not be sent
+func closeBody(body io.ReadCloser) {
+	if body != nil && body != http.NoBody {
+		_ = body.Close()
+	}
+}
+
+func (c *HTTPClient) breakerChanged(ctx context.Context, logger Logger, state CircuitState) {
+	level.Info(logger).Message("circuit breaker state changed", "state", state.String())
+
+	if err := stats.IncCounter(ctx, c.deps.Telemetry(), "httpclient", stats.CircuitBreakerChangesCount, 1, telemetry.KVString(stats.TagState, state.String())); err != nil {
+		level.Error(logger).Err("could not record stat", err)
+	}
+}
+
 // SetHeaders adds the provided headers to the set to be included in all requests
 func (c *HTTPClient) SetHeaders(h http.Header) {
 	addHeaders(c.headers, h)
@@ -158,15 +283,7 @@ func (c *HTTPClient) GetJSON(ctx context.Context, url string, headers *http.Head
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
-		var body []byte
-		var err error
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			err = errors.WithDetails(ErrResponse, "read_error", err.Error())
-		} else {
-			err = ErrResponse
-		}
-		return resp, errors.Wrap(err, "non-200 response", "status_code", resp.StatusCode, "response_body", string(body))
+		return resp, errors.Wrap(responseError(resp), "non-2xx response")
 	}
 
 	err = json.UnmarshalFromReader(resp.Body, t)
@@ -238,15 +355,7 @@ func (c *HTTPClient) PostJSON(ctx context.Context, url string, headers *http.Hea
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
-		var body []byte
-		var err error
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			err = errors.WithDetails(ErrResponse, "read_error", err.Error())
-		} else {
-			err = ErrResponse
-		}
-		return resp, errors.Wrap(err, "non-200 response", "status_code", resp.StatusCode, "response_body", string(body))
+		return resp, errors.Wrap(responseError(resp), "non-2xx response")
 	}
 
 	err = json.UnmarshalFromReader(resp.Body, t)
@@ -318,15 +427,143 @@ func (c *HTTPClient) PutJSON(ctx context.Context, url string, headers *http.Head
 	}
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
-		var body []byte
-		var err error
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			err = errors.WithDetails(ErrResponse, "read_error", err.Error())
-		} else {
-			err = ErrResponse
-		}
-		return resp, errors.Wrap(err, "non-200 response", "status_code", resp.StatusCode, "response_body", string(body))
+		return resp, errors.Wrap(responseError(resp), "non-2xx response")
+	}
+
+	err = json.UnmarshalFromReader(resp.Body, t)
+	return resp, errors.Wrap(err, "could not unmarshal json")
+}
+
+// Patch performs an http PATCH
+func (c *HTTPClient) Patch(ctx context.Context, url string, headers *http.Header, body io.Reader) (*http.Response, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "Patch")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	resp, err := c.doRequest(ctx, logger, "PATCH", url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+
+	return resp, nil
+}
+
+// PatchBody performs an http PATCH and returns the response body
+func (c *HTTPClient) PatchBody(ctx context.Context, url string, headers *http.Header, body io.Reader) (*http.Response, []byte, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "PatchBody")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	resp, err := c.doRequest(ctx, logger, "PATCH", url, headers, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.Body != nil {
+		defer resp.Body.Close() //nolint:errcheck // not a real issue here
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+
+	return resp, respBody, err
+}
+
+// PatchJSON performs an http PATCH and unmarshals the response body into the provided target
+func (c *HTTPClient) PatchJSON(ctx context.Context, url string, headers *http.Header, body io.Reader, t interface{}) (*http.Response, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "PatchJSON")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	if headers == nil {
+		headers = &http.Header{}
+	}
+
+	if headers.Get("content-type") == "" {
+		headers.Set("content-type", "application/json")
+	}
+
+	resp, err := c.doRequest(ctx, logger, "PATCH", url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Body != nil {
+		defer resp.Body.Close() //nolint:errcheck // not a real issue here
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		return resp, errors.Wrap(responseError(resp), "non-2xx response")
+	}
+
+	err = json.UnmarshalFromReader(resp.Body, t)
+	return resp, errors.Wrap(err, "could not unmarshal json")
+}
+
+// Delete performs an http DELETE
+func (c *HTTPClient) Delete(ctx context.Context, url string, headers *http.Header, body io.Reader) (*http.Response, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "Delete")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	resp, err := c.doRequest(ctx, logger, "DELETE", url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+
+	return resp, nil
+}
+
+// DeleteBody performs an http DELETE and returns the response body
+func (c *HTTPClient) DeleteBody(ctx context.Context, url string, headers *http.Header, body io.Reader) (*http.Response, []byte, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "DeleteBody")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	resp, err := c.doRequest(ctx, logger, "DELETE", url, headers, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.Body != nil {
+		defer resp.Body.Close() //nolint:errcheck // not a real issue here
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+
+	return resp, respBody, err
+}
+
+// DeleteJSON performs an http DELETE and unmarshals the response body into the provided target
+func (c *HTTPClient) DeleteJSON(ctx context.Context, url string, headers *http.Header, body io.Reader, t interface{}) (*http.Response, error) {
+	ctx, span := c.deps.Telemetry().StartSpan(ctx, "httpclient", "DeleteJSON")
+	defer span.End()
+
+	logger := logging.WithContext(ctx, c.deps.Logger())
+
+	resp, err := c.doRequest(ctx, logger, "DELETE", url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Body != nil {
+		defer resp.Body.Close() //nolint:errcheck // not a real issue here
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		return resp, errors.Wrap(responseError(resp), "non-2xx response")
 	}
 
 	err = json.UnmarshalFromReader(resp.Body, t)
@@ -0,0 +1,245 @@
+package httpclient
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+)
+
+// ErrCircuitOpen is the error returned when a request is refused because the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// RetryPolicy controls how the client retries requests that fail transiently
+//
+// Idempotent requests (GET, HEAD, OPTIONS, PUT and DELETE) are retried after network errors and
+// 500, 502, 503 and 504 responses. Other requests are only retried when the response shows that discord
+// did not apply them (429 and 503). A 429 response is retried after the delay discord asks for instead
+// of the backoff delay, unless that is longer than MaxDelay, in which case the 429 is returned.
+// Requests with a body that cannot be replayed are never retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the first (<= 1 disables retries)
+	MaxAttempts int
+	// BaseDelay is the backoff delay before the first retry, doubled for each retry after that
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay and the wait after a 429 (0 for no cap)
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy a new HTTPClient uses
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   250 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// backoff returns the jittered delay before the given retry (starting at 1)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay) && d < math.MaxInt64/2; i++ {
+		d *= 2
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	// equal jitter: half fixed, half random
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) //nolint:gosec // jitter does not need a secure source
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// shouldRetry determines if a request with the given method should be retried after the given outcome
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		// a cancelled or expired context is not transient, and an open breaker should fail fast
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+			return false
+		}
+		return isIdempotent(method)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable:
+		return true
+	case isTransientStatus(resp.StatusCode):
+		return isIdempotent(method)
+	default:
+		return false
+	}
+}
+
+// retryAfter returns the delay discord asked for in a 429 response, if any
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	for _, h := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		v := resp.Header.Get(h)
+		if v == "" {
+			continue
+		}
+
+		secs, err := strconv.ParseFloat(v, 64)
+		if err != nil || secs < 0 {
+			continue
+		}
+
+		return time.Duration(secs * float64(time.Second)), true
+	}
+
+	return 0, false
+}
+
+// sleepCtx waits for d, or until the context ends
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CircuitState is the state of a CircuitBreaker
+type CircuitState int
+
+// These are the states of a CircuitBreaker
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String returns the name of the state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker fails requests fast during an extended outage
+//
+// After Threshold consecutive transient failures (network errors and 5xx responses) the breaker opens
+// and refuses requests with ErrCircuitOpen. Once Cooldown has passed, a single trial request is let through
+// (half-open); its success closes the breaker and its failure opens it again
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	openedAt    time.Time
+	trialActive bool
+}
+
+// NewCircuitBreaker creates a new circuit breaker
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.Cooldown {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// allow determines if a request may proceed, returning the state transition it caused (if any)
+func (b *CircuitBreaker) allow() (bool, CircuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false, b.state, false
+		}
+		b.state = CircuitHalfOpen
+		b.trialActive = true
+		return true, b.state, true
+	case CircuitHalfOpen:
+		if b.trialActive {
+			return false, b.state, false
+		}
+		b.trialActive = true
+		return true, b.state, false
+	default:
+		return true, b.state, false
+	}
+}
+
+// abandon releases a half-open trial whose outcome says nothing about the server (e.g., a cancelled request)
+func (b *CircuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialActive = false
+}
+
+// record registers the outcome of a request, returning the state transition it caused (if any)
+func (b *CircuitBreaker) record(failed bool) (CircuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	prev := b.state
+	b.trialActive = false
+
+	if !failed {
+		b.failures = 0
+		b.state = CircuitClosed
+		return b.state, prev != b.state
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.Threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+
+	return b.state, prev != b.state
+}
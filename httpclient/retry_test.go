@@ -0,0 +1,569 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/nonrecording"
+)
+
+type nopLogger struct{}
+
+func (l nopLogger) Log(kv ...interface{}) error              { return nil }
+func (l nopLogger) Err(m string, e error, kv ...interface{}) {}
+func (l nopLogger) Message(m string, kv ...interface{})      {}
+func (l nopLogger) Printf(f string, a ...interface{})        {}
+
+type nopExporter struct{}
+
+func (nopExporter) ExportSpans(context.Context, []telemetry.ReadOnlySpan) error { return nil }
+func (nopExporter) Shutdown(context.Context) error                              { return nil }
+
+type reply struct {
+	status int
+	header http.Header
+	err    error
+}
+
+// fakeDoer answers requests with a script of replies, repeating the last one once the script runs out
+type fakeDoer struct {
+	replies []reply
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (d *fakeDoer) Do(req *http.Request) (*http.Response, error) {
+	var body string
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		body = string(b)
+	}
+
+	d.mu.Lock()
+	n := len(d.bodies)
+	d.bodies = append(d.bodies, body)
+	d.mu.Unlock()
+
+	if n >= len(d.replies) {
+		n = len(d.replies) - 1
+	}
+	r := d.replies[n]
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	h := r.header
+	if h == nil {
+		h = http.Header{}
+	}
+
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     h,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func (d *fakeDoer) calls() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.bodies)
+}
+
+type testDeps struct {
+	telemeter *telemetry.Telemeter
+	doer      Doer
+}
+
+func (d *testDeps) Logger() Logger                  { return nopLogger{} }
+func (d *testDeps) Telemetry() *telemetry.Telemeter { return d.telemeter }
+func (d *testDeps) HTTPDoer() Doer                  { return d.doer }
+
+func newTestClient(doer Doer) *HTTPClient {
+	return NewHTTPClient(&testDeps{
+		telemeter: telemetry.NewTelemeter("test", "test", "test", nopExporter{}, nonrecording.NewNoopMeterProvider(), 1.0),
+		doer:      doer,
+	})
+}
+
+// streamReader is a body that net/http cannot replay
+type streamReader struct {
+	io.Reader
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		retry  int
+		want   time.Duration // the un-jittered delay
+	}{
+		{name: "first retry", policy: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, retry: 1, want: 100 * time.Millisecond},
+		{name: "doubles", policy: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, retry: 3, want: 400 * time.Millisecond},
+		{name: "capped", policy: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, retry: 5, want: time.Second},
+		{name: "far past the cap", policy: RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, retry: 100, want: time.Second},
+		{name: "uncapped", policy: RetryPolicy{BaseDelay: 100 * time.Millisecond}, retry: 2, want: 200 * time.Millisecond},
+		{name: "uncapped does not overflow", policy: RetryPolicy{BaseDelay: time.Second}, retry: 100, want: time.Second << 33},
+		{name: "no base delay", policy: RetryPolicy{MaxDelay: time.Second}, retry: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 0; i < 100; i++ {
+				d := tt.policy.backoff(tt.retry)
+				if d < tt.want/2 || d > tt.want {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.retry, d, tt.want/2, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	t.Parallel()
+
+	netErr := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "GET ok", method: http.MethodGet, status: http.StatusOK, want: false},
+		{name: "GET not found", method: http.MethodGet, status: http.StatusNotFound, want: false},
+		{name: "GET 429", method: http.MethodGet, status: http.StatusTooManyRequests, want: true},
+		{name: "POST 429", method: http.MethodPost, status: http.StatusTooManyRequests, want: true},
+		{name: "GET 500", method: http.MethodGet, status: http.StatusInternalServerError, want: true},
+		{name: "PUT 502", method: http.MethodPut, status: http.StatusBadGateway, want: true},
+		{name: "DELETE 504", method: http.MethodDelete, status: http.StatusGatewayTimeout, want: true},
+		{name: "POST 500", method: http.MethodPost, status: http.StatusInternalServerError, want: false},
+		{name: "PATCH 502", method: http.MethodPatch, status: http.StatusBadGateway, want: false},
+		{name: "POST 503", method: http.MethodPost, status: http.StatusServiceUnavailable, want: true},
+		{name: "PATCH 503", method: http.MethodPatch, status: http.StatusServiceUnavailable, want: true},
+		{name: "GET 501", method: http.MethodGet, status: http.StatusNotImplemented, want: false},
+		{name: "GET network error", method: http.MethodGet, err: netErr, want: true},
+		{name: "POST network error", method: http.MethodPost, err: netErr, want: false},
+		{name: "GET cancelled", method: http.MethodGet, err: context.Canceled, want: false},
+		{name: "GET deadline", method: http.MethodGet, err: context.DeadlineExceeded, want: false},
+		{name: "GET breaker open", method: http.MethodGet, err: ErrCircuitOpen, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+
+			assert.Equal(t, tt.want, shouldRetry(tt.method, resp, tt.err))
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "none", header: http.Header{}},
+		{name: "whole seconds", header: http.Header{"Retry-After": {"2"}}, want: 2 * time.Second, wantOK: true},
+		{name: "fractional seconds", header: http.Header{"Retry-After": {"0.25"}}, want: 250 * time.Millisecond, wantOK: true},
+		{name: "rate limit reset", header: http.Header{"X-Ratelimit-Reset-After": {"1.5"}}, want: 1500 * time.Millisecond, wantOK: true},
+		{name: "retry-after wins", header: http.Header{"Retry-After": {"1"}, "X-Ratelimit-Reset-After": {"3"}}, want: time.Second, wantOK: true},
+		{name: "bad retry-after falls back", header: http.Header{"Retry-After": {"soon"}, "X-Ratelimit-Reset-After": {"3"}}, want: 3 * time.Second, wantOK: true},
+		{name: "http date is not supported", header: http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}},
+		{name: "negative", header: http.Header{"Retry-After": {"-1"}}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d, ok := retryAfter(&http.Response{Header: tt.header})
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, d)
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker(2, time.Hour)
+	expire := func() {
+		b.mu.Lock()
+		b.openedAt = time.Now().Add(-2 * time.Hour)
+		b.mu.Unlock()
+	}
+
+	ok, state, changed := b.allow()
+	assert.True(t, ok)
+	assert.Equal(t, CircuitClosed, state)
+	assert.False(t, changed)
+
+	// a success resets the failure count
+	b.record(true)
+	b.record(false)
+	b.record(true)
+	assert.Equal(t, CircuitClosed, b.State())
+
+	state, changed = b.record(true)
+	assert.Equal(t, CircuitOpen, state)
+	assert.True(t, changed)
+
+	ok, _, _ = b.allow()
+	assert.False(t, ok, "an open breaker refuses requests")
+
+	// after the cooldown, exactly one trial request is let through
+	expire()
+	assert.Equal(t, CircuitHalfOpen, b.State())
+
+	ok, state, changed = b.allow()
+	assert.True(t, ok)
+	assert.Equal(t, CircuitHalfOpen, state)
+	assert.True(t, changed)
+
+	ok, _, changed = b.allow()
+	assert.False(t, ok, "only one trial at a time")
+	assert.False(t, changed)
+
+	// an abandoned trial frees the slot without changing the state
+	b.abandon()
+	assert.Equal(t, CircuitHalfOpen, b.State())
+
+	ok, _, changed = b.allow()
+	assert.True(t, ok)
+	assert.False(t, changed)
+
+	// a failed trial reopens the breaker at once
+	state, changed = b.record(true)
+	assert.Equal(t, CircuitOpen, state)
+	assert.True(t, changed)
+
+	ok, _, _ = b.allow()
+	assert.False(t, ok)
+
+	// a successful trial closes it
+	expire()
+	ok, _, _ = b.allow()
+	require.True(t, ok)
+
+	state, changed = b.record(false)
+	assert.Equal(t, CircuitClosed, state)
+	assert.True(t, changed)
+
+	ok, _, _ = b.allow()
+	assert.True(t, ok)
+}
+
+func TestHTTPClient_retries(t *testing.T) {
+	t.Parallel()
+
+	netErr := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name       string
+		method     string
+		body       func() io.Reader
+		replies    []reply
+		wantCalls  int
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "GET succeeds",
+			method:     http.MethodGet,
+			replies:    []reply{{status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "GET retried after 500",
+			method:     http.MethodGet,
+			replies:    []reply{{status: http.StatusInternalServerError}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "GET gives up after the last attempt",
+			method:     http.MethodGet,
+			replies:    []reply{{status: http.StatusBadGateway}},
+			wantCalls:  3,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:      "GET retried after a network error",
+			method:    http.MethodGet,
+			replies:   []reply{{err: netErr}, {err: netErr}, {err: netErr}},
+			wantCalls: 3,
+			wantErr:   true,
+		},
+		{
+			name:       "429 retried after the requested delay",
+			method:     http.MethodPost,
+			body:       func() io.Reader { return strings.NewReader(`{"content":"hi"}`) },
+			replies:    []reply{{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"0.001"}}}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "429 longer than the max delay not retried",
+			method:     http.MethodGet,
+			replies:    []reply{{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"60"}}}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "POST retried after 503",
+			method:     http.MethodPost,
+			body:       func() io.Reader { return strings.NewReader(`{"content":"hi"}`) },
+			replies:    []reply{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "POST not retried after 500",
+			method:     http.MethodPost,
+			body:       func() io.Reader { return strings.NewReader(`{"content":"hi"}`) },
+			replies:    []reply{{status: http.StatusInternalServerError}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:      "POST not retried after a network error",
+			method:    http.MethodPost,
+			body:      func() io.Reader { return strings.NewReader(`{"content":"hi"}`) },
+			replies:   []reply{{err: netErr}, {status: http.StatusOK}},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:       "streamed body not retried",
+			method:     http.MethodPost,
+			body:       func() io.Reader { return streamReader{strings.NewReader(`{"content":"hi"}`)} },
+			replies:    []reply{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doer := &fakeDoer{replies: tt.replies}
+			c := newTestClient(doer)
+			c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond})
+			c.SetCircuitBreaker(nil)
+
+			var body io.Reader
+			if tt.body != nil {
+				body = tt.body()
+			}
+
+			resp, err := c.doRequest(context.Background(), nopLogger{}, tt.method, "http://discord.test/api", nil, body)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			}
+
+			assert.Equal(t, tt.wantCalls, doer.calls())
+
+			// every attempt sends the whole body
+			for i, b := range doer.bodies {
+				if tt.body == nil {
+					assert.Empty(t, b, "attempt %d", i+1)
+				} else {
+					assert.Equal(t, `{"content":"hi"}`, b, "attempt %d", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPClient_retryDeadline(t *testing.T) {
+	t.Parallel()
+
+	doer := &fakeDoer{replies: []reply{{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"60"}}}}}
+	c := newTestClient(doer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// waiting out the rate limit would overrun the deadline, so the 429 is handed back at once
+	resp, err := c.doRequest(ctx, nopLogger{}, http.MethodGet, "http://discord.test/api", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 1, doer.calls())
+}
+
+func TestHTTPClient_circuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	doer := &fakeDoer{replies: []reply{
+		{status: http.StatusInternalServerError},
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusBadGateway},
+		{status: http.StatusOK},
+	}}
+	c := newTestClient(doer)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+	b := NewCircuitBreaker(2, time.Hour)
+	c.SetCircuitBreaker(b)
+
+	expire := func() {
+		b.mu.Lock()
+		b.openedAt = time.Now().Add(-2 * time.Hour)
+		b.mu.Unlock()
+	}
+
+	get := func() (*http.Response, error) {
+		return c.doRequest(context.Background(), nopLogger{}, http.MethodGet, "http://discord.test/api", nil, nil)
+	}
+
+	// closed: failures are counted until the threshold opens the breaker
+	_, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, CircuitClosed, b.State())
+
+	_, err = get()
+	require.NoError(t, err)
+	assert.Equal(t, CircuitOpen, b.State())
+
+	// open: requests are refused without reaching discord
+	_, err = get()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, doer.calls())
+
+	// half-open: a failed trial reopens the breaker
+	expire()
+	resp, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, CircuitOpen, b.State())
+
+	_, err = get()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3, doer.calls())
+
+	// half-open: a successful trial closes it
+	expire()
+	resp, err = get()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, CircuitClosed, b.State())
+
+	_, err = get()
+	require.NoError(t, err)
+	assert.Equal(t, 5, doer.calls())
+}
+
+// cancelDoer cancels the request context before failing, as if the caller had given up mid-request
+type cancelDoer struct {
+	cancel context.CancelFunc
+}
+
+func (d cancelDoer) Do(req *http.Request) (*http.Response, error) {
+	d.cancel()
+	return nil, req.Context().Err()
+}
+
+func TestHTTPClient_circuitBreakerAbandonedTrial(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newTestClient(cancelDoer{cancel: cancel})
+
+	b := NewCircuitBreaker(1, time.Hour)
+	b.record(true)
+	b.openedAt = time.Now().Add(-2 * time.Hour)
+	c.SetCircuitBreaker(b)
+
+	_, err := c.doRequest(ctx, nopLogger{}, http.MethodGet, "http://discord.test/api", nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the cancelled trial says nothing about discord, so the next request may try again
+	assert.Equal(t, CircuitHalfOpen, b.State())
+
+	ok, _, _ := b.allow()
+	assert.True(t, ok)
+}
+
+// closeReader is a request body that records whether it was closed
+type closeReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestHTTPClient_unsentBodiesClosed(t *testing.T) {
+	t.Parallel()
+
+	t.Run("breaker open", func(t *testing.T) {
+		t.Parallel()
+
+		doer := &fakeDoer{replies: []reply{{status: http.StatusOK}}}
+		c := newTestClient(doer)
+
+		b := NewCircuitBreaker(1, time.Hour)
+		b.record(true)
+		c.SetCircuitBreaker(b)
+
+		body := &closeReader{Reader: strings.NewReader("data")}
+		_, err := c.doRequest(context.Background(), nopLogger{}, http.MethodPost, "http://discord.test/api", nil, body)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.True(t, body.closed)
+		assert.Equal(t, 0, doer.calls())
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		t.Parallel()
+
+		doer := &fakeDoer{replies: []reply{{status: http.StatusOK}}}
+		c := newTestClient(doer)
+
+		body := &closeReader{Reader: strings.NewReader("data")}
+		_, err := c.doRequest(context.Background(), nopLogger{}, "BAD METHOD", "http://discord.test/api", nil, body)
+		assert.Error(t, err)
+		assert.True(t, body.closed)
+		assert.Equal(t, 0, doer.calls())
+	})
+}
@@ -0,0 +1,223 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+// Args are the values substituted for the {name} placeholders of a message
+type Args map[string]interface{}
+
+// Catalog holds the messages of each locale, keyed like "signup.success"
+//
+// Locales are discord's locale codes, like "de", "en-US" or "es-ES". A lookup tries the requested locale,
+// then its fallbacks (see SetFallbacks), then its base language ("es" for "es-ES"), and finally the
+// default locale of the catalog
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]string
+	fallbacks     map[string][]string
+}
+
+// NewCatalog creates an empty Catalog that falls back to messages of the default locale
+func NewCatalog(defaultLocale string) *Catalog {
+	return &Catalog{
+		defaultLocale: defaultLocale,
+		messages:      map[string]map[string]string{},
+		fallbacks:     map[string][]string{},
+	}
+}
+
+// DefaultLocale returns the locale used when no other locale has a message
+func (c *Catalog) DefaultLocale() string {
+	return c.defaultLocale
+}
+
+// Add adds messages for a locale, replacing any existing messages with the same keys
+func (c *Catalog) Add(locale string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m, ok := c.messages[locale]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		c.messages[locale] = m
+	}
+
+	for k, v := range messages {
+		m[k] = v
+	}
+}
+
+// SetFallbacks sets the locales to try, in order, when a locale is missing a message (like "pt-BR" for "pt-PT")
+func (c *Catalog) SetFallbacks(locale string, fallbacks ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fallbacks[locale] = append([]string(nil), fallbacks...)
+}
+
+// Locales lists the locales that have messages, sorted
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+
+	return locales
+}
+
+// chain lists the locales to try for the requested ones, in order and without repeats
+func (c *Catalog) chain(locales []string) []string {
+	var out []string
+	seen := map[string]bool{}
+
+	var visit func(string)
+	visit = func(l string) {
+		if l == "" || seen[l] {
+			return
+		}
+
+		seen[l] = true
+		out = append(out, l)
+
+		for _, f := range c.fallbacks[l] {
+			visit(f)
+		}
+
+		if i := strings.IndexByte(l, '-'); i > 0 {
+			visit(l[:i])
+		}
+	}
+
+	for _, l := range locales {
+		visit(l)
+	}
+	visit(c.defaultLocale)
+
+	return out
+}
+
+// Lookup finds the unformatted message for the key in the first of the locales (or their fallbacks) that has it
+func (c *Catalog) Lookup(key string, locales ...string) (msg, locale string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, l := range c.chain(locales) {
+		if msg, ok = c.messages[l][key]; ok {
+			return msg, l, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Translate formats the message for the key in the first of the locales (or their fallbacks) that has it
+//
+// If no locale has the message, the key itself is returned, so that missing messages are easy to spot
+func (c *Catalog) Translate(key string, args Args, locales ...string) string {
+	msg, _, ok := c.Lookup(key, locales...)
+	if !ok {
+		return key
+	}
+
+	return Format(msg, args)
+}
+
+// T formats the message for the key in the locale (see Translate)
+func (c *Catalog) T(locale, key string, args Args) string {
+	return c.Translate(key, args, locale)
+}
+
+// Localizations collects the message for the key in every locale but the default one, as used for the
+// name_localizations and description_localizations of application commands
+//
+// Placeholders are not substituted. It returns nil if no other locale has the message
+func (c *Catalog) Localizations(key string) entity.Localizations {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out entity.Localizations
+	for l, m := range c.messages {
+		if l == c.defaultLocale {
+			continue
+		}
+
+		if msg, ok := m[key]; ok {
+			if out == nil {
+				out = entity.Localizations{}
+			}
+			out[l] = msg
+		}
+	}
+
+	return out
+}
+
+// Format substitutes the args for the {name} placeholders of a message
+//
+// Placeholders without a matching arg are left as they are, and "{{" is a literal "{"
+func Format(msg string, args Args) string {
+	if !strings.Contains(msg, "{") {
+		return msg
+	}
+
+	b := strings.Builder{}
+	b.Grow(len(msg))
+
+	for {
+		i := strings.IndexByte(msg, '{')
+		if i < 0 {
+			_, _ = b.WriteString(msg)
+			break
+		}
+
+		_, _ = b.WriteString(msg[:i])
+		msg = msg[i:]
+
+		if strings.HasPrefix(msg, "{{") {
+			_ = b.WriteByte('{')
+			msg = msg[2:]
+			continue
+		}
+
+		j := strings.IndexByte(msg, '}')
+		if j < 0 {
+			_, _ = b.WriteString(msg)
+			break
+		}
+
+		if v, ok := args[msg[1:j]]; ok {
+			_, _ = b.WriteString(fmt.Sprint(v))
+		} else {
+			_, _ = b.WriteString(msg[:j+1])
+		}
+		msg = msg[j+1:]
+	}
+
+	return b.String()
+}
+
+// ContextKey is a wrapper type for our keys attached to a context
+type ContextKey string
+
+// WithCatalog provides a derived context including the catalog
+func WithCatalog(ctx context.Context, c *Catalog) context.Context {
+	return context.WithValue(ctx, ContextKey("catalog"), c)
+}
+
+// GetCatalog retrieves the catalog from a context, if it exists
+func GetCatalog(ctx context.Context) (*Catalog, bool) {
+	c, ok := ctx.Value(ContextKey("catalog")).(*Catalog)
+	return c, ok && c != nil
+}
@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gsmcwhirter/discord-bot-lib/v24/discordapi/entity"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+
+	c := NewCatalog("en-US")
+	err := c.LoadFS(fstest.MapFS{
+		"locales/en-US.json": {Data: []byte(`{"signup": {"success": "Signed up for {event}!", "full": "{event} is full"}, "hello": "Hello"}`)},
+		"locales/de.json":    {Data: []byte(`{"signup": {"success": "Angemeldet für {event}!"}}`)},
+		"locales/pt-BR.json": {Data: []byte(`{"hello": "Olá"}`)},
+		"locales/README.md":  {Data: []byte(`not a catalog`)},
+	}, "locales")
+	if err != nil {
+		t.Fatalf("LoadFS() error = %v", err)
+	}
+
+	return c
+}
+
+func TestCatalog_Translate(t *testing.T) {
+	t.Parallel()
+
+	c := testCatalog(t)
+	c.SetFallbacks("pt-PT", "pt-BR")
+
+	tests := []struct {
+		name    string
+		key     string
+		locales []string
+		want    string
+	}{
+		{name: "exact locale", key: "signup.success", locales: []string{"de"}, want: "Angemeldet für Raid!"},
+		{name: "base language", key: "signup.success", locales: []string{"de-AT"}, want: "Angemeldet für Raid!"},
+		{name: "default locale", key: "signup.full", locales: []string{"de"}, want: "Raid is full"},
+		{name: "explicit fallback", key: "hello", locales: []string{"pt-PT"}, want: "Olá"},
+		{name: "second preference", key: "hello", locales: []string{"fr", "pt-BR"}, want: "Olá"},
+		{name: "no locale", key: "hello", want: "Hello"},
+		{name: "missing message", key: "signup.missing", locales: []string{"de"}, want: "signup.missing"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := c.Translate(tt.key, Args{"event": "Raid"}, tt.locales...); got != tt.want {
+				t.Errorf("Translate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		msg  string
+		args Args
+		want string
+	}{
+		{name: "no placeholders", msg: "hi", want: "hi"},
+		{name: "placeholders", msg: "{who} has {n} points", args: Args{"who": "Ann", "n": 3}, want: "Ann has 3 points"},
+		{name: "missing arg", msg: "{who} has {n} points", args: Args{"n": 3}, want: "{who} has 3 points"},
+		{name: "escaped brace", msg: "{{who} and {who}", args: Args{"who": "Ann"}, want: "{who} and Ann"},
+		{name: "unclosed", msg: "oops {who", args: Args{"who": "Ann"}, want: "oops {who"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Format(tt.msg, tt.args); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCatalog_Localizations(t *testing.T) {
+	t.Parallel()
+
+	c := testCatalog(t)
+
+	if got, want := c.Localizations("signup.success"), (entity.Localizations{"de": "Angemeldet für {event}!"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Localizations() = %v, want %v", got, want)
+	}
+
+	if got := c.Localizations("signup.full"); got != nil {
+		t.Errorf("Localizations() = %v, want nil", got)
+	}
+
+	if got, want := c.Locales(), []string{"de", "en-US", "pt-BR"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Locales() = %v, want %v", got, want)
+	}
+}
+
+func TestCatalog_AddJSON_bad(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalog("en-US")
+	if err := c.AddJSON("en-US", []byte(`{"count": 3}`)); err == nil {
+		t.Errorf("AddJSON() expected an error for a non-string message")
+	}
+}
+
+func TestGetCatalog(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := GetCatalog(context.Background()); ok {
+		t.Errorf("GetCatalog() found a catalog in an empty context")
+	}
+
+	c := NewCatalog("en-US")
+	if got, ok := GetCatalog(WithCatalog(context.Background(), c)); !ok || got != c {
+		t.Errorf("GetCatalog() = %v, %v", got, ok)
+	}
+}
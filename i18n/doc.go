@@ -0,0 +1,2 @@
+// Package i18n provides message catalogs for responding to users in their own locale
+package i18n
@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gsmcwhirter/go-util/v10/errors"
+	"github.com/gsmcwhirter/go-util/v10/json"
+)
+
+// ErrBadMessage is returned when a catalog file has a message that is not a string
+var ErrBadMessage = errors.New("catalog messages must be strings")
+
+// AddJSON adds the messages of a locale from a json object
+//
+// Nested objects are flattened into dotted keys, so {"signup": {"success": "..."}} has the key "signup.success"
+func (c *Catalog) AddJSON(locale string, b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return errors.Wrap(err, "could not parse messages", "locale", locale)
+	}
+
+	messages := map[string]string{}
+	if err := flatten("", raw, messages); err != nil {
+		return errors.Wrap(err, "could not read messages", "locale", locale)
+	}
+
+	c.Add(locale, messages)
+
+	return nil
+}
+
+func flatten(prefix string, raw map[string]interface{}, out map[string]string) error {
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			out[prefix+k] = v
+		case map[string]interface{}:
+			if err := flatten(prefix+k+".", v, out); err != nil {
+				return err
+			}
+		default:
+			return errors.Wrap(ErrBadMessage, "could not read message", "key", prefix+k)
+		}
+	}
+
+	return nil
+}
+
+// LoadFS adds the messages of every <locale>.json file in the directory, like "de.json" or "es-ES.json"
+//
+// This works with an embed.FS, so catalogs can be compiled into the bot
+func (c *Catalog) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return errors.Wrap(err, "could not list catalog files", "dir", dir)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		p := path.Join(dir, e.Name())
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return errors.Wrap(err, "could not read catalog file", "path", p)
+		}
+
+		if err := c.AddJSON(strings.TrimSuffix(e.Name(), ".json"), b); err != nil {
+			return errors.Wrap(err, "could not load catalog file", "path", p)
+		}
+	}
+
+	return nil
+}
+
+// LoadDir adds the messages of every <locale>.json file in a directory on disk (see LoadFS)
+func (c *Catalog) LoadDir(dir string) error {
+	return c.LoadFS(os.DirFS(dir), ".")
+}
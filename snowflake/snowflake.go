@@ -2,8 +2,12 @@ package snowflake
 
 import (
 	"strconv"
+	"time"
 )
 
+// Epoch is the first millisecond of 2015, the epoch of discord snowflake timestamps
+const Epoch = 1420070400000
+
 // Snowflake represents a discord-like snowflake id
 type Snowflake uint64
 
@@ -18,3 +22,20 @@ func FromString(v string) (s Snowflake, err error) {
 	s = Snowflake(i)
 	return
 }
+
+// Time returns the creation time encoded in the snowflake
+func (s Snowflake) Time() time.Time {
+	return time.UnixMilli(int64(s>>22) + Epoch)
+}
+
+// FromTime creates the smallest snowflake with the provided creation time
+//
+// This is useful as a pagination boundary (e.g., "messages before this time")
+func FromTime(t time.Time) Snowflake {
+	ms := t.UnixMilli() - Epoch
+	if ms < 0 {
+		return 0
+	}
+
+	return Snowflake(ms) << 22
+}
@@ -2,6 +2,7 @@ package snowflake
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -16,3 +17,13 @@ func TestSnowflake(t *testing.T) {
 		assert.Equal(t, Snowflake(12345), s)
 	}
 }
+
+func TestSnowflake_Time(t *testing.T) {
+	t.Parallel()
+
+	s := Snowflake(175928847299117063)
+	assert.Equal(t, int64(1462015105796), s.Time().UnixMilli())
+
+	assert.Equal(t, Snowflake(175928847298985984), FromTime(s.Time()))
+	assert.Equal(t, Snowflake(0), FromTime(time.Unix(0, 0)))
+}
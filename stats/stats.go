@@ -14,9 +14,16 @@ const (
 	InteractionResponsesCount     = "interaction_responses_ct"
 	InteractionAutocompletesCount = "interaction_autocompletes_ct"
 	InteractionDeferralsCount     = "interaction_deferrals_ct"
+	InteractionFollowupsCount     = "interaction_followups_ct"
 	MessagesPostedCount           = "messages_posted_ct"
+	MessagesEditedCount           = "messages_edited_ct"
+	MessagesDeletedCount          = "messages_deleted_ct"
+	ModerationActionsCount        = "moderation_actions_ct"
 	RawEventsCount                = "raw_events_ct"
 	OpCodesCount                  = "opcode_events_ct"
+	HTTPRetriesCount              = "http_retries_ct"
+	CircuitBreakerChangesCount    = "circuit_breaker_changes_ct"
+	HandlerLatency                = "handler_latency_ms"
 )
 
 // Known metric tag names
@@ -24,6 +31,10 @@ const (
 	TagStatus    = "status"
 	TagEventName = "event_name"
 	TagOpCode    = "op_code"
+	TagAction    = "action"
+	TagMethod    = "method"
+	TagState     = "state"
+	TagCommand   = "command"
 )
 
 // IncCounter increments a counter with the given value
@@ -33,6 +44,17 @@ func IncCounter(ctx context.Context, t *telemetry.Telemeter, pkg, name string, v
 		return errors.Wrap(err, "could not create counter")
 	}
 
-	counter.Add(ctx, 1, tags...)
+	counter.Add(ctx, v, tags...)
+	return nil
+}
+
+// RecordValue records a value (like a latency) in a histogram
+func RecordValue(ctx context.Context, t *telemetry.Telemeter, pkg, name string, v float64, tags ...telemetry.KeyValue) error {
+	histogram, err := t.Meter(pkg).SyncFloat64().Histogram(name)
+	if err != nil {
+		return errors.Wrap(err, "could not create histogram")
+	}
+
+	histogram.Record(ctx, v, tags...)
 	return nil
 }